@@ -0,0 +1,122 @@
+// Command fizzbuzz-load generates reproducible load against a fizzbuzz-server.
+//
+// It either replays a JSONL file of parameter sets, one per line:
+//
+//	fizzbuzz-load -target http://localhost:8080 -input requests.jsonl -n 10000
+//
+// or synthesises a Zipf-distributed workload over -keys distinct parameter
+// sets, so that a few sets dominate like they would in real traffic:
+//
+//	fizzbuzz-load -keys 100 -zipf-s 1.2 -n 50000 -rate 500 -concurrency 16
+//
+// When the run ends it reports latency percentiles, errors by status and
+// compares the most frequent request it sent with the one /stats reports.
+// Use -reset (with -admin-token) so earlier traffic does not skew the
+// comparison.
+package main
+
+import (
+	"context"
+	"errors"
+	"fizzbuzz-server/pkg/client"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "fizzbuzz-load:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// run sends the load selected by args and writes its report to stdout,
+// warnings going to stderr
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("fizzbuzz-load", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	target := fs.String("target", "http://localhost:8080", "server base URL")
+	apiKey := fs.String("api-key", os.Getenv("FIZZBUZZ_API_KEY"), "API key")
+	adminToken := fs.String("admin-token", os.Getenv("FIZZBUZZ_ADMIN_TOKEN"), "admin token, needed by -reset")
+	reset := fs.Bool("reset", false, "reset the server stats before the run")
+	input := fs.String("input", "", "JSONL file of parameter sets to replay, - for stdin")
+	total := fs.Int("n", 0, "number of requests; 0 replays -input once, or runs until -duration")
+	duration := fs.Duration("duration", 0, "stop after this long")
+	rate := fs.Float64("rate", 0, "requests per second, 0 for as fast as possible")
+	concurrency := fs.Int("concurrency", 8, "number of concurrent requests")
+	timeout := fs.Duration("timeout", 10*time.Second, "per-request timeout")
+	keys := fs.Int("keys", 100, "synthetic workload: number of distinct parameter sets")
+	zipfS := fs.Float64("zipf-s", 1.1, "synthetic workload: Zipf skew, > 1")
+	zipfV := fs.Float64("zipf-v", 1, "synthetic workload: Zipf offset, >= 1")
+	limit := fs.Int("limit", 100, "synthetic workload: limit of the first parameter set")
+	seed := fs.Uint64("seed", 1, "synthetic workload: random seed")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *concurrency < 1 {
+		return fmt.Errorf("-concurrency must be at least 1")
+	}
+	if *input == "" && *total == 0 && *duration == 0 {
+		return fmt.Errorf("a synthetic workload needs -n or -duration")
+	}
+
+	var w workload
+	if *input != "" {
+		params, err := readParams(*input, stdin)
+		if err != nil {
+			return err
+		}
+		w = newReplay(params, *total)
+	} else {
+		var err error
+		w, err = newZipf(*keys, *zipfS, *zipfV, *limit, *seed, *total)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Throttling is part of what is measured, so it is reported instead of retried
+	c := client.New(*target,
+		client.WithAPIKey(*apiKey),
+		client.WithAdminToken(*adminToken),
+		client.WithUserAgent("fizzbuzz-load"),
+		client.WithRetryPolicy(client.RetryPolicy{}),
+	)
+	if *reset {
+		if err := c.ResetStats(ctx); err != nil {
+			return fmt.Errorf("reset stats: %w", err)
+		}
+	}
+
+	if *duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *duration)
+		defer cancel()
+	}
+
+	r := runner{client: c, concurrency: *concurrency, rate: *rate, timeout: *timeout}
+	res := r.run(ctx, w)
+
+	// the run context may be done already, the stats query gets its own and,
+	// unlike the load itself, waits out the rate limit
+	statsCtx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	observed, err := c.Stats(statsCtx, 0, client.WithRequestRetryPolicy(client.RetryPolicy{
+		MaxRetries: 10,
+		BaseDelay:  time.Second,
+		MaxDelay:   time.Minute,
+	}))
+	if err != nil {
+		fmt.Fprintln(stderr, "fizzbuzz-load: query stats:", err)
+	}
+	return res.report(stdout, observed, *reset)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun_Replay(t *testing.T) {
+	var resets atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/admin/stats/reset":
+			resets.Add(1)
+		case "/stats":
+			_, _ = w.Write([]byte(`{"most_frequent_request":{"int1":3,"int2":5,"limit":15,"str1":"fizz","str2":"buzz","hits":4}}`))
+		case "/fizzbuzz":
+			if r.URL.Query().Get("limit") == "10" {
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			_, _ = w.Write([]byte(`{"result":[]}`))
+		}
+	}))
+	defer srv.Close()
+
+	stdin := strings.NewReader("{\"int1\":3,\"int2\":5,\"limit\":15}\n{\"int1\":2,\"int2\":7,\"limit\":10}\n")
+	var stdout, stderr bytes.Buffer
+	err := run(context.Background(), []string{"-target", srv.URL, "-input", "-", "-n", "6", "-concurrency", "2", "-reset"}, stdin, &stdout, &stderr)
+	require.NoError(t, err)
+	assert.Empty(t, stderr.String())
+	assert.Equal(t, int32(1), resets.Load())
+
+	report := stdout.String()
+	for _, line := range []string{
+		"requests      6\n",
+		"succeeded     3\n",
+		"failed        3 (50.00%)\n",
+		"  429         3\n",
+		"expected top  int1=3 int2=5 limit=15 str1=fizz str2=buzz (3 hits)\n",
+		"top matches   yes\n",
+	} {
+		assert.Contains(t, report, line)
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"no concurrency", []string{"-n", "1", "-concurrency", "0"}, "-concurrency must be at least 1"},
+		{"unbounded synthetic workload", nil, "a synthetic workload needs -n or -duration"},
+		{"invalid workload", []string{"-n", "1", "-zipf-s", "0.5"}, "invalid Zipf parameters: need -zipf-s > 1 and -zipf-v >= 1"},
+		{"empty input", []string{"-input", "-"}, "-: no parameter sets"},
+		{"reset rejected", []string{"-target", srv.URL, "-n", "1", "-reset"}, "reset stats: fizzbuzz-server: 401 Unauthorized: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := run(context.Background(), tt.args, strings.NewReader(""), &bytes.Buffer{}, &bytes.Buffer{})
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fizzbuzz-server/pkg/client"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// report writes a summary of the run. observed is the /stats response, nil
+// when it could not be fetched.
+func (res *result) report(out io.Writer, observed *client.StatsResponse, reset bool) error {
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+
+	total := len(res.latencies)
+	failed := 0
+	for _, count := range res.errors {
+		failed += count
+	}
+	fmt.Fprintf(tw, "requests\t%d\n", total)
+	fmt.Fprintf(tw, "duration\t%s\n", res.elapsed.Round(time.Millisecond))
+	if secs := res.elapsed.Seconds(); secs > 0 {
+		fmt.Fprintf(tw, "throughput\t%.1f req/s\n", float64(total)/secs)
+	}
+	fmt.Fprintf(tw, "succeeded\t%d\n", total-failed)
+	fmt.Fprintf(tw, "failed\t%d (%.2f%%)\n", failed, percent(failed, total))
+
+	statuses := make([]string, 0, len(res.errors))
+	for status := range res.errors {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	for _, status := range statuses {
+		fmt.Fprintf(tw, "  %s\t%d\n", status, res.errors[status])
+	}
+
+	latencies := slices.Clone(res.latencies)
+	slices.Sort(latencies)
+	for _, p := range []float64{50, 90, 95, 99, 100} {
+		name := fmt.Sprintf("p%g", p)
+		if p == 100 {
+			name = "max"
+		}
+		fmt.Fprintf(tw, "latency %s\t%s\n", name, percentile(latencies, p).Round(time.Microsecond))
+	}
+
+	expected, expectedHits := res.top()
+	fmt.Fprintf(tw, "expected top\t%s\n", describe(expected, expectedHits))
+	if observed != nil {
+		top := observed.MostFrequentRequest
+		fmt.Fprintf(tw, "observed top\t%s\n", describe(client.Params{
+			Int1: top.Int1, Int2: top.Int2, Limit: top.Limit, Str1: top.Str1, Str2: top.Str2,
+		}, top.Hits))
+
+		match := expectedHits > 0 && top.Int1 == expected.Int1 && top.Int2 == expected.Int2 &&
+			top.Limit == expected.Limit && top.Str1 == expected.Str1 && top.Str2 == expected.Str2
+		verdict := "no"
+		if match {
+			verdict = "yes"
+		}
+		if !reset {
+			verdict += " (stats not reset, earlier traffic is included)"
+		}
+		fmt.Fprintf(tw, "top matches\t%s\n", verdict)
+	}
+	return tw.Flush()
+}
+
+// top returns the most frequent successful parameter set, ties broken by
+// stats key like the server does
+func (res *result) top() (client.Params, int) {
+	bestKey, bestHits := "", 0
+	for key, hits := range res.hits {
+		if hits > bestHits || (hits == bestHits && key < bestKey) {
+			bestKey, bestHits = key, hits
+		}
+	}
+	return res.params[bestKey], bestHits
+}
+
+func describe(p client.Params, hits int) string {
+	if hits == 0 {
+		return "none"
+	}
+	return fmt.Sprintf("int1=%d int2=%d limit=%d str1=%s str2=%s (%d hits)",
+		p.Int1, p.Int2, p.Limit, p.Str1, p.Str2, hits)
+}
+
+// percentile returns the nearest-rank percentile p of sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[min(max(rank, 1), len(sorted))-1]
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(n) / float64(total)
+}
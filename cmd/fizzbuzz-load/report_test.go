@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"fizzbuzz-server/pkg/client"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPercentile(t *testing.T) {
+	ms := func(ns ...int) []time.Duration {
+		d := make([]time.Duration, len(ns))
+		for i, n := range ns {
+			d[i] = time.Duration(n) * time.Millisecond
+		}
+		return d
+	}
+	tenth := ms(1, 2, 3, 4, 5, 6, 7, 8, 9, 10)
+
+	tests := []struct {
+		name   string
+		sorted []time.Duration
+		p      float64
+		want   time.Duration
+	}{
+		{"empty", nil, 50, 0},
+		{"single", ms(7), 99, 7 * time.Millisecond},
+		{"median", tenth, 50, 5 * time.Millisecond},
+		{"nearest rank rounds up", tenth, 91, 10 * time.Millisecond},
+		{"p90", tenth, 90, 9 * time.Millisecond},
+		{"max", tenth, 100, 10 * time.Millisecond},
+		{"min", tenth, 0, time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, percentile(tt.sorted, tt.p))
+		})
+	}
+}
+
+func TestResult_Report(t *testing.T) {
+	res := &result{errors: map[string]int{}, hits: map[string]int{}, params: map[string]client.Params{}}
+	fizz := client.Params{Int1: 3, Int2: 5, Limit: 15}
+	other := client.Params{Int1: 2, Int2: 7, Limit: 10, Str1: "a,b", Str2: "c"}
+	for i := range 4 {
+		res.record(fizz, time.Duration(4-i)*time.Millisecond, nil)
+	}
+	res.record(other, 5*time.Millisecond, nil)
+	res.record(other, 6*time.Millisecond, &client.Error{StatusCode: 429})
+	res.record(other, 7*time.Millisecond, &client.Error{StatusCode: 429})
+	res.record(other, 8*time.Millisecond, assert.AnError)
+	res.elapsed = 2 * time.Second
+
+	observed := &client.StatsResponse{MostFrequentRequest: client.StatsEntry{
+		Int1: 3, Int2: 5, Limit: 15, Str1: "fizz", Str2: "buzz", Hits: 4,
+	}}
+	var out bytes.Buffer
+	require.NoError(t, res.report(&out, observed, true))
+	assert.Equal(t, `requests      8
+duration      2s
+throughput    4.0 req/s
+succeeded     5
+failed        3 (37.50%)
+  429         2
+  transport   1
+latency p50   4ms
+latency p90   8ms
+latency p95   8ms
+latency p99   8ms
+latency max   8ms
+expected top  int1=3 int2=5 limit=15 str1=fizz str2=buzz (4 hits)
+observed top  int1=3 int2=5 limit=15 str1=fizz str2=buzz (4 hits)
+top matches   yes
+`, out.String())
+
+	// without reset the verdict warns about earlier traffic
+	observed.MostFrequentRequest.Limit = 30
+	out.Reset()
+	require.NoError(t, res.report(&out, observed, false))
+	assert.Contains(t, out.String(), "top matches   no (stats not reset, earlier traffic is included)\n")
+
+	// the stats may not have been fetched
+	out.Reset()
+	require.NoError(t, res.report(&out, nil, true))
+	assert.NotContains(t, out.String(), "observed top")
+}
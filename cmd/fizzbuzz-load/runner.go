@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fizzbuzz-server/internal/binding"
+	"fizzbuzz-server/internal/services"
+	"fizzbuzz-server/pkg/client"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultWords are the words the server gives to requests without them
+var defaultWords = binding.Defaults(client.Params{})
+
+// runner sends the requests of a workload with bounded concurrency and rate
+type runner struct {
+	client      *client.Client
+	concurrency int
+	rate        float64
+	timeout     time.Duration
+}
+
+// run sends requests until the workload is done or ctx is cancelled. Requests
+// in flight when ctx is cancelled still complete, within the request timeout.
+func (r *runner) run(ctx context.Context, w workload) *result {
+	res := &result{
+		errors: map[string]int{},
+		hits:   map[string]int{},
+		params: map[string]client.Params{},
+		start:  time.Now(),
+	}
+
+	jobs := make(chan client.Params, r.concurrency)
+	var wg sync.WaitGroup
+	for range r.concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				r.send(p, res)
+			}
+		}()
+	}
+
+	r.dispatch(ctx, w, jobs)
+	close(jobs)
+	wg.Wait()
+	res.elapsed = time.Since(res.start)
+	return res
+}
+
+func (r *runner) dispatch(ctx context.Context, w workload, jobs chan<- client.Params) {
+	var tick <-chan time.Time
+	if r.rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / r.rate))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		p, ok := w.next()
+		if !ok {
+			return
+		}
+		if tick != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-tick:
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case jobs <- p:
+		}
+	}
+}
+
+func (r *runner) send(p client.Params, res *result) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	_, err := r.client.Generate(ctx, p)
+	res.record(p, time.Since(start), err)
+}
+
+// result collects the outcome of every request
+type result struct {
+	mu        sync.Mutex
+	start     time.Time
+	elapsed   time.Duration
+	latencies []time.Duration
+	errors    map[string]int // by HTTP status, or "transport"
+	hits      map[string]int // successful requests by stats key
+	params    map[string]client.Params
+}
+
+func (res *result) record(p client.Params, latency time.Duration, err error) {
+	res.mu.Lock()
+	defer res.mu.Unlock()
+
+	res.latencies = append(res.latencies, latency)
+	if err != nil {
+		var apiErr *client.Error
+		if errors.As(err, &apiErr) {
+			res.errors[strconv.Itoa(apiErr.StatusCode)]++
+		} else {
+			res.errors["transport"]++
+		}
+		return
+	}
+
+	// the server counts requests after applying the default words
+	if p.Str1 == "" {
+		p.Str1 = defaultWords["str1"]
+	}
+	if p.Str2 == "" {
+		p.Str2 = defaultWords["str2"]
+	}
+	key := services.StatsKey(p.Int1, p.Int2, p.Limit, p.Str1, p.Str2)
+	res.hits[key]++
+	res.params[key] = p
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fizzbuzz-server/pkg/client"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"strings"
+)
+
+// workload produces the parameter sets to send, in order. It is only used by
+// the dispatching goroutine.
+type workload interface {
+	// next returns the next parameter set, false once the workload is done
+	next() (client.Params, bool)
+}
+
+// replay sends the parameter sets of a file in order, looping over them
+// until total requests have been sent, or once when total is 0
+type replay struct {
+	params []client.Params
+	total  int
+	sent   int
+}
+
+func newReplay(params []client.Params, total int) *replay {
+	if total == 0 {
+		total = len(params)
+	}
+	return &replay{params: params, total: total}
+}
+
+func (r *replay) next() (client.Params, bool) {
+	if r.sent >= r.total {
+		return client.Params{}, false
+	}
+	p := r.params[r.sent%len(r.params)]
+	r.sent++
+	return p, true
+}
+
+// readParams reads one JSON parameter set per line, blank lines are skipped
+func readParams(path string, stdin io.Reader) ([]client.Params, error) {
+	in := stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var params []client.Params
+	scanner := bufio.NewScanner(in)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		p := client.Params{}
+		if err := json.Unmarshal([]byte(text), &p); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		params = append(params, p)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(params) == 0 {
+		return nil, fmt.Errorf("%s: no parameter sets", path)
+	}
+	return params, nil
+}
+
+// zipf draws from keys parameter sets with Zipf-distributed frequencies,
+// the first set being the most frequent. The sets differ by their limit.
+type zipf struct {
+	dist  *rand.Zipf
+	limit int
+	total int // 0 means unbounded
+	sent  int
+}
+
+func newZipf(keys int, s, v float64, limit int, seed uint64, total int) (*zipf, error) {
+	if keys < 1 {
+		return nil, fmt.Errorf("-keys must be at least 1")
+	}
+	if limit < 1 {
+		return nil, fmt.Errorf("-limit must be at least 1")
+	}
+	dist := rand.NewZipf(rand.New(rand.NewPCG(seed, seed)), s, v, uint64(keys-1))
+	if dist == nil {
+		return nil, fmt.Errorf("invalid Zipf parameters: need -zipf-s > 1 and -zipf-v >= 1")
+	}
+	return &zipf{dist: dist, limit: limit, total: total}, nil
+}
+
+func (z *zipf) next() (client.Params, bool) {
+	if z.total > 0 && z.sent >= z.total {
+		return client.Params{}, false
+	}
+	z.sent++
+	return client.Params{
+		Int1:  3,
+		Int2:  5,
+		Limit: z.limit + int(z.dist.Uint64()),
+		Str1:  "fizz",
+		Str2:  "buzz",
+	}, true
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fizzbuzz-server/pkg/client"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []client.Params
+		wantErr string
+	}{
+		{
+			name:  "sets and blank lines",
+			input: "{\"int1\":3,\"int2\":5,\"limit\":15}\n\n  \n{\"int1\":2,\"int2\":7,\"limit\":10,\"str1\":\"a\",\"str2\":\"b\"}\n",
+			want: []client.Params{
+				{Int1: 3, Int2: 5, Limit: 15},
+				{Int1: 2, Int2: 7, Limit: 10, Str1: "a", Str2: "b"},
+			},
+		},
+		{
+			name:    "invalid line",
+			input:   "{\"int1\":3,\"int2\":5,\"limit\":15}\n\n{\"int1\":\"three\"}\n",
+			wantErr: "-:3: json: cannot unmarshal string into Go struct field FizzBuzzRequest.int1 of type int",
+		},
+		{
+			name:    "empty",
+			input:   "\n\n",
+			wantErr: "-: no parameter sets",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := readParams("-", strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, params)
+		})
+	}
+
+	path := filepath.Join(t.TempDir(), "requests.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(`{"int1":3,"int2":5,"limit":15}`), 0o600))
+	params, err := readParams(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []client.Params{{Int1: 3, Int2: 5, Limit: 15}}, params)
+}
+
+// drain returns every parameter set of w
+func drain(w workload) []client.Params {
+	var params []client.Params
+	for p, ok := w.next(); ok; p, ok = w.next() {
+		params = append(params, p)
+	}
+	return params
+}
+
+func TestReplay(t *testing.T) {
+	a := client.Params{Int1: 3, Int2: 5, Limit: 15}
+	b := client.Params{Int1: 2, Int2: 7, Limit: 10}
+
+	tests := []struct {
+		name  string
+		total int
+		want  []client.Params
+	}{
+		{"once", 0, []client.Params{a, b}},
+		{"looping", 5, []client.Params{a, b, a, b, a}},
+		{"truncated", 1, []client.Params{a}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, drain(newReplay([]client.Params{a, b}, tt.total)))
+		})
+	}
+}
+
+func TestZipf(t *testing.T) {
+	w, err := newZipf(10, 1.5, 1, 100, 42, 10000)
+	require.NoError(t, err)
+	params := drain(w)
+	require.Len(t, params, 10000)
+
+	counts := map[int]int{}
+	for _, p := range params {
+		assert.Equal(t, client.Params{Int1: 3, Int2: 5, Limit: p.Limit, Str1: "fizz", Str2: "buzz"}, p)
+		counts[p.Limit]++
+	}
+	// the keys differ by their limit, the first one being the most frequent
+	// and each one less frequent than the previous
+	assert.Len(t, counts, 10)
+	for limit := 101; limit < 110; limit++ {
+		assert.Greater(t, counts[limit-1], counts[limit], "limit %d", limit)
+	}
+
+	// a seed replays the same workload
+	again, err := newZipf(10, 1.5, 1, 100, 42, 10000)
+	require.NoError(t, err)
+	assert.Equal(t, params, drain(again))
+
+	// without total it never ends
+	unbounded, err := newZipf(1, 1.5, 1, 100, 42, 0)
+	require.NoError(t, err)
+	for range 100 {
+		p, ok := unbounded.next()
+		require.True(t, ok)
+		assert.Equal(t, 100, p.Limit)
+	}
+}
+
+func TestZipf_InvalidParameters(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    int
+		s, v    float64
+		limit   int
+		wantErr string
+	}{
+		{"no keys", 0, 1.1, 1, 100, "-keys must be at least 1"},
+		{"no limit", 10, 1.1, 1, 0, "-limit must be at least 1"},
+		{"skew", 10, 1, 1, 100, "invalid Zipf parameters: need -zipf-s > 1 and -zipf-v >= 1"},
+		{"offset", 10, 1.1, 0.5, 100, "invalid Zipf parameters: need -zipf-s > 1 and -zipf-v >= 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newZipf(tt.keys, tt.s, tt.v, tt.limit, 1, 0)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
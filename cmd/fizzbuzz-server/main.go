@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fizzbuzz-server/internal/apps"
+	"fizzbuzz-server/internal/config"
+	"fizzbuzz-server/internal/handlers"
+	"fizzbuzz-server/internal/prefork"
+	"fizzbuzz-server/internal/services"
+	"fizzbuzz-server/internal/tlsconfig"
+	"fizzbuzz-server/internal/upgrade"
+	"fizzbuzz-server/pkg/ulog"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const shutdownTimeout = 10 * time.Second
+
+// adminSocketMode restricts the admin Unix socket to the owner and group
+const adminSocketMode = 0o660
+
+func main() {
+	cfg, err := config.Load(os.Args[1:]...)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if errors.Is(err, config.ErrPrintConfig) {
+		if err := config.Print(os.Stdout, cfg); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	// the logger is set up by apps.App, errors before go to stderr
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "fizzbuzz-server: failed to load configuration:", err)
+		os.Exit(1)
+	}
+
+	child, err := upgrade.Inherited()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "fizzbuzz-server: failed to inherit listeners:", err)
+		os.Exit(1)
+	}
+	worker, err := prefork.Inherited()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "fizzbuzz-server: failed to inherit listeners:", err)
+		os.Exit(1)
+	}
+
+	app := apps.App()
+	if worker != nil {
+		stats, err := services.DialStatsService(worker.StatsAddress, func(err error) {
+			ulog.Errorf("stats aggregator call failed: %v", err)
+		})
+		if err != nil {
+			ulog.Errorf("failed to reach the stats aggregator: %v", err)
+			os.Exit(1)
+		}
+		app.StatsService = stats
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := config.Watch(ctx, func(err error) {
+		ulog.Errorf("configuration reload rejected: %v", err)
+	}); err != nil {
+		ulog.Errorf("failed to watch configuration file: %v", err)
+	}
+
+	srv := &server{app: app, child: child, worker: worker}
+	if err := srv.openListeners(cfg); err != nil {
+		ulog.Errorf("failed to listen: %v", err)
+		os.Exit(1)
+	}
+
+	// the prefork master serves nothing itself
+	if cfg.Server.Prefork && worker == nil {
+		if err := srv.runMaster(cfg); err != nil {
+			ulog.Errorf("prefork master stopped: %v", err)
+			os.Exit(1)
+		}
+		srv.stop(cfg.Server.PIDFile)
+		_ = ulog.Close()
+		return
+	}
+
+	app.FiberApp = handlers.NewFiberApp()
+	if cfg.Admin.Token == "" && cfg.Admin.Address == "" && worker == nil {
+		ulog.Info("admin endpoints are not served on the public port, set ADMIN_TOKEN or ADMIN_ADDRESS to serve them")
+	}
+	ln, err := srv.listener("server")
+	if err != nil {
+		ulog.Errorf("failed to listen: %v", err)
+		os.Exit(1)
+	}
+
+	if cfg.Admin.Address != "" {
+		ln, err := srv.listener("admin")
+		if err != nil {
+			ulog.Errorf("failed to listen on admin address: %v", err)
+			os.Exit(1)
+		}
+		app.AdminApp = handlers.NewAdminApp()
+		go func() {
+			if err := app.AdminApp.Listener(ln); err != nil {
+				ulog.Errorf("admin listener stopped: %v", err)
+			}
+		}()
+		if worker == nil {
+			ulog.Info("serving admin endpoints on " + cfg.Admin.Address)
+		}
+	}
+
+	app.FiberApp.Hooks().OnListen(func(fiber.ListenData) error {
+		srv.ready(cfg.Server.PIDFile)
+		return nil
+	})
+	go srv.handleSignals()
+	if worker != nil {
+		worker.WatchMaster(func() {
+			ulog.Info("prefork master is gone, shutting down")
+			if err := srv.shutdown(); err != nil {
+				ulog.Errorf("graceful shutdown failed: %v", err)
+			}
+		})
+	}
+
+	if err := listen(ctx, app.FiberApp, cfg, ln); err != nil {
+		ulog.Errorf("server stopped: %v", err)
+		os.Exit(1)
+	}
+	// the admin listener stays up until the public requests have drained
+	if app.AdminApp != nil {
+		if err := app.AdminApp.ShutdownWithTimeout(shutdownTimeout); err != nil {
+			ulog.Errorf("admin listener shutdown failed: %v", err)
+		}
+	}
+	srv.stop(cfg.Server.PIDFile)
+	_ = ulog.Close()
+}
+
+// listen serves app on ln until it is shut down, terminating TLS when a
+// certificate is configured. Listeners and whether TLS is on are set at
+// startup, TLS files and settings are reloaded as they change.
+func listen(ctx context.Context, app *fiber.App, cfg *config.Config, ln net.Listener) error {
+	// the TLS files and settings are followed until app stops serving
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if cfg.TLS.Enabled() {
+		reloader, err := tlsconfig.New(cfg.TLS)
+		if err != nil {
+			_ = ln.Close()
+			return err
+		}
+		if err := reloader.Watch(ctx, func(err error) {
+			ulog.Errorf("TLS reload rejected: %v", err)
+		}); err != nil {
+			ulog.Errorf("failed to watch TLS files: %v", err)
+		}
+		if cfg.TLS.ClientCAFile != "" {
+			ulog.Infof("client certificates are verified (%s)", cfg.TLS.ClientAuth)
+		}
+		ln = tls.NewListener(ln, reloader.Config())
+	}
+
+	ulog.InfoE("starting fizzbuzz-server on " + ln.Addr().Network() + " " + ln.Addr().String())
+	return app.Listener(ln)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// envRunMain makes the test binary run main, as the server binary would
+const envRunMain = "FIZZBUZZ_SERVER_TEST_RUN_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(envRunMain) != "" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestMain_InvalidConfiguration(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     []string
+		wantErr string
+	}{
+		{"port not a number", []string{"PORT=abc"}, `config: server.port: value abc does not satisfy "port"`},
+		{"port out of range", []string{"PORT=99999"}, `config: server.port: value 99999 does not satisfy "port"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := exec.Command(os.Args[0])
+			cmd.Dir = t.TempDir()
+			cmd.Env = append(os.Environ(), append(tt.env, envRunMain+"=1")...)
+			var stdout, stderr bytes.Buffer
+			cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+			err := cmd.Run()
+			var exitErr *exec.ExitError
+			require.True(t, errors.As(err, &exitErr), "server did not fail: %v", err)
+			assert.Equal(t, 1, exitErr.ExitCode())
+			assert.Empty(t, stdout.String())
+			assert.Equal(t, "fizzbuzz-server: failed to load configuration: "+tt.wantErr+"\n", stderr.String())
+		})
+	}
+}
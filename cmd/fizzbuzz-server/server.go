@@ -0,0 +1,326 @@
+package main
+
+import (
+	"fizzbuzz-server/internal/apps"
+	"fizzbuzz-server/internal/apps/contracts"
+	"fizzbuzz-server/internal/config"
+	"fizzbuzz-server/internal/entities"
+	"fizzbuzz-server/internal/listener"
+	"fizzbuzz-server/internal/prefork"
+	"fizzbuzz-server/internal/services"
+	"fizzbuzz-server/internal/upgrade"
+	"fizzbuzz-server/pkg/ulog"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/rs/zerolog"
+)
+
+// server tracks the sockets of the process so that an upgrade can hand them
+// over to a new one, and the workers of prefork mode
+type server struct {
+	app       *apps.FizzbuzzApp
+	child     *upgrade.Child  // set when started by an upgrade
+	worker    *prefork.Worker // set in prefork workers
+	master    *prefork.Master // set in the prefork master once started
+	stopped   chan struct{}   // closed once the prefork workers stopped
+	listeners []listener.Named
+	handoff   atomic.Pointer[upgrade.Handoff] // set once an upgrade succeeded
+}
+
+// openListeners opens the listeners of the public API and of the admin
+// endpoints. They are inherited from the prefork master or from the previous
+// process on upgrade, otherwise the public API listens on the sockets passed
+// by systemd, a Unix socket or the TCP port.
+func (s *server) openListeners(cfg *config.Config) error {
+	switch {
+	case s.worker != nil:
+		s.listeners = s.worker.Listeners()
+		return nil
+	case s.child != nil:
+		s.listeners = s.child.Listeners()
+		for _, l := range s.listeners {
+			ulog.Infof("listening on %s %s inherited from the previous process", l.Listener.Addr().Network(), l.Listener.Addr())
+		}
+		return nil
+	}
+
+	switch {
+	case cfg.Server.Systemd:
+		lns, err := listener.Systemd()
+		if err != nil {
+			return err
+		}
+		for _, ln := range lns {
+			ulog.Infof("listening on %s %s passed by systemd", ln.Addr().Network(), ln.Addr())
+			s.listeners = append(s.listeners, listener.Named{Name: "server", Listener: ln})
+		}
+	case cfg.Server.Socket != "":
+		if err := s.open("server", func() (net.Listener, error) {
+			return listener.Unix(cfg.Server.Socket, cfg.Server.Mode())
+		}); err != nil {
+			return err
+		}
+	default:
+		if err := s.open("server", func() (net.Listener, error) {
+			return net.Listen("tcp", ":"+cfg.Server.Port)
+		}); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Admin.Address == "" {
+		return nil
+	}
+	if err := s.open("admin", func() (net.Listener, error) {
+		return listener.Address(cfg.Admin.Address, adminSocketMode)
+	}); err != nil {
+		return fmt.Errorf("admin address: %w", err)
+	}
+	return nil
+}
+
+func (s *server) open(name string, listen func() (net.Listener, error)) error {
+	ln, err := listen()
+	if err != nil {
+		return err
+	}
+	s.listeners = append(s.listeners, listener.Named{Name: name, Listener: ln, Owned: true})
+	return nil
+}
+
+// listener returns a listener accepting the connections of the listeners
+// named name
+func (s *server) listener(name string) (net.Listener, error) {
+	var lns []net.Listener
+	for _, l := range s.listeners {
+		if l.Name == name {
+			lns = append(lns, l.Listener)
+		}
+	}
+	if len(lns) == 0 {
+		return nil, fmt.Errorf("no %s listener", name)
+	}
+	return listener.Merge(lns...), nil
+}
+
+// runMaster starts the prefork workers on the listeners and supervises them
+// until shut down. The workers count their stats into the stats service of
+// the master, served on a Unix socket only the user can reach.
+func (s *server) runMaster(cfg *config.Config) error {
+	stats, ok := s.app.StatsService.(*services.StatsService)
+	if !ok {
+		return fmt.Errorf("prefork needs the local stats service, got %T", s.app.StatsService)
+	}
+	dir, err := os.MkdirTemp("", "fizzbuzz-prefork-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	ln, err := listener.Unix(filepath.Join(dir, "stats.sock"), 0o600)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+	aggregator := services.NewStatsAggregator(stats)
+	defer aggregator.Close()
+	go func() {
+		if err := aggregator.Serve(ln); err != nil {
+			ulog.Errorf("stats aggregator stopped: %v", err)
+		}
+	}()
+
+	workers := cfg.Server.Workers()
+	master, err := prefork.Start(workers, s.listeners, ln.Addr().String(), cfg.Server.UpgradeTimeout, func(id int, err error) {
+		ulog.Errorf("prefork worker %d is down, restarting: %v", id, err)
+	})
+	if err != nil {
+		return err
+	}
+	s.master = master
+	s.stopped = make(chan struct{})
+	ulog.InfoE(fmt.Sprintf("started %d fizzbuzz-server workers %v", workers, master.PIDs()))
+
+	s.ready(cfg.Server.PIDFile)
+	go s.handleSignals()
+	<-s.stopped
+	return nil
+}
+
+// ready runs once the server is serving: it writes the PID file and, after
+// an upgrade, lets the previous process drain and takes over its stats.
+// Prefork workers tell the master instead.
+func (s *server) ready(pidFile string) {
+	if s.worker != nil {
+		if err := s.worker.Ready(); err != nil {
+			ulog.Errorf("failed to notify the prefork master: %v", err)
+		}
+		return
+	}
+	if pidFile != "" {
+		if err := writePIDFile(pidFile); err != nil {
+			ulog.Errorf("failed to write PID file: %v", err)
+		}
+	}
+	if s.child == nil {
+		return
+	}
+	if err := s.child.Ready(); err != nil {
+		ulog.Errorf("failed to notify the previous process: %v", err)
+		return
+	}
+	go func() {
+		var snapshot entities.StatsSnapshot
+		if err := s.child.ReceiveState(&snapshot); err != nil {
+			ulog.Errorf("stats handover failed: %v", err)
+			return
+		}
+		if err := s.app.StatsService.Import(snapshot, true); err != nil {
+			ulog.Errorf("stats handover rejected: %v", err)
+			return
+		}
+		ulog.Infof("upgrade complete, %d request kinds handed over", len(snapshot.Entries))
+	}()
+}
+
+// shutdown stops serving gracefully: the prefork master stops its workers,
+// other processes drain the public API
+func (s *server) shutdown() error {
+	if s.master != nil {
+		defer close(s.stopped)
+		return s.master.Stop(shutdownTimeout)
+	}
+	return s.app.FiberApp.ShutdownWithTimeout(shutdownTimeout)
+}
+
+// upgrade starts a new process on the listeners and shuts this one down once
+// the new one is serving. A failed upgrade leaves this process serving.
+func (s *server) upgrade() bool {
+	ulog.Info("upgrading, starting a new process")
+	handoff, err := upgrade.Upgrade(s.listeners, config.Get().Server.UpgradeTimeout)
+	if err != nil {
+		ulog.Errorf("upgrade failed, still serving: %v", err)
+		return false
+	}
+	s.handoff.Store(handoff)
+	ulog.Infof("process %d is serving, draining", handoff.PID())
+	if err := s.shutdown(); err != nil {
+		ulog.Errorf("graceful shutdown failed: %v", err)
+	}
+	return true
+}
+
+// stop runs once the server has drained. After an upgrade it hands the
+// stats over to the new process, which then owns the PID file; otherwise the
+// PID file is removed.
+func (s *server) stop(pidFile string) {
+	if s.worker != nil {
+		return
+	}
+	handoff := s.handoff.Load()
+	if handoff == nil {
+		if pidFile != "" {
+			_ = os.Remove(pidFile)
+		}
+		return
+	}
+	if err := handOver(handoff.SendState, s.app.StatsService); err != nil {
+		ulog.Errorf("stats handover failed: %v", err)
+	}
+}
+
+// handOver sends the stats to the new process with send. Counters that
+// could not be exported are reported in the error, the others being sent
+// nonetheless.
+func handOver(send func(any) error, stats contracts.StatsServiceIface) error {
+	snapshot, exportErr := stats.Export()
+	if err := send(snapshot); err != nil {
+		return err
+	}
+	if exportErr != nil {
+		return fmt.Errorf("%d request kinds handed over, some counters were left out: %w", len(snapshot.Entries), exportErr)
+	}
+	return nil
+}
+
+// handleSignals shuts the server down on SIGINT/SIGTERM, reloads the
+// configuration and restores its log level on SIGHUP, upgrades to a new
+// process on SIGUSR2 and cycles the log level on SIGUSR1, one step more
+// verbose each time and from trace back to error. The prefork master passes
+// the reload and log level signals on to its workers, which leave upgrades
+// to the master.
+func (s *server) handleSignals() {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGUSR1, syscall.SIGUSR2)
+
+	for sig := range sigs {
+		if s.handleSignal(sig) {
+			return
+		}
+	}
+}
+
+// handleSignal acts on sig and reports whether the server is done
+func (s *server) handleSignal(sig os.Signal) bool {
+	switch sig {
+	case syscall.SIGHUP:
+		if _, err := config.Reload(); err != nil {
+			ulog.Errorf("configuration reload rejected: %v", err)
+		} else {
+			ulog.Infof("configuration reloaded, version %d", config.Version())
+		}
+		restoreLogLevel()
+	case syscall.SIGUSR1:
+		ulog.Infof("log verbosity changed, level is now %s", ulog.CycleVerbosity())
+	case syscall.SIGUSR2:
+		return s.worker == nil && s.upgrade()
+	default:
+		ulog.Info("shutting down", sig)
+		if err := s.shutdown(); err != nil {
+			ulog.Errorf("graceful shutdown failed: %v", err)
+		}
+		return true
+	}
+	if s.master != nil {
+		s.master.Signal(sig)
+	}
+	return false
+}
+
+// restoreLogLevel sets the default log level back to log.level, undoing the
+// changes made by SIGUSR1 or the admin endpoint
+func restoreLogLevel() {
+	cfg := config.Get().Log
+	if level, err := zerolog.ParseLevel(cfg.Level); err == nil && cfg.Level != "" {
+		ulog.SetLevel(level)
+		ulog.Infof("log level restored to %s", level)
+	}
+}
+
+// writePIDFile replaces path atomically so that readers never see it empty
+func writePIDFile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.WriteString(strconv.Itoa(os.Getpid()) + "\n")
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0o644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+	return err
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"fizzbuzz-server/internal/entities"
+	"fizzbuzz-server/internal/services"
+	"fizzbuzz-server/mocks"
+	"fizzbuzz-server/pkg/ulog"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandOver(t *testing.T) {
+	comma := entities.StatsEntry{Int1: 3, Int2: 5, Limit: 15, Str1: "a,b", Str2: "c", Hits: 2}
+
+	t.Run("complete", func(t *testing.T) {
+		stats := services.NewStatsService(time.Hour)
+		require.NoError(t, stats.Import(entities.StatsSnapshot{Entries: []entities.StatsEntry{comma}}, false))
+
+		var sent entities.StatsSnapshot
+		err := handOver(func(v any) error {
+			sent = v.(entities.StatsSnapshot)
+			return nil
+		}, stats)
+		require.NoError(t, err)
+		assert.Equal(t, []entities.StatsEntry{comma}, sent.Entries)
+	})
+
+	t.Run("incomplete export", func(t *testing.T) {
+		// the counters exported are handed over, the others reported
+		stats := mocks.NewStatsServiceIface(t)
+		partial := entities.StatsSnapshot{Entries: []entities.StatsEntry{comma}}
+		stats.On("Export").Return(partial, errors.New(`invalid stats key format: "3,5,15,fizz"`))
+
+		var sent entities.StatsSnapshot
+		err := handOver(func(v any) error {
+			sent = v.(entities.StatsSnapshot)
+			return nil
+		}, stats)
+		assert.EqualError(t, err, `1 request kinds handed over, some counters were left out: invalid stats key format: "3,5,15,fizz"`)
+		assert.Equal(t, partial, sent)
+	})
+
+	t.Run("send fails", func(t *testing.T) {
+		stats := services.NewStatsService(time.Hour)
+		err := handOver(func(any) error { return errors.New("broken pipe") }, stats)
+		assert.EqualError(t, err, "broken pipe")
+	})
+}
+
+func TestHandleSignal_CyclesLogLevel(t *testing.T) {
+	previous := ulog.Level()
+	t.Cleanup(func() { ulog.SetLevel(previous) })
+	ulog.SetLevel(zerolog.TraceLevel)
+
+	s := &server{}
+	assert.False(t, s.handleSignal(syscall.SIGUSR1))
+	assert.Equal(t, zerolog.ErrorLevel, ulog.Level())
+	assert.False(t, s.handleSignal(syscall.SIGUSR1))
+	assert.Equal(t, zerolog.WarnLevel, ulog.Level())
+}
@@ -0,0 +1,149 @@
+// Command fizzbuzz generates FizzBuzz sequences without running the server.
+//
+// It accepts the same parameters, defaults and validation as GET /fizzbuzz:
+//
+//	fizzbuzz -int1 3 -int2 5 -limit 100 -str1 fizz -str2 buzz -format text
+//
+// or reads one JSON parameter set per line with -input (use - for stdin):
+//
+//	{"int1":3,"int2":5,"limit":15,"str1":"fizz","str2":"buzz"}
+//
+// Output is streamed, so very large limits only need a raised -max-limit.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fizzbuzz-server/internal/binding"
+	"fizzbuzz-server/internal/config"
+	"fizzbuzz-server/internal/entities"
+	"fizzbuzz-server/internal/output"
+	"fizzbuzz-server/internal/services"
+	"fizzbuzz-server/internal/validation"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "fizzbuzz:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// run generates the sequences selected by args to stdout, reporting the
+// rejected parameter sets of an input file to stderr
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	req := entities.FizzBuzzRequest{}
+	defaults := binding.Defaults(req)
+
+	fs := flag.NewFlagSet("fizzbuzz", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.IntVar(&req.Int1, "int1", 0, "first divisor")
+	fs.IntVar(&req.Int2, "int2", 0, "second divisor")
+	fs.IntVar(&req.Limit, "limit", 0, "last number of the sequence")
+	fs.StringVar(&req.Str1, "str1", defaults["str1"], "word for multiples of int1")
+	fs.StringVar(&req.Str2, "str2", defaults["str2"], "word for multiples of int2")
+	format := fs.String("format", output.FormatJSON, "output format: "+strings.Join(output.Formats, ", "))
+	input := fs.String("input", "", "JSONL file of parameter sets, - for stdin")
+	maxLimit := fs.Int("max-limit", config.Get().FizzBuzz.MaxLimit, "largest accepted limit (env FIZZBUZZ_MAX_LIMIT)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if !output.Valid(*format) {
+		return fmt.Errorf("unsupported output format %q", *format)
+	}
+
+	// Words are bounded like the HTTP API; output is streamed so the
+	// response budget does not apply
+	cfg := config.Get().FizzBuzz
+	limits := validation.Limits{MaxLimit: *maxLimit, MaxWordLength: cfg.MaxWordLength, WordCharacters: cfg.WordCharacters}
+
+	g := generator{
+		service: services.NewFizzBuzzService(),
+		binder:  binding.New(validation.New(func() validation.Limits { return limits })),
+		format:  *format,
+		out:     stdout,
+		errOut:  stderr,
+	}
+
+	if *input == "" {
+		return g.generate(req)
+	}
+
+	in := stdin
+	if *input != "-" {
+		f, err := os.Open(*input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	return g.generateAll(in)
+}
+
+type generator struct {
+	service *services.FizzBuzzService
+	binder  *binding.Binder
+	format  string
+	out     io.Writer
+	errOut  io.Writer // rejected parameter sets of an input
+}
+
+func (g generator) generate(req entities.FizzBuzzRequest) error {
+	if err := g.binder.Complete(context.Background(), &req); err != nil {
+		return err
+	}
+	return g.write(req)
+}
+
+func (g generator) write(req entities.FizzBuzzRequest) error {
+	seq := g.service.Sequence(req.Int1, req.Int2, req.Limit, req.Str1, req.Str2)
+	return output.Write(g.out, g.format, seq)
+}
+
+// generateAll runs every parameter set of a JSONL stream. Invalid lines are
+// reported on errOut and skipped, an error is returned at the end if any line
+// failed. Output errors stop the run immediately.
+func (g generator) generateAll(in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	failed := 0
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		// Bound like the HTTP API, omitted words get the same defaults
+		req := entities.FizzBuzzRequest{}
+		err := json.Unmarshal([]byte(text), &req)
+		if err == nil {
+			err = g.binder.Complete(context.Background(), &req)
+		}
+		if err != nil {
+			fmt.Fprintf(g.errOut, "fizzbuzz: line %d: %v\n", line, err)
+			failed++
+			continue
+		}
+		if err := g.write(req); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d parameter set(s) failed", failed)
+	}
+	return nil
+}
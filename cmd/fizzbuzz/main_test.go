@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "default words",
+			args: []string{"-int1", "3", "-int2", "5", "-limit", "5", "-format", "text"},
+			want: "1\n2\nfizz\n4\nbuzz\n",
+		},
+		{
+			name: "words",
+			args: []string{"-int1", "2", "-int2", "3", "-limit", "6", "-str1", " a ", "-str2", "b", "-format", "ndjson"},
+			want: "\"1\"\n\"a\"\n\"b\"\n\"a\"\n\"5\"\n\"ab\"\n",
+		},
+		{
+			name: "json",
+			args: []string{"-int1", "3", "-int2", "5", "-limit", "3"},
+			want: `{"result":["1","2","fizz"]}` + "\n",
+		},
+		{
+			name: "csv",
+			args: []string{"-int1", "3", "-int2", "5", "-limit", "3", "-str1", "a,b", "-format", "csv"},
+			want: "n,value\n1,1\n2,2\n3,\"a,b\"\n",
+		},
+		{
+			name: "raised max limit",
+			args: []string{"-int1", "1", "-int2", "1", "-limit", "20000", "-max-limit", "20000", "-format", "text", "-str1", "x", "-str2", "y"},
+			want: strings.Repeat("xy\n", 20000),
+		},
+		{
+			name:    "unsupported format",
+			args:    []string{"-int1", "3", "-int2", "5", "-limit", "3", "-format", "xml"},
+			wantErr: `unsupported output format "xml"`,
+		},
+		{
+			name:    "invalid parameters",
+			args:    []string{"-int1", "3", "-limit", "20000"},
+			wantErr: "int2 is required; limit exceeds the maximum limit",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			err := run(tt.args, strings.NewReader(""), &stdout, &stderr)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, stdout.String())
+			assert.Empty(t, stderr.String())
+		})
+	}
+}
+
+func TestRun_Input(t *testing.T) {
+	input := strings.Join([]string{
+		`{"int1":3,"int2":5,"limit":3}`,
+		``,
+		`{"int1":3,`,
+		`{"int1":2,"int2":3,"limit":2,"str1":"a","str2":"b"}`,
+		`{"int1":0,"int2":5,"limit":3}`,
+	}, "\n")
+	want := "1\n2\nfizz\n1\na\n"
+	wantStderr := "fizzbuzz: line 3: unexpected end of JSON input\n" +
+		"fizzbuzz: line 5: int1 is required\n"
+
+	tests := []struct {
+		name  string
+		input func(t *testing.T) string
+		stdin string
+	}{
+		{"stdin", func(*testing.T) string { return "-" }, input},
+		{"file", func(t *testing.T) string {
+			path := filepath.Join(t.TempDir(), "params.jsonl")
+			require.NoError(t, os.WriteFile(path, []byte(input), 0o600))
+			return path
+		}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			err := run([]string{"-input", tt.input(t), "-format", "text"}, strings.NewReader(tt.stdin), &stdout, &stderr)
+			// valid sets are generated, the others reported and counted
+			assert.EqualError(t, err, "2 parameter set(s) failed")
+			assert.Equal(t, want, stdout.String())
+			assert.Equal(t, wantStderr, stderr.String())
+		})
+	}
+
+	err := run([]string{"-input", filepath.Join(t.TempDir(), "missing.jsonl")}, nil, &bytes.Buffer{}, &bytes.Buffer{})
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
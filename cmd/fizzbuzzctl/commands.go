@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fizzbuzz-server/pkg/client"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+func (c *cli) generate(ctx context.Context, args []string) error {
+	p := client.Params{}
+	fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+	fs.IntVar(&p.Int1, "int1", 0, "first divisor")
+	fs.IntVar(&p.Int2, "int2", 0, "second divisor")
+	fs.IntVar(&p.Limit, "limit", 0, "last number of the sequence")
+	fs.StringVar(&p.Str1, "str1", "", "word for multiples of int1")
+	fs.StringVar(&p.Str2, "str2", "", "word for multiples of int2")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	result, err := c.client.Generate(ctx, p)
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(map[string][]string{"result": result})
+	}
+	_, err = fmt.Fprintln(c.out, strings.Join(result, "\n"))
+	return err
+}
+
+func (c *cli) stats(ctx context.Context, args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "reset":
+			if err := c.client.ResetStats(ctx); err != nil {
+				return err
+			}
+			_, err := fmt.Fprintln(c.out, "stats reset")
+			return err
+		case "export":
+			return c.statsExport(ctx, args[1:])
+		case "import":
+			return c.statsImport(ctx, args[1:])
+		case "clients":
+			return c.statsClients(ctx)
+		}
+	}
+
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	window := fs.Duration("window", 0, "only count requests within this window, e.g. 15m")
+	top := fs.Int("top", 1, "number of entries to show")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	entries, err := c.client.Top(ctx, *top, *window)
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(map[string]any{"top": entries})
+	}
+	return c.printTable([]string{"INT1", "INT2", "LIMIT", "STR1", "STR2", "HITS"}, func(row func(...any)) {
+		for _, e := range entries {
+			row(e.Int1, e.Int2, e.Limit, e.Str1, e.Str2, e.Hits)
+		}
+	})
+}
+
+func (c *cli) statsClients(ctx context.Context) error {
+	clients, err := c.client.ClientStats(ctx)
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(map[string]any{"clients": clients})
+	}
+	return c.printTable([]string{"CLIENT", "REQUESTS"}, func(row func(...any)) {
+		for _, e := range clients {
+			row(e.Client, e.Requests)
+		}
+	})
+}
+
+func (c *cli) statsExport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("stats export", flag.ContinueOnError)
+	file := fs.String("file", "", "write the snapshot to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	snapshot, err := c.client.ExportStats(ctx)
+	if err != nil {
+		return err
+	}
+	if *file == "" {
+		return c.printJSON(snapshot)
+	}
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(*file, data, 0o644)
+}
+
+func (c *cli) statsImport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("stats import", flag.ContinueOnError)
+	file := fs.String("file", "", "snapshot written by stats export")
+	merge := fs.Bool("merge", false, "add to the current counters instead of replacing them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return fmt.Errorf("stats import: -file is required")
+	}
+
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		return err
+	}
+	snapshot := client.StatsSnapshot{}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("stats import: %w", err)
+	}
+	if err := c.client.ImportStats(ctx, snapshot, *merge); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.out, "imported %d entries\n", len(snapshot.Entries))
+	return err
+}
+
+func (c *cli) health(ctx context.Context) error {
+	health, err := c.client.Health(ctx)
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(health)
+	}
+	return c.printTable([]string{"STATUS", "UPTIME", "CONFIG VERSION"}, func(row func(...any)) {
+		row(health.Status, health.Uptime, health.ConfigVersion)
+	})
+}
+
+func (c *cli) logLevel(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("log-level", flag.ContinueOnError)
+	level := fs.String("level", "", "new level: trace, debug, info, warn or error")
+	component := fs.String("component", "", "only change this component")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var levels client.LogLevels
+	var err error
+	if *level == "" && *component == "" {
+		levels, err = c.client.LogLevels(ctx)
+	} else {
+		levels, err = c.client.SetLogLevel(ctx, *level, *component)
+	}
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(levels)
+	}
+
+	components := make([]string, 0, len(levels.Components))
+	for name := range levels.Components {
+		components = append(components, name)
+	}
+	sort.Strings(components)
+	return c.printTable([]string{"COMPONENT", "LEVEL"}, func(row func(...any)) {
+		row("(default)", levels.Level)
+		for _, name := range components {
+			row(name, levels.Components[name])
+		}
+	})
+}
+
+func (c *cli) config(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("config: expected show, version or reload")
+	}
+
+	switch args[0] {
+	case "show":
+		cfg, err := c.client.Config(ctx)
+		if err != nil {
+			return err
+		}
+		return c.printJSON(cfg)
+	case "version", "reload":
+		var version client.ConfigVersion
+		var err error
+		if args[0] == "reload" {
+			version, err = c.client.ReloadConfig(ctx)
+		} else {
+			version, err = c.client.ConfigVersion(ctx)
+		}
+		if err != nil {
+			return err
+		}
+		if c.json {
+			return c.printJSON(version)
+		}
+		return c.printTable([]string{"VERSION", "LOADED AT", "FILE"}, func(row func(...any)) {
+			row(version.Version, version.LoadedAt.Format("2006-01-02 15:04:05"), version.File)
+		})
+	default:
+		return fmt.Errorf("config: unknown subcommand %q", args[0])
+	}
+}
+
+func (c *cli) printJSON(v any) error {
+	enc := json.NewEncoder(c.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func (c *cli) printTable(header []string, rows func(row func(...any))) error {
+	tw := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	rows(func(values ...any) {
+		cells := make([]string, len(values))
+		for i, v := range values {
+			cells[i] = fmt.Sprint(v)
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	})
+	return tw.Flush()
+}
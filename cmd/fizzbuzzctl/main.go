@@ -0,0 +1,145 @@
+// Command fizzbuzzctl operates a running fizzbuzz-server.
+//
+//	fizzbuzzctl [global flags] <command> [flags]
+//
+// Commands:
+//
+//	generate -int1 3 -int2 5 -limit 15 [-str1 fizz -str2 buzz]
+//	stats [-window 15m] [-top 10]
+//	stats reset
+//	stats export [-file stats.json]
+//	stats import [-merge] -file stats.json
+//	stats clients
+//	health
+//	log-level [-level debug] [-component http]
+//	config show | version | reload
+//
+// Global flags default to the FIZZBUZZ_SERVER, FIZZBUZZ_API_KEY and
+// FIZZBUZZ_ADMIN_TOKEN environment variables. -ca-file trusts a private CA
+// and -cert-file/-key-file present a client certificate to servers
+// verifying them.
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fizzbuzz-server/pkg/client"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdout); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "fizzbuzzctl:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// cli holds the state shared by every command
+type cli struct {
+	client *client.Client
+	out    io.Writer
+	json   bool
+}
+
+func run(ctx context.Context, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("fizzbuzzctl", flag.ContinueOnError)
+	server := fs.String("server", envOr("FIZZBUZZ_SERVER", "http://localhost:8080"), "server base URL")
+	apiKey := fs.String("api-key", os.Getenv("FIZZBUZZ_API_KEY"), "API key")
+	tenant := fs.String("tenant", os.Getenv("FIZZBUZZ_TENANT"), "tenant of the generate and stats requests")
+	adminToken := fs.String("admin-token", os.Getenv("FIZZBUZZ_ADMIN_TOKEN"), "admin token for /admin routes")
+	output := fs.String("o", "table", "output format: table or json")
+	timeout := fs.Duration("timeout", 30*time.Second, "request timeout")
+	caFile := fs.String("ca-file", "", "PEM file of the CAs trusted to sign the server certificate")
+	certFile := fs.String("cert-file", "", "PEM file of the client certificate")
+	keyFile := fs.String("key-file", "", "PEM file of the client certificate key")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *output != "table" && *output != "json" {
+		return fmt.Errorf("unsupported output format %q", *output)
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	opts := []client.Option{client.WithAPIKey(*apiKey), client.WithTenant(*tenant), client.WithAdminToken(*adminToken)}
+	if *caFile != "" || *certFile != "" {
+		httpClient, err := tlsClient(*caFile, *certFile, *keyFile)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, client.WithHTTPClient(httpClient))
+	}
+
+	c := &cli{
+		client: client.New(*server, opts...),
+		out:    out,
+		json:   *output == "json",
+	}
+
+	command, rest := fs.Arg(0), fs.Args()[1:]
+	switch command {
+	case "generate":
+		return c.generate(ctx, rest)
+	case "stats":
+		return c.stats(ctx, rest)
+	case "health":
+		return c.health(ctx)
+	case "log-level":
+		return c.logLevel(ctx, rest)
+	case "config":
+		return c.config(ctx, rest)
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
+
+// tlsClient returns an HTTP client trusting the CAs of caFile, or the system
+// ones, and presenting the certificate of certFile when set
+func tlsClient(caFile, certFile, keyFile string) (*http.Client, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		data, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificate found in %s", caFile)
+		}
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = cfg
+	return &http.Client{Transport: transport}, nil
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServer answers each "METHOD path" with its canned JSON body, 404
+// otherwise, and records the request bodies
+func fakeServer(t *testing.T, responses map[string]string) map[string]string {
+	bodies := map[string]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := r.Method + " " + r.URL.Path
+		body, _ := io.ReadAll(r.Body)
+		bodies[route] = string(body)
+		resp, ok := responses[route]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(resp))
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("FIZZBUZZ_SERVER", srv.URL)
+	return bodies
+}
+
+func TestRun_Output(t *testing.T) {
+	fakeServer(t, map[string]string{
+		"GET /fizzbuzz":             `{"result":["1","2","fizz"]}`,
+		"GET /stats/top":            `{"top":[{"int1":3,"int2":5,"limit":15,"str1":"fizz","str2":"buzz","hits":12},{"int1":2,"int2":7,"limit":100,"str1":"a,b","str2":"c","hits":3}]}`,
+		"GET /admin/stats/clients":  `{"clients":[{"client":"alice","requests":2}]}`,
+		"POST /admin/stats/reset":   `{}`,
+		"GET /health":               `{"status":"ok","uptime":"1h0m0s","config_version":3}`,
+		"GET /admin/log-level":      `{"level":"info","components":{"http":"debug","auth":"warn"}}`,
+		"GET /admin/config/version": `{"version":2,"loaded_at":"2025-01-02T03:04:05Z","file":"config.yaml"}`,
+	})
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"generate", []string{"generate", "-int1", "3", "-int2", "5", "-limit", "3"}, "1\n2\nfizz\n"},
+		{"generate json", []string{"-o", "json", "generate", "-int1", "3", "-int2", "5", "-limit", "3"},
+			"{\n  \"result\": [\n    \"1\",\n    \"2\",\n    \"fizz\"\n  ]\n}\n"},
+		{"stats", []string{"stats", "-top", "2"},
+			"INT1  INT2  LIMIT  STR1  STR2  HITS\n" +
+				"3     5     15     fizz  buzz  12\n" +
+				"2     7     100    a,b   c     3\n"},
+		{"stats clients", []string{"stats", "clients"}, "CLIENT  REQUESTS\nalice   2\n"},
+		{"stats clients json", []string{"-o", "json", "stats", "clients"},
+			"{\n  \"clients\": [\n    {\n      \"client\": \"alice\",\n      \"requests\": 2\n    }\n  ]\n}\n"},
+		{"stats reset", []string{"stats", "reset"}, "stats reset\n"},
+		{"health", []string{"health"}, "STATUS  UPTIME  CONFIG VERSION\nok      1h0m0s  3\n"},
+		{"log levels sorted by component", []string{"log-level"},
+			"COMPONENT  LEVEL\n(default)  info\nauth       warn\nhttp       debug\n"},
+		{"config version", []string{"config", "version"},
+			"VERSION  LOADED AT            FILE\n2        2025-01-02 03:04:05  config.yaml\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			require.NoError(t, run(context.Background(), tt.args, &out))
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	fakeServer(t, map[string]string{})
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"unknown command", []string{"deploy"}, `unknown command "deploy"`},
+		{"unsupported output", []string{"-o", "yaml", "health"}, `unsupported output format "yaml"`},
+		{"config without subcommand", []string{"config"}, "config: expected show, version or reload"},
+		{"unknown config subcommand", []string{"config", "edit"}, `config: unknown subcommand "edit"`},
+		{"import without file", []string{"stats", "import"}, "stats import: -file is required"},
+		{"server error", []string{"health"}, "fizzbuzz-server: 404 Not Found: 404 page not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.EqualError(t, run(context.Background(), tt.args, io.Discard), tt.wantErr)
+		})
+	}
+}
+
+func TestRun_StatsExportImport(t *testing.T) {
+	snapshot := `{"exported_at":"2025-01-02T03:04:05Z","entries":[{"int1":3,"int2":5,"limit":15,"str1":"fizz","str2":"buzz","hits":2}]}`
+	bodies := fakeServer(t, map[string]string{
+		"GET /admin/stats/export":  snapshot,
+		"POST /admin/stats/import": `{}`,
+	})
+	file := filepath.Join(t.TempDir(), "stats.json")
+
+	var out bytes.Buffer
+	require.NoError(t, run(context.Background(), []string{"stats", "export", "-file", file}, &out))
+	assert.Empty(t, out.String())
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.JSONEq(t, snapshot, string(data))
+
+	require.NoError(t, run(context.Background(), []string{"stats", "import", "-merge", "-file", file}, &out))
+	assert.Equal(t, "imported 1 entries\n", out.String())
+	assert.JSONEq(t, snapshot, bodies["POST /admin/stats/import"])
+
+	require.NoError(t, os.WriteFile(file, []byte("{"), 0o600))
+	err = run(context.Background(), []string{"stats", "import", "-file", file}, io.Discard)
+	var syntaxErr *json.SyntaxError
+	assert.ErrorAs(t, err, &syntaxErr)
+}
@@ -1,5 +1,16 @@
 package contracts
 
+import (
+	"context"
+	"iter"
+)
+
 type FizzBuzzServiceIface interface {
 	GenerateFizzBuzz(int1, int2, limit int, str1, str2 string) []string
+	Sequence(int1, int2, limit int, str1, str2 string) iter.Seq[string]
+	// GenerateFizzBuzzContext stops with the error of ctx once it is done
+	GenerateFizzBuzzContext(ctx context.Context, int1, int2, limit int, str1, str2 string) ([]string, error)
+	// SequenceContext stops yielding once ctx is done, callers tell an
+	// interrupted sequence by the error of ctx
+	SequenceContext(ctx context.Context, int1, int2, limit int, str1, str2 string) iter.Seq[string]
 }
@@ -1,7 +1,20 @@
 package contracts
 
-import "fizzbuzz-server/internal/entities"
+import (
+	"fizzbuzz-server/internal/entities"
+	"time"
+)
 
 type StatsServiceIface interface {
 	ParseStatsKey(key string) (entities.StatsKeys, error)
+	Record(req entities.FizzBuzzRequest)
+	RecordClient(client string)
+	Clients() []entities.ClientStats
+	Top(n int, window time.Duration) ([]entities.StatsEntry, error)
+	Reset()
+	Export() (entities.StatsSnapshot, error)
+	Import(snapshot entities.StatsSnapshot, merge bool) error
+	Subscribe(fn func()) (unsubscribe func())
+	Tenant(name string) StatsServiceIface
+	Tenants() []string
 }
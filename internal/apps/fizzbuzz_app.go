@@ -2,11 +2,13 @@ package apps
 
 import (
 	"fizzbuzz-server/internal/apps/contracts"
+	"fizzbuzz-server/internal/config"
 	"fizzbuzz-server/internal/services"
 	"fizzbuzz-server/pkg/ulog"
 	"sync"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
 )
 
 var (
@@ -24,12 +26,49 @@ var App = func() *FizzbuzzApp {
 
 type FizzbuzzApp struct {
 	FiberApp        *fiber.App
+	AdminApp        *fiber.App // nil unless the admin listener is configured
 	FizzBuzzService contracts.FizzBuzzServiceIface
 	StatsService    contracts.StatsServiceIface
 }
 
 func (f *FizzbuzzApp) init() {
-	ulog.LogInit()
+	ulog.LogInit(logOptions(config.Get().Log)...)
+	config.Subscribe(applyLogLevel)
 	f.FizzBuzzService = services.NewFizzBuzzService()
-	f.StatsService = services.NewStatsService()
+	f.StatsService = services.NewStatsService(config.Get().Stats.WindowRetention)
+}
+
+// applyLogLevel follows log level changes on configuration reload
+func applyLogLevel(old, new *config.Config) {
+	if old.Log.Level == new.Log.Level {
+		return
+	}
+	if level, err := zerolog.ParseLevel(new.Log.Level); err == nil {
+		ulog.SetLevel(level)
+	}
+}
+
+// logOptions maps the logging configuration onto ulog options
+func logOptions(cfg config.LogConfig) []ulog.Option {
+	var opts []ulog.Option
+	if level, err := zerolog.ParseLevel(cfg.Level); err == nil && cfg.Level != "" {
+		opts = append(opts, ulog.WithLevel(level))
+	}
+	if cfg.File != "" {
+		opts = append(opts, ulog.WithFile(fileConfig(cfg, cfg.File)))
+	}
+	if cfg.ErrorFile != "" {
+		opts = append(opts, ulog.WithErrorFile(fileConfig(cfg, cfg.ErrorFile)))
+	}
+	return opts
+}
+
+func fileConfig(cfg config.LogConfig, path string) ulog.FileConfig {
+	return ulog.FileConfig{
+		Path:       path,
+		MaxSizeMB:  cfg.MaxSizeMB,
+		MaxAgeDays: cfg.MaxAgeDays,
+		MaxBackups: cfg.MaxBackups,
+		Compress:   cfg.Compress,
+	}
 }
@@ -0,0 +1,195 @@
+package binding
+
+import (
+	"context"
+	"errors"
+	"fizzbuzz-server/internal/fieldvalue"
+	"fizzbuzz-server/internal/i18n"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/text/unicode/norm"
+)
+
+type defaultsKey struct{}
+
+// WithDefaults overrides the default tags for the structs completed with
+// ctx, e.g. the default words of a tenant. defaults maps the name a field is
+// bound under to its default value, empty values are ignored.
+func WithDefaults(ctx context.Context, defaults map[string]string) context.Context {
+	merged := map[string]string{}
+	if previous, ok := ctx.Value(defaultsKey{}).(map[string]string); ok {
+		for name, value := range previous {
+			merged[name] = value
+		}
+	}
+	for name, value := range defaults {
+		if value != "" {
+			merged[name] = value
+		}
+	}
+	return context.WithValue(ctx, defaultsKey{}, merged)
+}
+
+// FieldError describes a field rejected by validation
+type FieldError struct {
+	Field string `json:"field"` // name the field is bound under
+	Rule  string `json:"rule"`
+	Param string `json:"param,omitempty"`
+}
+
+// Message describes the error in the language of t, e.g. "limit is
+// required"
+func (e FieldError) Message(t i18n.Translator) string {
+	return t.Field(e.Field, e.Rule, e.Param)
+}
+
+// Error is a request the binder rejected, either because it could not be
+// parsed or because fields failed validation
+type Error struct {
+	Message string       // what went wrong, translated; the field messages on validation
+	Fields  []FieldError // empty when the request could not be parsed
+	err     error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// Binder binds request values into structs the same way for every endpoint:
+// values are parsed, strings are trimmed and normalised to Unicode NFC,
+// fields left empty get the value of their default tag, then the struct is
+// validated with the shared validator.
+type Binder struct {
+	validate *validator.Validate
+}
+
+// New returns a binder validating with validate
+func New(validate *validator.Validate) *Binder {
+	return &Binder{validate: validate}
+}
+
+// Bind parses the request into dst, a pointer to a struct: the query string
+// of GET, HEAD and DELETE requests, the JSON or form body of the others. It
+// then completes dst with ctx like Complete.
+func (b *Binder) Bind(ctx context.Context, c *fiber.Ctx, dst any) error {
+	t := i18n.FromContext(ctx)
+	switch c.Method() {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodDelete:
+		if err := c.QueryParser(dst); err != nil {
+			return &Error{Message: t.T("invalid_parameter_format"), err: err}
+		}
+	default:
+		if err := c.BodyParser(dst); err != nil {
+			return &Error{Message: t.T("invalid_request_body"), err: err}
+		}
+	}
+	return b.Complete(ctx, dst)
+}
+
+// Complete normalises, defaults and validates dst, a pointer to a struct
+// decoded by the caller. Validation rules depending on the request, such as
+// validation.WithMaxLimit, and the translator of the messages are read from
+// ctx.
+func (b *Binder) Complete(ctx context.Context, dst any) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("binding: %T is not a pointer to a struct", dst)
+	}
+	overrides, _ := ctx.Value(defaultsKey{}).(map[string]string)
+	if err := complete(v.Elem(), overrides); err != nil {
+		return err
+	}
+
+	err := b.validate.StructCtx(ctx, dst)
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return err
+	}
+	t := v.Elem().Type()
+	translator := i18n.FromContext(ctx)
+	fields := make([]FieldError, len(validationErrors))
+	messages := make([]string, len(validationErrors))
+	for i, fe := range validationErrors {
+		name := strings.ToLower(fe.StructField())
+		if f, ok := t.FieldByName(fe.StructField()); ok {
+			name = fieldName(f)
+		}
+		fields[i] = FieldError{Field: name, Rule: fe.Tag(), Param: fe.Param()}
+		messages[i] = fields[i].Message(translator)
+	}
+	return &Error{Message: strings.Join(messages, "; "), Fields: fields, err: err}
+}
+
+// Defaults returns the default tags of the fields of v, a struct or a
+// pointer to one, by the name they are bound under
+func Defaults(v any) map[string]string {
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	defaults := map[string]string{}
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if value, ok := f.Tag.Lookup("default"); ok && f.IsExported() {
+			defaults[fieldName(f)] = value
+		}
+	}
+	return defaults
+}
+
+// complete normalises the strings of v and sets the defaults of its empty
+// fields, overrides taking precedence over the default tags
+func complete(v reflect.Value, overrides map[string]string) error {
+	t := v.Type()
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		field := v.Field(i)
+		if field.Kind() == reflect.String {
+			field.SetString(normalise(field.String()))
+		}
+		if !field.IsZero() {
+			continue
+		}
+		value, ok := overrides[fieldName(f)]
+		if !ok {
+			value, ok = f.Tag.Lookup("default")
+		}
+		if !ok || value == "" {
+			continue
+		}
+		if err := fieldvalue.Set(field, normalise(value)); err != nil {
+			// default tags are static, failing to parse one is a
+			// programming error
+			return fmt.Errorf("binding: default of %s.%s: %w", t.Name(), f.Name, err)
+		}
+	}
+	return nil
+}
+
+// normalise trims s and puts it in Unicode NFC, so that equal words are
+// validated and counted in the stats as one
+func normalise(s string) string {
+	return norm.NFC.String(strings.TrimSpace(s))
+}
+
+// fieldName returns the name f is bound under: its json, query or form tag,
+// else its lowercase name
+func fieldName(f reflect.StructField) string {
+	for _, tag := range []string{"json", "query", "form"} {
+		if name, _, _ := strings.Cut(f.Tag.Get(tag), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return strings.ToLower(f.Name)
+}
@@ -0,0 +1,166 @@
+package binding_test
+
+import (
+	"context"
+	"encoding/json"
+	"fizzbuzz-server/internal/binding"
+	"fizzbuzz-server/internal/entities"
+	"fizzbuzz-server/internal/validation"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBinder() *binding.Binder {
+	return binding.New(validation.New(func() validation.Limits { return validation.Limits{MaxLimit: 100, MaxWordLength: 8} }))
+}
+
+func TestBinder_Complete(t *testing.T) {
+	binder := newBinder()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		req  entities.FizzBuzzRequest
+		want entities.FizzBuzzRequest
+	}{
+		{
+			name: "default tags",
+			ctx:  context.Background(),
+			req:  entities.FizzBuzzRequest{Int1: 3, Int2: 5, Limit: 15},
+			want: entities.FizzBuzzRequest{Int1: 3, Int2: 5, Limit: 15, Str1: "fizz", Str2: "buzz"},
+		},
+		{
+			name: "blank words are defaulted",
+			ctx:  context.Background(),
+			req:  entities.FizzBuzzRequest{Int1: 3, Int2: 5, Limit: 15, Str1: "  ", Str2: "\t"},
+			want: entities.FizzBuzzRequest{Int1: 3, Int2: 5, Limit: 15, Str1: "fizz", Str2: "buzz"},
+		},
+		{
+			name: "trimmed and composed",
+			ctx:  context.Background(),
+			req:  entities.FizzBuzzRequest{Int1: 3, Int2: 5, Limit: 15, Str1: " cafe\u0301 ", Str2: "buzz\n"},
+			want: entities.FizzBuzzRequest{Int1: 3, Int2: 5, Limit: 15, Str1: "caf\u00e9", Str2: "buzz"},
+		},
+		{
+			name: "overridden defaults",
+			ctx:  binding.WithDefaults(context.Background(), map[string]string{"str1": "foo", "str2": ""}),
+			req:  entities.FizzBuzzRequest{Int1: 3, Int2: 5, Limit: 15},
+			want: entities.FizzBuzzRequest{Int1: 3, Int2: 5, Limit: 15, Str1: "foo", Str2: "buzz"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			require.NoError(t, binder.Complete(tt.ctx, &req))
+			assert.Equal(t, tt.want, req)
+		})
+	}
+}
+
+func TestBinder_CompleteErrors(t *testing.T) {
+	binder := newBinder()
+
+	req := entities.FizzBuzzRequest{Int2: -1, Limit: 101}
+	err := binder.Complete(context.Background(), &req)
+	var bindErr *binding.Error
+	require.ErrorAs(t, err, &bindErr)
+	assert.Equal(t, []binding.FieldError{
+		{Field: "int1", Rule: "required"},
+		{Field: "int2", Rule: "gt", Param: "0"},
+		{Field: "limit", Rule: "maxlimit"},
+	}, bindErr.Fields)
+
+	// the cap of the context applies
+	req = entities.FizzBuzzRequest{Int1: 3, Int2: 5, Limit: 50}
+	err = binder.Complete(validation.WithMaxLimit(context.Background(), 20), &req)
+	require.ErrorAs(t, err, &bindErr)
+	assert.Equal(t, []binding.FieldError{{Field: "limit", Rule: "maxlimit"}}, bindErr.Fields)
+
+	// words are bounded in characters, not bytes, and control characters are
+	// rejected
+	req = entities.FizzBuzzRequest{Int1: 3, Int2: 5, Limit: 15, Str1: "fizzfizzf", Str2: "b\x00zz"}
+	err = binder.Complete(context.Background(), &req)
+	require.ErrorAs(t, err, &bindErr)
+	assert.Equal(t, []binding.FieldError{
+		{Field: "str1", Rule: "wordlen"},
+		{Field: "str2", Rule: "wordchars"},
+	}, bindErr.Fields)
+	req = entities.FizzBuzzRequest{Int1: 3, Int2: 5, Limit: 15, Str1: "ça va ?", Str2: "€½"}
+	assert.NoError(t, binder.Complete(context.Background(), &req))
+
+	assert.Error(t, binder.Complete(context.Background(), req))
+}
+
+func TestBinder_CompleteOtherKinds(t *testing.T) {
+	type request struct {
+		N       int           `json:"n" default:"10"`
+		Ratio   float64       `json:"ratio" default:"0.5"`
+		Verbose bool          `json:"verbose" default:"true"`
+		Window  time.Duration `json:"window" default:"1m"`
+	}
+	var req request
+	require.NoError(t, newBinder().Complete(context.Background(), &req))
+	assert.Equal(t, request{N: 10, Ratio: 0.5, Verbose: true, Window: time.Minute}, req)
+}
+
+func TestDefaults(t *testing.T) {
+	want := map[string]string{"str1": "fizz", "str2": "buzz"}
+	assert.Equal(t, want, binding.Defaults(entities.FizzBuzzRequest{}))
+	assert.Equal(t, want, binding.Defaults(&entities.FizzBuzzRequest{}))
+}
+
+func TestBinder_Bind(t *testing.T) {
+	binder := newBinder()
+	app := fiber.New()
+	app.All("/", func(c *fiber.Ctx) error {
+		req := entities.FizzBuzzRequest{}
+		if err := binder.Bind(c.UserContext(), c, &req); err != nil {
+			return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+		}
+		return c.JSON(req)
+	})
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		body        string
+		status      int
+	}{
+		{"query", http.MethodGet, "/?int1=3&int2=5&limit=15&str1=+fizz+", "", "", http.StatusOK},
+		{"json", http.MethodPost, "/", fiber.MIMEApplicationJSON, `{"int1":3,"int2":5,"limit":15,"str1":"fizz"}`, http.StatusOK},
+		{"form", http.MethodPost, "/", fiber.MIMEApplicationForm, "int1=3&int2=5&limit=15&str1=fizz", http.StatusOK},
+		{"malformed query", http.MethodGet, "/?int1=x", "", "", http.StatusBadRequest},
+		{"malformed json", http.MethodPost, "/", fiber.MIMEApplicationJSON, `{"int1":`, http.StatusBadRequest},
+		{"invalid", http.MethodGet, "/?int1=3&int2=5", "", "", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set(fiber.HeaderContentType, tt.contentType)
+			}
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.Equal(t, tt.status, resp.StatusCode, string(body))
+			if tt.status != http.StatusOK {
+				return
+			}
+			var got entities.FizzBuzzRequest
+			require.NoError(t, json.Unmarshal(body, &got))
+			assert.Equal(t, entities.FizzBuzzRequest{Int1: 3, Int2: 5, Limit: 15, Str1: "fizz", Str2: "buzz"}, got)
+		})
+	}
+}
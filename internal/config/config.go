@@ -1,107 +1,292 @@
 package config
 
 import (
-	"os"
-	"strconv"
+	"crypto/subtle"
+	"errors"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
 // Config holds all configuration for the application
+//
+// Every leaf field is described by struct tags read by the loader:
+//   - key: name of the field in config files and CLI flags, nested under the
+//     key of its section (e.g. "server.port", passed as --server.port)
+//   - env: environment variable overriding the file value
+//   - default: value used when no source sets the field
+//   - validate: go-playground/validator rules checked once all sources are merged
+//   - secret: "true" masks the value in dumps and lets <env>_FILE name a file
+//     holding the value, e.g. API_KEYS_FILE=/run/secrets/api_keys
+//
+// Sources are merged with the following precedence, highest first:
+// CLI flags, environment variables, config file, defaults.
 type Config struct {
-	Server     ServerConfig
-	Telemetry  TelemetryConfig
-	Prometheus PrometheusConfig
+	Server     ServerConfig     `key:"server"`
+	TLS        TLSConfig        `key:"tls"`
+	Telemetry  TelemetryConfig  `key:"telemetry"`
+	Prometheus PrometheusConfig `key:"prometheus"`
+	Log        LogConfig        `key:"log"`
+	RateLimit  RateLimitConfig  `key:"rate_limit"`
+	FizzBuzz   FizzBuzzConfig   `key:"fizzbuzz"`
+	Stats      StatsConfig      `key:"stats"`
+	WebSocket  WebSocketConfig  `key:"websocket"`
+	GraphQL    GraphQLConfig    `key:"graphql"`
+	Timeout    TimeoutConfig    `key:"timeout"`
+	Auth       AuthConfig       `key:"auth"`
+	Tenancy    TenancyConfig    `key:"tenancy"`
+	Admin      AdminConfig      `key:"admin"`
 }
 
 // ServerConfig holds server-related configuration
+// The public API listens on Port, or on the Unix socket at Socket created
+// with SocketMode, or, when Systemd is set, on the sockets passed by systemd
+// socket activation. The listeners are opened at startup only, an upgrade
+// hands them over to the new process as they are.
+// On SIGUSR2 the server upgrades to a new process of its binary, which must
+// be serving within UpgradeTimeout. PIDFile, when set, holds the ID of the
+// serving process.
+// With Prefork, PreforkWorkers processes (one per CPU when 0) serve the
+// listeners and count their stats in the master process.
 type ServerConfig struct {
-	Port string
+	Port           string        `key:"port" env:"PORT" default:"8080" validate:"required,port"`
+	Socket         string        `key:"socket" env:"SERVER_SOCKET" validate:"omitempty,startswith=/"`
+	SocketMode     string        `key:"socket_mode" env:"SERVER_SOCKET_MODE" default:"0660" validate:"file_mode"`
+	Systemd        bool          `key:"systemd" env:"SERVER_SYSTEMD"`
+	UpgradeTimeout time.Duration `key:"upgrade_timeout" env:"SERVER_UPGRADE_TIMEOUT" default:"30s" validate:"gt=0"`
+	PIDFile        string        `key:"pid_file" env:"SERVER_PID_FILE"`
+	Prefork        bool          `key:"prefork" env:"SERVER_PREFORK"`
+	PreforkWorkers int           `key:"prefork_workers" env:"SERVER_PREFORK_WORKERS" default:"0" validate:"gte=0"`
+}
+
+// TLSConfig holds the TLS settings of the server
+// TLS is enabled at startup when CertFile and KeyFile are set; the files are
+// read again when they change. CipherSuites only apply up to TLS 1.2.
+// With ClientCAFile set, clients present a certificate signed by one of its
+// CAs, always when ClientAuth is "require" and if they have one when it is
+// "optional". The subject common name of a verified certificate identifies
+// the client for authentication, rate limiting and stats.
+type TLSConfig struct {
+	CertFile     string   `key:"cert_file" env:"TLS_CERT_FILE" validate:"required_with=KeyFile"`
+	KeyFile      string   `key:"key_file" env:"TLS_KEY_FILE" validate:"required_with=CertFile"`
+	MinVersion   string   `key:"min_version" env:"TLS_MIN_VERSION" default:"1.2" validate:"oneof=1.0 1.1 1.2 1.3"`
+	CipherSuites []string `key:"cipher_suites" env:"TLS_CIPHER_SUITES" validate:"dive,cipher_suite"`
+	ClientCAFile string   `key:"client_ca_file" env:"TLS_CLIENT_CA_FILE" validate:"excluded_without=CertFile"`
+	ClientAuth   string   `key:"client_auth" env:"TLS_CLIENT_AUTH" default:"require" validate:"oneof=require optional"`
+}
+
+// Enabled reports whether the server terminates TLS
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" && c.KeyFile != ""
 }
 
 // TelemetryConfig holds OpenTelemetry configuration
 // Works with both OpenTelemetry Collector and Grafana Alloy
 type TelemetryConfig struct {
-	OTLPEndpoint      string
-	ServiceName       string
-	ResourceAttributes string
+	OTLPEndpoint       string `key:"otlp_endpoint" env:"TELEMETRY_OTLP_ENDPOINT" default:"alloy:4317"`
+	ServiceName        string `key:"service_name" env:"TELEMETRY_SERVICE_NAME" default:"fizzbuzz-server" validate:"required"`
+	ResourceAttributes string `key:"resource_attributes" env:"TELEMETRY_RESOURCE_ATTRIBUTES" default:"service.version=1.0.0,deployment.environment=development"`
 }
 
 // PrometheusConfig holds Prometheus configuration
 // Works with both Prometheus and Grafana Alloy
 type PrometheusConfig struct {
-	Enabled      bool
-	Endpoint     string
-	PushGateway  string
-	PushInterval time.Duration
+	Enabled      bool          `key:"enabled" env:"PROMETHEUS_ENABLED" default:"true"`
+	Endpoint     string        `key:"endpoint" env:"PROMETHEUS_ENDPOINT" default:"/metrics" validate:"required,startswith=/"`
+	PushGateway  string        `key:"push_gateway" env:"PROMETHEUS_PUSH_GATEWAY" default:"http://pushgateway:9091" validate:"omitempty,url"`
+	PushInterval time.Duration `key:"push_interval" env:"PROMETHEUS_PUSH_INTERVAL" default:"10s" validate:"gt=0"`
 }
 
-// Global configuration instance
-var config *Config
+// LogConfig holds logging configuration
+// File sinks are optional and rotate by size and age
+// Access logs keep the first AccessSampleBurst entries per AccessSamplePeriod,
+// then one in every AccessSampleEvery; warnings and errors are never sampled
+type LogConfig struct {
+	Level              string        `key:"level" env:"LOG_LEVEL" default:"debug" validate:"oneof=trace debug info warn error"`
+	AccessSampleEvery  uint32        `key:"access_sample_every" env:"LOG_ACCESS_SAMPLE_EVERY" default:"1" validate:"gte=1"`
+	AccessSampleBurst  uint32        `key:"access_sample_burst" env:"LOG_ACCESS_SAMPLE_BURST" default:"0"`
+	AccessSamplePeriod time.Duration `key:"access_sample_period" env:"LOG_ACCESS_SAMPLE_PERIOD" default:"1s" validate:"gt=0"`
+	File               string        `key:"file" env:"LOG_FILE"`
+	ErrorFile          string        `key:"error_file" env:"LOG_ERROR_FILE"`
+	MaxSizeMB          int           `key:"max_size_mb" env:"LOG_MAX_SIZE_MB" default:"100" validate:"gt=0"`
+	MaxAgeDays         int           `key:"max_age_days" env:"LOG_MAX_AGE_DAYS" default:"28" validate:"gte=0"`
+	MaxBackups         int           `key:"max_backups" env:"LOG_MAX_BACKUPS" default:"7" validate:"gte=0"`
+	Compress           bool          `key:"compress" env:"LOG_COMPRESS" default:"true"`
+}
 
-// Load loads configuration from environment variables
-func Load() (*Config, error) {
-	// Load .env file if it exists
-	_ = godotenv.Load()
+// RateLimitConfig holds the per client rate limit of the public API
+type RateLimitConfig struct {
+	Enabled    bool          `key:"enabled" env:"RATE_LIMIT_ENABLED" default:"false"`
+	Max        int           `key:"max" env:"RATE_LIMIT_MAX" default:"100" validate:"gt=0"`
+	Expiration time.Duration `key:"expiration" env:"RATE_LIMIT_EXPIRATION" default:"1s" validate:"gt=0"`
+}
 
-	config = &Config{
-		Server: ServerConfig{
-			Port: getEnv("PORT", "8080"),
-		},
-		Telemetry: TelemetryConfig{
-			OTLPEndpoint:       getEnv("TELEMETRY_OTLP_ENDPOINT", "alloy:4317"),
-			ServiceName:        getEnv("TELEMETRY_SERVICE_NAME", "fizzbuzz-server"),
-			ResourceAttributes: getEnv("TELEMETRY_RESOURCE_ATTRIBUTES", "service.version=1.0.0,deployment.environment=development"),
-		},
-		Prometheus: PrometheusConfig{
-			Enabled:      getBoolEnv("PROMETHEUS_ENABLED", true),
-			Endpoint:     getEnv("PROMETHEUS_ENDPOINT", "/metrics"),
-			PushGateway:  getEnv("PROMETHEUS_PUSH_GATEWAY", "http://pushgateway:9091"),
-			PushInterval: getDurationEnv("PROMETHEUS_PUSH_INTERVAL", 10*time.Second),
-		},
-	}
+// FizzBuzzConfig holds the bounds applied to fizzbuzz requests
+// Words are at most MaxWordLength characters of the WordCharacters classes;
+// requests whose response is estimated above MaxResponseBytes are rejected
+// before generation, 0 disabling the budget
+type FizzBuzzConfig struct {
+	MaxLimit         int      `key:"max_limit" env:"FIZZBUZZ_MAX_LIMIT" default:"10000" validate:"gt=0"`
+	MaxBatchSize     int      `key:"max_batch_size" env:"FIZZBUZZ_MAX_BATCH_SIZE" default:"100" validate:"gt=0"`
+	MaxWordLength    int      `key:"max_word_length" env:"FIZZBUZZ_MAX_WORD_LENGTH" default:"64" validate:"gt=0"`
+	WordCharacters   []string `key:"word_characters" env:"FIZZBUZZ_WORD_CHARACTERS" default:"letters,marks,numbers,punctuation,symbols,space" validate:"min=1,dive,oneof=letters marks numbers punctuation symbols space"`
+	MaxResponseBytes int      `key:"max_response_bytes" env:"FIZZBUZZ_MAX_RESPONSE_BYTES" default:"1048576" validate:"gte=0"`
+}
 
-	return config, nil
+// StatsConfig holds request statistics configuration
+// WindowRetention bounds the windows that can be queried, e.g. /stats?window=15m
+// /stats/events pushes the EventsTop most frequent requests when they change
+// and every EventsInterval, with a heartbeat comment every EventsHeartbeat
+type StatsConfig struct {
+	WindowRetention time.Duration `key:"window_retention" env:"STATS_WINDOW_RETENTION" default:"1h" validate:"gte=1m"`
+	EventsTop       int           `key:"events_top" env:"STATS_EVENTS_TOP" default:"3" validate:"gt=0,lte=100"`
+	EventsInterval  time.Duration `key:"events_interval" env:"STATS_EVENTS_INTERVAL" default:"30s" validate:"gt=0"`
+	EventsHeartbeat time.Duration `key:"events_heartbeat" env:"STATS_EVENTS_HEARTBEAT" default:"15s" validate:"gt=0"`
 }
 
-// Get returns the current configuration
-func Get() *Config {
-	if config == nil {
-		_, _ = Load()
-	}
-	return config
+// WebSocketConfig holds the settings of the /ws endpoint
+// A stream sends ChunkSize values per message and at most Window messages
+// ahead of the client acknowledgements
+type WebSocketConfig struct {
+	ChunkSize     int           `key:"chunk_size" env:"WS_CHUNK_SIZE" default:"100" validate:"gt=0"`
+	Window        int           `key:"window" env:"WS_WINDOW" default:"4" validate:"gt=0"`
+	MaxStreams    int           `key:"max_streams" env:"WS_MAX_STREAMS" default:"4" validate:"gt=0"`
+	StatsInterval time.Duration `key:"stats_interval" env:"WS_STATS_INTERVAL" default:"500ms" validate:"gt=0"`
+}
+
+// GraphQLConfig holds the limits of the /graphql endpoint
+// A request returns at most FizzBuzz.MaxLimit sequence values in total and
+// nests selections at most MaxDepth deep; stats subscriptions are updated at
+// most once per SubscriptionInterval
+type GraphQLConfig struct {
+	MaxDepth             int           `key:"max_depth" env:"GRAPHQL_MAX_DEPTH" default:"5" validate:"gt=0"`
+	SubscriptionInterval time.Duration `key:"subscription_interval" env:"GRAPHQL_SUBSCRIPTION_INTERVAL" default:"500ms" validate:"gt=0"`
+}
+
+// TimeoutConfig holds the deadline of the requests of each route
+// 0 disables a deadline; generation stops once it is exceeded or the client
+// disconnects
+type TimeoutConfig struct {
+	FizzBuzz time.Duration `key:"fizzbuzz" env:"TIMEOUT_FIZZBUZZ" default:"10s" validate:"gte=0"`
+	Batch    time.Duration `key:"batch" env:"TIMEOUT_BATCH" default:"30s" validate:"gte=0"`
+	RPC      time.Duration `key:"rpc" env:"TIMEOUT_RPC" default:"30s" validate:"gte=0"`
+	GraphQL  time.Duration `key:"graphql" env:"TIMEOUT_GRAPHQL" default:"30s" validate:"gte=0"`
+}
+
+// AuthConfig holds the credentials accepted by the public API
+// When APIKeys is empty the API is open
+type AuthConfig struct {
+	APIKeys []string `key:"api_keys" env:"API_KEYS" secret:"true"`
+}
+
+// TenancyConfig holds the tenants sharing the deployment
+// A request belongs to a tenant when it is made under /tenants/<name>, names
+// the tenant in the Header header, or presents one of the tenant's API keys.
+// Each tenant has its own stats, and may lower the limit cap and change the
+// default words. Tenants are configured in the config file only, e.g.
+// tenancy.tenants.acme.max_limit.
+type TenancyConfig struct {
+	Header  string                   `key:"header" env:"TENANT_HEADER" default:"X-Tenant"`
+	Tenants map[string]*TenantConfig `key:"tenants" validate:"dive,keys,excludesall=/?#%,endkeys,required"`
+}
+
+// TenantConfig holds the settings of one tenant
+// When APIKeys is set, requests of the tenant must present one of them.
+// MaxLimit caps the limit below FizzBuzz.MaxLimit, 0 keeps the global cap;
+// Str1 and Str2 replace fizz and buzz as the default words.
+type TenantConfig struct {
+	APIKeys  []string `key:"api_keys" secret:"true"`
+	MaxLimit int      `key:"max_limit" default:"0" validate:"gte=0"`
+	Str1     string   `key:"str1"`
+	Str2     string   `key:"str2"`
 }
 
-// Helper functions to get environment variables with defaults
-func getEnv(key, defaultValue string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
+// Tenant returns the settings of the tenant name, nil when it is not
+// configured
+func (c TenancyConfig) Tenant(name string) *TenantConfig {
+	if name == "" {
+		return nil
 	}
-	return value
+	return c.Tenants[name]
 }
 
-func getBoolEnv(key string, defaultValue bool) bool {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
+// Owner returns the tenant holding the API key, empty when none does
+func (c TenancyConfig) Owner(key string) string {
+	for name, tenant := range c.Tenants {
+		for _, k := range tenant.APIKeys {
+			if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+				return name
+			}
+		}
 	}
-	boolValue, err := strconv.ParseBool(value)
+	return ""
+}
+
+// AdminConfig holds the settings of the /admin endpoints
+// Requests to the admin endpoints present Token as a bearer token. When it is
+// empty they are only served on the admin listener, unauthenticated.
+// When Address is set, /metrics, /debug/pprof, /health and /admin are served
+// on a separate listener, a TCP address such as "127.0.0.1:9090" or a Unix
+// socket such as "unix:/run/fizzbuzz/admin.sock", instead of the public port.
+// Address is read at startup only.
+type AdminConfig struct {
+	Token   string `key:"token" env:"ADMIN_TOKEN" secret:"true"`
+	Address string `key:"address" env:"ADMIN_ADDRESS" validate:"omitempty,listen_address"`
+}
+
+// ErrPrintConfig is returned by Load, together with the loaded configuration,
+// when --print-config was given
+var ErrPrintConfig = errors.New("config: print requested")
+
+// Load loads configuration from defaults, an optional config file, environment
+// variables and the given command line arguments, in increasing precedence.
+// The config file is taken from --config or the CONFIG_FILE variable and may be
+// YAML, TOML or JSON. Invalid values are reported as a *KeyError naming the
+// offending key; the previous configuration is kept in that case.
+// The arguments are remembered and reused by Reload.
+func Load(args ...string) (*Config, error) {
+	// Load .env file if it exists
+	_ = godotenv.Load()
+
+	l := newLoader(args)
+	cfg, err := l.load()
 	if err != nil {
-		return defaultValue
+		return nil, err
+	}
+	if l.printConfig {
+		return cfg, ErrPrintConfig
 	}
-	return boolValue
+
+	state.mu.Lock()
+	state.args = args
+	state.file = l.configFile
+	state.mu.Unlock()
+
+	swap(cfg)
+	return cfg, nil
 }
 
-func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
+// Get returns the current configuration, loading it on first use.
+// If loading fails the defaults are used; call Load to get the error.
+// The returned value must be treated as read-only, a reload swaps in a new one.
+func Get() *Config {
+	if cfg := state.current.Load(); cfg != nil {
+		return cfg
 	}
-	duration, err := time.ParseDuration(value)
-	if err != nil {
-		return defaultValue
+	if _, err := Load(); err != nil {
+		state.current.CompareAndSwap(nil, Defaults())
 	}
-	return duration
-}
\ No newline at end of file
+	return state.current.Load()
+}
+
+// Defaults returns a configuration with every field set to its default
+func Defaults() *Config {
+	cfg := &Config{}
+	_ = walkFields(cfg, func(f field) error {
+		if f.def == "" {
+			return nil
+		}
+		return f.set(f.def)
+	})
+	return cfg
+}
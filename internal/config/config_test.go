@@ -0,0 +1,195 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "8080", cfg.Server.Port)
+	assert.Equal(t, 10*time.Second, cfg.Prometheus.PushInterval)
+	assert.True(t, cfg.Prometheus.Enabled)
+	assert.Equal(t, uint32(1), cfg.Log.AccessSampleEvery)
+	assert.Equal(t, Defaults(), cfg)
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+server:
+  port: "7000"
+log:
+  level: info
+  max_backups: 3
+prometheus:
+  push_interval: 30s
+`)
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("LOG_LEVEL", "warn")
+
+	cfg, err := Load("--server.port", "9000")
+	assert.NoError(t, err)
+	assert.Equal(t, "9000", cfg.Server.Port)
+	assert.Equal(t, "warn", cfg.Log.Level)
+	assert.Equal(t, 3, cfg.Log.MaxBackups)
+	assert.Equal(t, 30*time.Second, cfg.Prometheus.PushInterval)
+}
+
+func TestLoad_FileFormats(t *testing.T) {
+	files := map[string]string{
+		"config.toml": "[server]\nport = \"7001\"\n[log]\nmax_size_mb = 5\n",
+		"config.json": `{"server": {"port": "7001"}, "log": {"max_size_mb": 5}}`,
+	}
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := Load("--config", writeFile(t, name, content))
+			assert.NoError(t, err)
+			assert.Equal(t, "7001", cfg.Server.Port)
+			assert.Equal(t, 5, cfg.Log.MaxSizeMB)
+		})
+	}
+}
+
+func TestLoad_InvalidEnvValue(t *testing.T) {
+	t.Setenv("PROMETHEUS_ENABLED", "maybe")
+
+	_, err := Load()
+	var keyErr *KeyError
+	assert.True(t, errors.As(err, &keyErr))
+	assert.Equal(t, "prometheus.enabled", keyErr.Key)
+	assert.Equal(t, "env PROMETHEUS_ENABLED", keyErr.Source)
+}
+
+func TestLoad_ValidationError(t *testing.T) {
+	_, err := Load("--log.level", "loud")
+	var keyErr *KeyError
+	assert.True(t, errors.As(err, &keyErr))
+	assert.Equal(t, "log.level", keyErr.Key)
+}
+
+func TestLoad_Port(t *testing.T) {
+	cfg, err := Load("--server.port", "65535")
+	assert.NoError(t, err)
+	assert.Equal(t, "65535", cfg.Server.Port)
+
+	for _, port := range []string{"abc", "0", "99999", "-5", "+5", "1.5"} {
+		_, err := Load("--server.port", port)
+		var keyErr *KeyError
+		assert.True(t, errors.As(err, &keyErr), port)
+		assert.EqualError(t, err, fmt.Sprintf(`config: server.port: value %s does not satisfy "port"`, port))
+	}
+}
+
+func TestLoad_UnknownFileKey(t *testing.T) {
+	_, err := Load("--config", writeFile(t, "config.yaml", "server:\n  prot: 80\n"))
+	var keyErr *KeyError
+	assert.True(t, errors.As(err, &keyErr))
+	assert.Equal(t, "server.prot", keyErr.Key)
+}
+
+func TestLoad_TLSValidation(t *testing.T) {
+	cfg, err := Load("--tls.cert_file", "cert.pem", "--tls.key_file", "key.pem",
+		"--tls.cipher_suites", "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384")
+	assert.NoError(t, err)
+	assert.True(t, cfg.TLS.Enabled())
+
+	tests := map[string][]string{
+		"tls.key_file":         {"--tls.cert_file", "cert.pem"},
+		"tls.cipher_suites[0]": {"--tls.cert_file", "cert.pem", "--tls.key_file", "key.pem", "--tls.cipher_suites", "TLS_FAST"},
+		"tls.client_ca_file":   {"--tls.client_ca_file", "ca.pem"},
+		"tls.min_version":      {"--tls.min_version", "1.4"},
+	}
+	for key, args := range tests {
+		t.Run(key, func(t *testing.T) {
+			_, err := Load(args...)
+			var keyErr *KeyError
+			assert.True(t, errors.As(err, &keyErr))
+			assert.Equal(t, key, keyErr.Key)
+		})
+	}
+}
+
+func TestLoad_AdminAddress(t *testing.T) {
+	for _, address := range []string{"127.0.0.1:9090", ":9090", "[::1]:9090", "unix:/run/fizzbuzz/admin.sock"} {
+		cfg, err := Load("--admin.address", address)
+		assert.NoError(t, err, address)
+		assert.Equal(t, address, cfg.Admin.Address)
+	}
+	for _, address := range []string{"9090", "localhost:http", "unix:admin.sock"} {
+		_, err := Load("--admin.address", address)
+		var keyErr *KeyError
+		assert.True(t, errors.As(err, &keyErr), address)
+	}
+}
+
+func TestLoad_ServerSocket(t *testing.T) {
+	cfg, err := Load("--server.socket", "/run/fizzbuzz/server.sock", "--server.socket_mode", "0600")
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), cfg.Server.Mode())
+
+	for _, args := range [][]string{
+		{"--server.socket", "server.sock"},
+		{"--server.socket_mode", "0999"},
+		{"--server.socket_mode", "01777"},
+	} {
+		_, err := Load(args...)
+		var keyErr *KeyError
+		assert.True(t, errors.As(err, &keyErr), args)
+	}
+}
+
+func TestLoad_Tenants(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+auth:
+  api_keys: [shared]
+tenancy:
+  tenants:
+    acme:
+      api_keys: [acme-1, acme-2]
+      max_limit: 50
+      str1: foo
+    globex: {str2: bar}
+`)
+	cfg, err := Load("--config", path)
+	assert.NoError(t, err)
+	assert.Equal(t, "X-Tenant", cfg.Tenancy.Header)
+	assert.Equal(t, map[string]*TenantConfig{
+		"acme":   {APIKeys: []string{"acme-1", "acme-2"}, MaxLimit: 50, Str1: "foo"},
+		"globex": {Str2: "bar"},
+	}, cfg.Tenancy.Tenants)
+	assert.Equal(t, "acme", cfg.Tenancy.Owner("acme-2"))
+	assert.Empty(t, cfg.Tenancy.Owner("shared"))
+	assert.Nil(t, cfg.Tenancy.Tenant("initech"))
+
+	tenancy := Redacted(cfg)["tenancy"].(map[string]any)
+	acme := tenancy["tenants"].(map[string]any)["acme"].(map[string]any)
+	assert.Equal(t, []string{redactedValue, redactedValue}, acme["api_keys"])
+
+	for content, key := range map[string]string{
+		"tenancy:\n  tenants:\n    acme:\n      max_limit: -1\n":                           "tenancy.tenants.acme.max_limit",
+		"tenancy:\n  tenants:\n    acme:\n      mx_limit: 5\n":                             "tenancy.tenants.acme.mx_limit",
+		"auth:\n  api_keys: [k]\ntenancy:\n  tenants:\n    acme:\n      api_keys: [k]\n":   "tenancy.tenants.acme.api_keys",
+		"tenancy:\n  tenants:\n    a:\n      api_keys: [k]\n    b:\n      api_keys: [k]\n": "tenancy.tenants.b.api_keys",
+		"tenancy:\n  tenants:\n    \"a%b\":\n      str1: foo\n":                            "tenancy.tenants[a%b]",
+	} {
+		_, err := Load("--config", writeFile(t, "config.yaml", content))
+		var keyErr *KeyError
+		if assert.True(t, errors.As(err, &keyErr), content) {
+			assert.Equal(t, key, keyErr.Key, content)
+		}
+	}
+}
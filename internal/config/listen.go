@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// unixPrefix marks listen addresses naming a Unix socket
+const unixPrefix = "unix:"
+
+// UnixSocket returns the socket path of a "unix:/path" listen address
+func UnixSocket(address string) (string, bool) {
+	return strings.CutPrefix(address, unixPrefix)
+}
+
+// Mode returns the permissions of the Unix socket
+func (c ServerConfig) Mode() os.FileMode {
+	mode, _ := parseFileMode(c.SocketMode)
+	return mode
+}
+
+// parseFileMode parses octal permissions such as "0660"
+func parseFileMode(s string) (os.FileMode, error) {
+	mode, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || mode > 0o777 {
+		return 0, fmt.Errorf("invalid file mode %q", s)
+	}
+	return os.FileMode(mode), nil
+}
+
+// validPort accepts the decimal TCP ports from 1 to 65535
+func validPort(port string) bool {
+	n, err := strconv.ParseUint(port, 10, 16)
+	return err == nil && n > 0
+}
+
+// validListenAddress accepts "host:port", ":port" and "unix:/absolute/path"
+func validListenAddress(address string) bool {
+	if path, ok := UnixSocket(address); ok {
+		return filepath.IsAbs(path)
+	}
+	_, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	n, err := strconv.Atoi(port)
+	return err == nil && n >= 0 && n <= 65535
+}
+
+// Workers returns the number of prefork workers
+func (c ServerConfig) Workers() int {
+	if c.PreforkWorkers > 0 {
+		return c.PreforkWorkers
+	}
+	return runtime.NumCPU()
+}
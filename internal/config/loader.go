@@ -0,0 +1,447 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fizzbuzz-server/internal/fieldvalue"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Sources a configuration value can come from
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// KeyError reports an invalid configuration value
+type KeyError struct {
+	Key    string // dotted key, e.g. "server.port"
+	Source string // where the value came from, empty for validation errors
+	Err    error
+}
+
+func (e *KeyError) Error() string {
+	if e.Source == "" {
+		return fmt.Sprintf("config: %s: %v", e.Key, e.Err)
+	}
+	return fmt.Sprintf("config: %s (from %s): %v", e.Key, e.Source, e.Err)
+}
+
+func (e *KeyError) Unwrap() error {
+	return e.Err
+}
+
+// field is a leaf of the Config struct together with its tags
+type field struct {
+	key    string
+	env    string
+	def    string
+	secret bool
+	value  reflect.Value
+}
+
+func (f field) set(raw string) error {
+	return fieldvalue.Set(f.value, raw)
+}
+
+// walkFields calls fn for every leaf field of cfg, including those of the
+// entries of map sections keyed by entry name, e.g. "tenancy.tenants.acme.str1"
+func walkFields(cfg *Config, fn func(f field) error) error {
+	return walkStruct(reflect.ValueOf(cfg).Elem(), "", fn)
+}
+
+func walkStruct(v reflect.Value, prefix string, fn func(f field) error) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		key := sf.Tag.Get("key")
+		if key == "" || !sf.IsExported() {
+			continue
+		}
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct {
+			if err := walkStruct(fv, key, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if isSection(fv.Type()) {
+			names := make([]string, 0, fv.Len())
+			for _, name := range fv.MapKeys() {
+				names = append(names, name.String())
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				entry := fv.MapIndex(reflect.ValueOf(name)).Elem()
+				if err := walkStruct(entry, key+"."+name, fn); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if err := fn(field{
+			key:    key,
+			env:    sf.Tag.Get("env"),
+			def:    sf.Tag.Get("default"),
+			secret: sf.Tag.Get("secret") == "true",
+			value:  fv,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isSection reports whether t is a map section, a map of struct pointers
+// keyed by entry name
+func isSection(t reflect.Type) bool {
+	return t.Kind() == reflect.Map && t.Key().Kind() == reflect.String &&
+		t.Elem().Kind() == reflect.Pointer && t.Elem().Elem().Kind() == reflect.Struct
+}
+
+// addEntries creates the entries of the map sections named by the config
+// file keys, e.g. tenancy.tenants.acme.max_limit creates the entry acme, so
+// that walkFields reaches their fields. Entries are only defined in files.
+func addEntries(v reflect.Value, prefix string, values map[string]any) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		key := sf.Tag.Get("key")
+		if key == "" || !sf.IsExported() {
+			continue
+		}
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct {
+			addEntries(fv, key, values)
+			continue
+		}
+		if !isSection(fv.Type()) {
+			continue
+		}
+		for k := range values {
+			rest, ok := strings.CutPrefix(k, key+".")
+			if !ok {
+				continue
+			}
+			name, _, _ := strings.Cut(rest, ".")
+			if fv.IsNil() {
+				fv.Set(reflect.MakeMap(fv.Type()))
+			}
+			if !fv.MapIndex(reflect.ValueOf(name)).IsValid() {
+				fv.SetMapIndex(reflect.ValueOf(name), reflect.New(fv.Type().Elem().Elem()))
+			}
+		}
+	}
+}
+
+type loader struct {
+	args        []string
+	lookup      func(string) (string, bool)
+	configFile  string // resolved by load
+	printConfig bool   // --print-config was given
+}
+
+func newLoader(args []string) *loader {
+	return &loader{args: args, lookup: os.LookupEnv}
+}
+
+func (l *loader) load() (*Config, error) {
+	flags, configFile, err := l.parseFlags()
+	if err != nil {
+		return nil, err
+	}
+	if configFile == "" {
+		configFile, _ = l.lookup("CONFIG_FILE")
+	}
+	l.configFile = configFile
+
+	var fileValues map[string]any
+	if configFile != "" {
+		if fileValues, err = readConfigFile(configFile); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg := &Config{}
+	addEntries(reflect.ValueOf(cfg).Elem(), "", fileValues)
+	known := map[string]bool{}
+	err = walkFields(cfg, func(f field) error {
+		known[f.key] = true
+
+		raw, source := f.def, SourceDefault
+		if v, ok := fileValues[f.key]; ok {
+			raw, source = fileString(v), SourceFile
+		}
+		if f.env != "" {
+			if v, ok := l.lookup(f.env); ok && v != "" {
+				raw, source = v, SourceEnv+" "+f.env
+			}
+		}
+		if f.secret && f.env != "" {
+			v, fileSource, err := l.secretFile(f.env)
+			if err != nil {
+				return &KeyError{Key: f.key, Source: fileSource, Err: err}
+			}
+			if fileSource != "" {
+				if source == SourceEnv+" "+f.env {
+					return &KeyError{Key: f.key, Source: fileSource, Err: fmt.Errorf("%s and %s_FILE are both set", f.env, f.env)}
+				}
+				raw, source = v, fileSource
+			}
+		}
+		if v, ok := flags[f.key]; ok {
+			raw, source = v, SourceFlag+" --"+f.key
+		}
+
+		if raw == "" && source == SourceDefault {
+			return nil
+		}
+		if err := f.set(raw); err != nil {
+			return &KeyError{Key: f.key, Source: source, Err: err}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rejectUnknownKeys(fileValues, known, configFile); err != nil {
+		return nil, err
+	}
+	if err := validateConfig(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// secretFile reads the <ENV>_FILE indirection of a secret, the file content
+// with surrounding whitespace removed being the value. The returned source is
+// empty when the variable is not set.
+func (l *loader) secretFile(env string) (string, string, error) {
+	path, ok := l.lookup(env + "_FILE")
+	if !ok || path == "" {
+		return "", "", nil
+	}
+	source := SourceEnv + " " + env + "_FILE"
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", source, err
+	}
+	return strings.TrimSpace(string(data)), source, nil
+}
+
+// parseFlags registers one --<key> flag per config field plus --config and
+// returns the flags that were explicitly set
+func (l *loader) parseFlags() (map[string]string, string, error) {
+	set := map[string]string{}
+	if len(l.args) == 0 {
+		return set, "", nil
+	}
+
+	fs := flag.NewFlagSet("fizzbuzz-server", flag.ContinueOnError)
+	configFile := fs.String("config", "", "path to a YAML, TOML or JSON config file (env CONFIG_FILE)")
+	fs.BoolVar(&l.printConfig, "print-config", false, "print the effective configuration, secrets redacted, and exit")
+	values := map[string]*string{}
+	_ = walkFields(&Config{}, func(f field) error {
+		usage := "env " + f.env
+		if f.env == "" {
+			usage = "no env variable"
+		} else if f.secret {
+			usage += " or " + f.env + "_FILE"
+		}
+		values[f.key] = fs.String(f.key, f.def, usage)
+		return nil
+	})
+
+	if err := fs.Parse(l.args); err != nil {
+		return nil, "", err
+	}
+	if fs.NArg() > 0 {
+		return nil, "", fmt.Errorf("config: unexpected argument %q", fs.Arg(0))
+	}
+	fs.Visit(func(fl *flag.Flag) {
+		if v, ok := values[fl.Name]; ok {
+			set[fl.Name] = *v
+		}
+	})
+	return set, *configFile, nil
+}
+
+// readConfigFile decodes a config file and flattens it into dotted keys
+func readConfigFile(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config: reading %s: %w", path, err)
+	}
+
+	raw := map[string]any{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	case ".json":
+		err = json.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("config: unsupported config file format %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config: parsing %s: %w", path, err)
+	}
+
+	values := map[string]any{}
+	flatten("", raw, values)
+	return values, nil
+}
+
+func flatten(prefix string, in map[string]any, out map[string]any) {
+	for k, v := range in {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		// an empty section is kept, it may define an entry, e.g. acme: {}
+		if nested, ok := v.(map[string]any); ok && len(nested) > 0 {
+			flatten(key, nested, out)
+			continue
+		}
+		out[key] = v
+	}
+}
+
+// fileString renders a decoded file value the way it would be written in an
+// environment variable, lists becoming comma separated
+func fileString(v any) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case []any:
+		items := make([]string, len(val))
+		for i, item := range val {
+			items[i] = fileString(item)
+		}
+		return strings.Join(items, ",")
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+func rejectUnknownKeys(values map[string]any, known map[string]bool, path string) error {
+	var unknown []string
+	for key, v := range values {
+		if section, ok := v.(map[string]any); ok && len(section) == 0 {
+			continue
+		}
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return &KeyError{Key: unknown[0], Source: SourceFile + " " + path, Err: errors.New("unknown configuration key")}
+}
+
+var configValidator = func() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(sf reflect.StructField) string {
+		return sf.Tag.Get("key")
+	})
+	_ = v.RegisterValidation("cipher_suite", func(fl validator.FieldLevel) bool {
+		_, ok := CipherSuite(fl.Field().String())
+		return ok
+	})
+	_ = v.RegisterValidation("port", func(fl validator.FieldLevel) bool {
+		return validPort(fl.Field().String())
+	})
+	_ = v.RegisterValidation("listen_address", func(fl validator.FieldLevel) bool {
+		return validListenAddress(fl.Field().String())
+	})
+	_ = v.RegisterValidation("file_mode", func(fl validator.FieldLevel) bool {
+		_, err := parseFileMode(fl.Field().String())
+		return err == nil
+	})
+	return v
+}()
+
+// sectionEntry matches the entry of a map section in a validation namespace
+var sectionEntry = regexp.MustCompile(`\[([^\]]*)\]\.`)
+
+// validateConfig applies the validate tags and reports the first failure
+// with its dotted key
+func validateConfig(cfg *Config) error {
+	err := configValidator.Struct(cfg)
+	if err == nil {
+		return validateAPIKeys(cfg)
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) || len(validationErrors) == 0 {
+		return err
+	}
+	fe := validationErrors[0]
+	// Config.tenancy.tenants[acme].max_limit names tenancy.tenants.acme.max_limit
+	key := sectionEntry.ReplaceAllString(fe.Namespace(), ".$1.")
+	if i := strings.Index(key, "."); i >= 0 {
+		key = key[i+1:]
+	}
+	rule := fe.Tag()
+	if fe.Param() != "" {
+		rule += "=" + fe.Param()
+	}
+	value := fe.Value()
+	if isSecret(cfg, key) {
+		value = redactedValue
+	}
+	return &KeyError{Key: key, Err: fmt.Errorf("value %v does not satisfy %q", value, rule)}
+}
+
+// validateAPIKeys rejects API keys shared by tenants, or by a tenant and the
+// whole API, as they would not tell which tenant a request belongs to
+func validateAPIKeys(cfg *Config) error {
+	owners := map[string]string{}
+	for _, key := range cfg.Auth.APIKeys {
+		owners[key] = "auth.api_keys"
+	}
+	names := make([]string, 0, len(cfg.Tenancy.Tenants))
+	for name := range cfg.Tenancy.Tenants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		key := "tenancy.tenants." + name + ".api_keys"
+		for _, apiKey := range cfg.Tenancy.Tenants[name].APIKeys {
+			if owner, ok := owners[apiKey]; ok && owner != key {
+				return &KeyError{Key: key, Err: fmt.Errorf("API key %s also in %s", redactedValue, owner)}
+			}
+			owners[apiKey] = key
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"encoding/json"
+	"fizzbuzz-server/internal/fieldvalue"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const redactedValue = "******"
+
+// Redacted returns the configuration as nested maps keyed like the config
+// file, with the values of fields tagged secret:"true" masked. Unset secrets
+// stay empty so a dump still shows whether they are configured.
+func Redacted(cfg *Config) map[string]any {
+	out := map[string]any{}
+	_ = walkFields(cfg, func(f field) error {
+		section := out
+		parts := strings.Split(f.key, ".")
+		for _, part := range parts[:len(parts)-1] {
+			next, ok := section[part].(map[string]any)
+			if !ok {
+				next = map[string]any{}
+				section[part] = next
+			}
+			section = next
+		}
+		section[parts[len(parts)-1]] = dumpValue(f)
+		return nil
+	})
+	return out
+}
+
+// Print writes the redacted configuration as indented JSON
+func Print(w io.Writer, cfg *Config) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(Redacted(cfg))
+}
+
+func dumpValue(f field) any {
+	if f.secret && !f.value.IsZero() {
+		if f.value.Kind() == reflect.Slice {
+			masked := make([]string, f.value.Len())
+			for i := range masked {
+				masked[i] = redactedValue
+			}
+			return masked
+		}
+		return redactedValue
+	}
+	if f.value.Type() == fieldvalue.DurationType {
+		return time.Duration(f.value.Int()).String()
+	}
+	return f.value.Interface()
+}
+
+// isSecret reports whether the dotted key names a secret field of cfg
+func isSecret(cfg *Config, key string) bool {
+	secret := false
+	_ = walkFields(cfg, func(f field) error {
+		if f.key == key {
+			secret = f.secret
+		}
+		return nil
+	})
+	return secret
+}
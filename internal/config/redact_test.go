@@ -0,0 +1,54 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad_SecretFromFile(t *testing.T) {
+	t.Setenv("API_KEYS_FILE", writeFile(t, "api_keys", "key-one,key-two\n"))
+	t.Setenv("ADMIN_TOKEN_FILE", writeFile(t, "admin_token", "  s3cret\n"))
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"key-one", "key-two"}, cfg.Auth.APIKeys)
+	assert.Equal(t, "s3cret", cfg.Admin.Token)
+}
+
+func TestLoad_SecretSetTwice(t *testing.T) {
+	t.Setenv("ADMIN_TOKEN", "s3cret")
+	t.Setenv("ADMIN_TOKEN_FILE", writeFile(t, "admin_token", "s3cret"))
+
+	_, err := Load()
+	var keyErr *KeyError
+	assert.True(t, errors.As(err, &keyErr))
+	assert.Equal(t, "admin.token", keyErr.Key)
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := Defaults()
+	cfg.Auth.APIKeys = []string{"key-one", "key-two"}
+	cfg.Admin.Token = "hunter2"
+
+	dump := Redacted(cfg)
+	auth := dump["auth"].(map[string]any)
+	prometheus := dump["prometheus"].(map[string]any)
+	admin := dump["admin"].(map[string]any)
+
+	assert.Equal(t, []string{redactedValue, redactedValue}, auth["api_keys"])
+	assert.Equal(t, redactedValue, admin["token"])
+	assert.Equal(t, "10s", prometheus["push_interval"])
+	assert.Equal(t, "8080", dump["server"].(map[string]any)["port"])
+
+	// unset secrets stay empty
+	cfg.Admin.Token = ""
+	assert.Equal(t, "", Redacted(cfg)["admin"].(map[string]any)["token"])
+}
+
+func TestLoad_PrintConfig(t *testing.T) {
+	cfg, err := Load("--print-config")
+	assert.ErrorIs(t, err, ErrPrintConfig)
+	assert.NotNil(t, cfg)
+}
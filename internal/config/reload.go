@@ -0,0 +1,147 @@
+package config
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Subscriber is notified after a new configuration has been swapped in
+type Subscriber func(old, new *Config)
+
+var state struct {
+	current   atomic.Pointer[Config]
+	version   atomic.Uint64
+	loadedAt  atomic.Int64
+	mu        sync.Mutex
+	args      []string
+	file      string
+	subs      map[int]Subscriber
+	nextSubID int
+}
+
+// swap installs cfg as the current configuration and notifies subscribers
+func swap(cfg *Config) {
+	old := state.current.Swap(cfg)
+	state.version.Add(1)
+	state.loadedAt.Store(time.Now().UnixNano())
+
+	if old == nil {
+		return
+	}
+	state.mu.Lock()
+	subs := make([]Subscriber, 0, len(state.subs))
+	for _, sub := range state.subs {
+		subs = append(subs, sub)
+	}
+	state.mu.Unlock()
+
+	for _, sub := range subs {
+		sub(old, cfg)
+	}
+}
+
+// Subscribe registers fn to be called whenever the configuration is reloaded.
+// It returns a function removing the subscription.
+func Subscribe(fn Subscriber) (unsubscribe func()) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if state.subs == nil {
+		state.subs = map[int]Subscriber{}
+	}
+	id := state.nextSubID
+	state.nextSubID++
+	state.subs[id] = fn
+
+	return func() {
+		state.mu.Lock()
+		defer state.mu.Unlock()
+		delete(state.subs, id)
+	}
+}
+
+// Reload loads the configuration again from the same sources as the last Load.
+// An invalid configuration is rejected and the current one stays active.
+func Reload() (*Config, error) {
+	state.mu.Lock()
+	args := state.args
+	state.mu.Unlock()
+	return Load(args...)
+}
+
+// Version is incremented every time a configuration is swapped in
+func Version() uint64 {
+	return state.version.Load()
+}
+
+// LoadedAt returns when the current configuration was swapped in
+func LoadedAt() time.Time {
+	return time.Unix(0, state.loadedAt.Load())
+}
+
+// File returns the config file used by the last successful Load, if any
+func File() string {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	return state.file
+}
+
+// Watch reloads the configuration whenever the config file changes, until ctx
+// is done. Editors often replace files instead of writing them, so the parent
+// directory is watched and events are debounced. onError receives rejected
+// reloads. Watch returns immediately when no config file is in use.
+func Watch(ctx context.Context, onError func(error)) error {
+	path := File()
+	if path == "" {
+		return nil
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		_ = watcher.Close()
+		return err
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		const debounce = 100 * time.Millisecond
+		timer := time.NewTimer(debounce)
+		timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) == path && event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+					timer.Reset(debounce)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				onError(err)
+			case <-timer.C:
+				if _, err := Reload(); err != nil {
+					onError(err)
+				}
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReload_NotifiesSubscribers(t *testing.T) {
+	path := writeFile(t, "config.yaml", "rate_limit:\n  max: 10\n")
+	_, err := Load("--config", path)
+	assert.NoError(t, err)
+	version := Version()
+
+	var got *Config
+	unsubscribe := Subscribe(func(old, new *Config) {
+		got = new
+	})
+	defer unsubscribe()
+
+	assert.NoError(t, os.WriteFile(path, []byte("rate_limit:\n  max: 20\n"), 0o600))
+	cfg, err := Reload()
+	assert.NoError(t, err)
+	assert.Equal(t, 20, cfg.RateLimit.Max)
+	assert.Equal(t, cfg, got)
+	assert.Equal(t, version+1, Version())
+}
+
+func TestReload_RejectsInvalidConfig(t *testing.T) {
+	path := writeFile(t, "config.yaml", "rate_limit:\n  max: 10\n")
+	_, err := Load("--config", path)
+	assert.NoError(t, err)
+	version := Version()
+
+	assert.NoError(t, os.WriteFile(path, []byte("rate_limit:\n  max: -1\n"), 0o600))
+	_, err = Reload()
+	assert.Error(t, err)
+	assert.Equal(t, 10, Get().RateLimit.Max)
+	assert.Equal(t, version, Version())
+}
+
+func TestWatch_ReloadsOnFileChange(t *testing.T) {
+	path := writeFile(t, "config.yaml", "fizzbuzz:\n  max_limit: 100\n")
+	_, err := Load("--config", path)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.NoError(t, Watch(ctx, func(err error) { t.Error(err) }))
+
+	assert.NoError(t, os.WriteFile(path, []byte("fizzbuzz:\n  max_limit: 200\n"), 0o600))
+	assert.Eventually(t, func() bool {
+		return Get().FizzBuzz.MaxLimit == 200
+	}, 2*time.Second, 20*time.Millisecond)
+}
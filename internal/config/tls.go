@@ -0,0 +1,30 @@
+package config
+
+import "crypto/tls"
+
+// tlsVersions maps the accepted tls.min_version values
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Version returns the crypto/tls constant of MinVersion
+func (c TLSConfig) Version() uint16 {
+	return tlsVersions[c.MinVersion]
+}
+
+// CipherSuite returns the ID of a cipher suite given by its standard name,
+// e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256. Insecure suites are accepted
+// so that old clients can still be served when required.
+func CipherSuite(name string) (uint16, bool) {
+	for _, suites := range [][]*tls.CipherSuite{tls.CipherSuites(), tls.InsecureCipherSuites()} {
+		for _, suite := range suites {
+			if suite.Name == name {
+				return suite.ID, true
+			}
+		}
+	}
+	return 0, false
+}
@@ -1,6 +1,9 @@
 package entities
 
-import "sync"
+import (
+	"sync"
+	"time"
+)
 
 // RequestStats holds request statistics with thread-safe access
 type RequestStats struct {
@@ -10,11 +13,11 @@ type RequestStats struct {
 
 // FizzBuzzRequest represents the expected query parameters
 type FizzBuzzRequest struct {
-	Int1  int    `query:"int1" validate:"required,gt=0"`
-	Int2  int    `query:"int2" validate:"required,gt=0"`
-	Limit int    `query:"limit" validate:"required,gt=0,lte=10000"`
-	Str1  string `query:"str1" validate:"required" default:"fizz"`
-	Str2  string `query:"str2" validate:"required" default:"buzz"`
+	Int1  int    `query:"int1" json:"int1" form:"int1" validate:"required,gt=0"`
+	Int2  int    `query:"int2" json:"int2" form:"int2" validate:"required,gt=0"`
+	Limit int    `query:"limit" json:"limit" form:"limit" validate:"required,gt=0,maxlimit"`
+	Str1  string `query:"str1" json:"str1" form:"str1" validate:"required,wordlen,wordchars" default:"fizz"`
+	Str2  string `query:"str2" json:"str2" form:"str2" validate:"required,wordlen,wordchars" default:"buzz"`
 }
 
 type StatsKeys struct {
@@ -24,3 +27,87 @@ type StatsKeys struct {
 	Str1  string
 	Str2  string
 }
+
+// StatsEntry is a request parameter set with the number of times it was requested
+type StatsEntry struct {
+	Int1  int    `json:"int1"`
+	Int2  int    `json:"int2"`
+	Limit int    `json:"limit"`
+	Str1  string `json:"str1"`
+	Str2  string `json:"str2"`
+	Hits  int    `json:"hits"`
+}
+
+// ClientStats is the number of requests made by an identified client
+type ClientStats struct {
+	Client   string `json:"client"`
+	Requests int    `json:"requests"`
+}
+
+// ClientStatsResponse represents the client stats endpoint response
+type ClientStatsResponse struct {
+	Clients []ClientStats `json:"clients"`
+}
+
+// TenantStats sums up the requests of one tenant
+type TenantStats struct {
+	Tenant              string      `json:"tenant"`
+	Configured          bool        `json:"configured"`
+	MostFrequentRequest *StatsEntry `json:"most_frequent_request,omitempty"`
+}
+
+// TenantStatsResponse represents the tenant stats endpoint response
+type TenantStatsResponse struct {
+	Tenants []TenantStats `json:"tenants"`
+}
+
+// StatsSnapshot is the portable form of the request statistics,
+// used to export stats and import them into another instance.
+// Tenants holds the counters of each tenant, apart from Entries.
+type StatsSnapshot struct {
+	ExportedAt time.Time                `json:"exported_at"`
+	Entries    []StatsEntry             `json:"entries"`
+	Tenants    map[string]StatsSnapshot `json:"tenants,omitempty"`
+}
+
+// FizzBuzzResponse represents the fizzbuzz endpoint response
+type FizzBuzzResponse struct {
+	Result []string `json:"result"`
+}
+
+// BatchResult is the outcome of one parameter set of a batch request,
+// either a result or the problem that prevented it
+type BatchResult struct {
+	Result []string `json:"result,omitempty"`
+	Error  *Problem `json:"error,omitempty"`
+}
+
+// StatsResponse represents the stats endpoint response
+type StatsResponse struct {
+	MostFrequentRequest StatsEntry `json:"most_frequent_request"`
+}
+
+// TopStatsResponse represents the stats top endpoint response
+type TopStatsResponse struct {
+	Window string       `json:"window,omitempty"`
+	Top    []StatsEntry `json:"top"`
+}
+
+// Problem is the body of every error response, an RFC 7807 problem details
+// document served as application/problem+json
+type Problem struct {
+	Type   string              `json:"type"`
+	Title  string              `json:"title"`
+	Status int                 `json:"status"`
+	Detail string              `json:"detail,omitempty"`
+	Errors []ProblemFieldError `json:"errors,omitempty"`
+}
+
+// ProblemFieldError describes a parameter rejected by validation, by the
+// name it is sent under
+type ProblemFieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"` // the validation rule, e.g. required
+	Message string `json:"message"`
+	Param   string `json:"param,omitempty"`
+}
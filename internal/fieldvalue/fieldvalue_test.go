@@ -0,0 +1,44 @@
+package fieldvalue
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  any // pointer to the field
+		raw     string
+		want    any
+		wantErr string
+	}{
+		{"string", new(string), " fizz ", "fizz", ""},
+		{"bool", new(bool), "true", true, ""},
+		{"int", new(int), " 15", 15, ""},
+		{"int8 overflow", new(int8), "300", int8(0), `strconv.ParseInt: parsing "300": value out of range`},
+		{"uint", new(uint16), "8080", uint16(8080), ""},
+		{"negative uint", new(uint), "-1", uint(0), `strconv.ParseUint: parsing "-1": invalid syntax`},
+		{"float", new(float64), "0.5", 0.5, ""},
+		{"duration", new(time.Duration), "1m30s", 90 * time.Second, ""},
+		{"invalid duration", new(time.Duration), "90", time.Duration(0), `time: missing unit in duration "90"`},
+		{"string slice", new([]string), "a, b,,c ", []string{"a", "b", "c"}, ""},
+		{"empty string slice", new([]string), " ", []string(nil), ""},
+		{"unsupported", new(map[string]string), "a", map[string]string(nil), "unsupported type map[string]string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := reflect.ValueOf(tt.target).Elem()
+			err := Set(v, tt.raw)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, v.Interface())
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// ErrSubscriptionTransport is reported when a subscription is sent to
+// Execute, which only returns one result
+var ErrSubscriptionTransport = errors.New("subscriptions require the WebSocket transport")
+
+// Request is a GraphQL request as sent over HTTP or WebSocket
+type Request struct {
+	Query         string         `json:"query"`
+	Variables     map[string]any `json:"variables,omitempty"`
+	OperationName string         `json:"operationName,omitempty"`
+}
+
+// Executor runs requests against the schema
+type Executor struct {
+	schema   graphql.Schema
+	resolver *Resolver
+}
+
+// New builds the schema resolving through r
+func New(r Resolver) (*Executor, error) {
+	schema, err := r.schema()
+	if err != nil {
+		return nil, err
+	}
+	return &Executor{schema: schema, resolver: &r}, nil
+}
+
+// Execute runs a query. Subscriptions are rejected.
+func (e *Executor) Execute(ctx context.Context, req Request) *graphql.Result {
+	doc, op, limits, result := e.prepare(req)
+	if result != nil {
+		return result
+	}
+	if op.Operation == ast.OperationTypeSubscription {
+		return errorResult(ErrSubscriptionTransport)
+	}
+	return graphql.Execute(e.params(ctx, doc, req, limits))
+}
+
+// Subscribe runs a request, sending one result for a query and one per
+// update for a subscription. The channel is closed when the request is done
+// or ctx is cancelled, and must be drained.
+func (e *Executor) Subscribe(ctx context.Context, req Request) <-chan *graphql.Result {
+	doc, op, limits, result := e.prepare(req)
+	if result == nil && op.Operation == ast.OperationTypeSubscription {
+		return graphql.ExecuteSubscription(e.params(ctx, doc, req, limits))
+	}
+	if result == nil {
+		result = graphql.Execute(e.params(ctx, doc, req, limits))
+	}
+	results := make(chan *graphql.Result, 1)
+	results <- result
+	close(results)
+	return results
+}
+
+// prepare parses and validates a request, returning a result holding the
+// errors when it cannot be executed
+func (e *Executor) prepare(req Request) (*ast.Document, *ast.OperationDefinition, Limits, *graphql.Result) {
+	limits := e.resolver.Limits()
+
+	src := source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"})
+	doc, err := parser.Parse(parser.ParseParams{Source: src})
+	if err != nil {
+		return nil, nil, limits, errorResult(err)
+	}
+	if validation := graphql.ValidateDocument(&e.schema, doc, nil); !validation.IsValid {
+		return nil, nil, limits, &graphql.Result{Errors: validation.Errors}
+	}
+
+	op, err := operation(doc, req.OperationName)
+	if err != nil {
+		return nil, nil, limits, errorResult(err)
+	}
+	if depth := queryDepth(op.SelectionSet, fragments(doc), map[string]bool{}); depth > limits.MaxDepth {
+		return nil, nil, limits, errorResult(fmt.Errorf("query depth %d exceeds the limit of %d", depth, limits.MaxDepth))
+	}
+	return doc, op, limits, nil
+}
+
+func (e *Executor) params(ctx context.Context, doc *ast.Document, req Request, limits Limits) graphql.ExecuteParams {
+	return graphql.ExecuteParams{
+		Schema:        e.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       context.WithValue(ctx, budgetKey{}, newBudget(limits.MaxCost, limits.MaxResponseBytes)),
+	}
+}
+
+func errorResult(err error) *graphql.Result {
+	return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+}
+
+// operation returns the operation to run: the named one, or the only one
+func operation(doc *ast.Document, name string) (*ast.OperationDefinition, error) {
+	var found *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if name == "" {
+			if found != nil {
+				return nil, fmt.Errorf("operationName is required when the document has several operations")
+			}
+			found = op
+		} else if op.Name != nil && op.Name.Value == name {
+			return op, nil
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("unknown operation %q", name)
+	}
+	return found, nil
+}
+
+func fragments(doc *ast.Document) map[string]*ast.FragmentDefinition {
+	defs := map[string]*ast.FragmentDefinition{}
+	for _, def := range doc.Definitions {
+		if f, ok := def.(*ast.FragmentDefinition); ok {
+			defs[f.Name.Value] = f
+		}
+	}
+	return defs
+}
+
+// queryDepth returns how deep fields are nested in set, fragments expanded.
+// Introspection fields are not counted so that tools can load the schema.
+func queryDepth(set *ast.SelectionSet, defs map[string]*ast.FragmentDefinition, visiting map[string]bool) int {
+	if set == nil {
+		return 0
+	}
+	deepest := 0
+	for _, selection := range set.Selections {
+		depth := 0
+		switch s := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(s.Name.Value, "__") {
+				continue
+			}
+			depth = 1 + queryDepth(s.SelectionSet, defs, visiting)
+		case *ast.InlineFragment:
+			depth = queryDepth(s.SelectionSet, defs, visiting)
+		case *ast.FragmentSpread:
+			def, ok := defs[s.Name.Value]
+			if !ok || visiting[s.Name.Value] {
+				continue
+			}
+			visiting[s.Name.Value] = true
+			depth = queryDepth(def.SelectionSet, defs, visiting)
+			delete(visiting, s.Name.Value)
+		}
+		deepest = max(deepest, depth)
+	}
+	return deepest
+}
+
+type clientKey struct{}
+
+// WithClient attributes the requests run with ctx to an identified client
+// in the stats
+func WithClient(ctx context.Context, client string) context.Context {
+	if client == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+type tenantKey struct{}
+
+// WithTenant runs the requests run with ctx in the stats of tenant, the
+// default ones when empty. The limit cap and the default words of the tenant
+// are set on ctx for the binder.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+func tenantFrom(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+type budgetKey struct{}
+
+// budget is the number of sequence values a request may still return, and
+// the estimated bytes they may take when maxBytes is not 0
+type budget struct {
+	max       int
+	maxBytes  int
+	remaining atomic.Int64
+	used      atomic.Int64 // estimated bytes
+}
+
+func newBudget(max, maxBytes int) *budget {
+	b := &budget{max: max, maxBytes: maxBytes}
+	b.remaining.Store(int64(max))
+	return b
+}
+
+func budgetFrom(ctx context.Context) *budget {
+	if b, ok := ctx.Value(budgetKey{}).(*budget); ok {
+		return b
+	}
+	return newBudget(0, 0)
+}
+
+func (b *budget) take(n int) error {
+	if b.remaining.Add(-int64(n)) < 0 {
+		return fmt.Errorf("query cost exceeds the limit of %d sequence values", b.max)
+	}
+	return nil
+}
+
+// takeBytes adds size to the estimated bytes of the request, returning the
+// total and whether it fits
+func (b *budget) takeBytes(size int) (int, bool) {
+	total := int(b.used.Add(int64(size)))
+	return total, b.maxBytes == 0 || total <= b.maxBytes
+}
@@ -0,0 +1,348 @@
+// Package graph serves the GraphQL API over the FizzBuzz and stats services.
+//
+//	type Query {
+//	  fizzbuzz(int1: Int!, int2: Int!, limit: Int!, str1: String, str2: String,
+//	           offset: Int = 0, count: Int): [String!]!
+//	  stats(window: String): Stats!
+//	}
+//	type Subscription {
+//	  stats(window: String): Stats!
+//	}
+//	type Stats {
+//	  window: String
+//	  mostFrequent: StatsEntry
+//	  top(n: Int = 10): [StatsEntry!]!
+//	}
+//	type StatsEntry { int1: Int! int2: Int! limit: Int! str1: String! str2: String! hits: Int! }
+package graph
+
+import (
+	"context"
+	"errors"
+	"fizzbuzz-server/internal/apps/contracts"
+	"fizzbuzz-server/internal/binding"
+	"fizzbuzz-server/internal/entities"
+	"fizzbuzz-server/internal/i18n"
+	"fizzbuzz-server/internal/metrics"
+	"fizzbuzz-server/internal/output"
+	"fizzbuzz-server/internal/services"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+// maxTopEntries bounds the n argument of Stats.top
+const maxTopEntries = 100
+
+// Limits bound the work of a request
+type Limits struct {
+	MaxDepth             int           // deepest selection, introspection excluded
+	MaxCost              int           // sequence values returned by all fizzbuzz fields
+	MaxResponseBytes     int           // estimated size of the values of all fizzbuzz fields, 0 for no bound
+	SubscriptionInterval time.Duration // shortest delay between two stats updates
+}
+
+// Resolver holds what the schema resolves through. Limits is called for
+// every request, so changed limits apply to the next one.
+type Resolver struct {
+	FizzBuzz contracts.FizzBuzzServiceIface
+	Stats    contracts.StatsServiceIface
+	Binder   *binding.Binder
+	Limits   func() Limits
+}
+
+// statsQuery is the source of the Stats fields
+type statsQuery struct {
+	window time.Duration
+}
+
+func (r *Resolver) schema() (graphql.Schema, error) {
+	entry := graphql.NewObject(graphql.ObjectConfig{
+		Name: "StatsEntry",
+		Fields: graphql.Fields{
+			"int1":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"int2":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"limit": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"str1":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"str2":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"hits":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+
+	stats := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Stats",
+		Fields: graphql.Fields{
+			"window": &graphql.Field{
+				Type:        graphql.String,
+				Description: "Window the counts cover, null for all time",
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					if q := p.Source.(statsQuery); q.window > 0 {
+						return q.window.String(), nil
+					}
+					return nil, nil
+				},
+			},
+			"mostFrequent": &graphql.Field{
+				Type:        entry,
+				Description: "Most frequent request, null when none was made",
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					top, err := r.stats(p.Context).Top(1, p.Source.(statsQuery).window)
+					if err != nil || len(top) == 0 {
+						return nil, err
+					}
+					return top[0], nil
+				},
+			},
+			"top": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(entry))),
+				Description: "Most frequent requests, most frequent first",
+				Args: graphql.FieldConfigArgument{
+					"n": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 10},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					n := p.Args["n"].(int)
+					if n <= 0 || n > maxTopEntries {
+						return nil, fmt.Errorf("n must be between 1 and %d", maxTopEntries)
+					}
+					return r.stats(p.Context).Top(n, p.Source.(statsQuery).window)
+				},
+			},
+		},
+	})
+
+	windowArgs := graphql.FieldConfigArgument{
+		"window": &graphql.ArgumentConfig{
+			Type:        graphql.String,
+			Description: "Only count requests within this duration, e.g. 15m",
+		},
+	}
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"fizzbuzz": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))),
+				Description: "Values offset+1 to offset+count of the FizzBuzz sequence",
+				Args: graphql.FieldConfigArgument{
+					"int1":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"int2":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"limit":  &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"str1":   &graphql.ArgumentConfig{Type: graphql.String, Description: "Defaults to fizz, or to the word of the tenant"},
+					"str2":   &graphql.ArgumentConfig{Type: graphql.String, Description: "Defaults to buzz, or to the word of the tenant"},
+					"offset": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+					"count":  &graphql.ArgumentConfig{Type: graphql.Int, Description: "Defaults to the rest of the sequence"},
+				},
+				Resolve: r.fizzbuzz,
+			},
+			"stats": &graphql.Field{
+				Type:    graphql.NewNonNull(stats),
+				Args:    windowArgs,
+				Resolve: resolveStats,
+			},
+		},
+	})
+
+	subscription := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Subscription",
+		Fields: graphql.Fields{
+			"stats": &graphql.Field{
+				Type:        graphql.NewNonNull(stats),
+				Description: "Current stats, then an update after every change",
+				Args:        windowArgs,
+				Subscribe:   r.subscribeStats,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:        query,
+		Subscription: subscription,
+	})
+}
+
+func (r *Resolver) fizzbuzz(p graphql.ResolveParams) (any, error) {
+	str1, _ := p.Args["str1"].(string)
+	str2, _ := p.Args["str2"].(string)
+	req := entities.FizzBuzzRequest{
+		Int1:  p.Args["int1"].(int),
+		Int2:  p.Args["int2"].(int),
+		Limit: p.Args["limit"].(int),
+		Str1:  str1,
+		Str2:  str2,
+	}
+	if err := r.Binder.Complete(p.Context, &req); err != nil {
+		var bindErr *binding.Error
+		if errors.As(err, &bindErr) {
+			return nil, validationError{err: bindErr, translator: i18n.FromContext(p.Context)}
+		}
+		return nil, err
+	}
+
+	offset := p.Args["offset"].(int)
+	if offset < 0 || offset > req.Limit {
+		return nil, fmt.Errorf("offset must be between 0 and limit")
+	}
+	n := req.Limit - offset
+	if count, ok := p.Args["count"].(int); ok {
+		if count < 0 {
+			return nil, fmt.Errorf("count must not be negative")
+		}
+		n = min(n, count)
+	}
+	budget := budgetFrom(p.Context)
+	if err := budget.take(n); err != nil {
+		return nil, err
+	}
+	size := services.ResponseSize(output.FormatJSON, req.Int1, req.Int2, offset+1, offset+n, req.Str1, req.Str2)
+	if total, ok := budget.takeBytes(size); !ok {
+		metrics.ResponseBudgetRejections.WithLabelValues("graphql").Inc()
+		return nil, responseTooLargeError{size: total, budget: budget.maxBytes, translator: i18n.FromContext(p.Context)}
+	}
+
+	values := make([]string, 0, n)
+	i := 0
+	for value := range r.FizzBuzz.SequenceContext(p.Context, req.Int1, req.Int2, req.Limit, req.Str1, req.Str2) {
+		if len(values) == n {
+			break
+		}
+		if i >= offset {
+			values = append(values, value)
+		}
+		i++
+	}
+	if len(values) < n {
+		return nil, p.Context.Err()
+	}
+
+	stats := r.stats(p.Context)
+	stats.Record(req)
+	if client, ok := p.Context.Value(clientKey{}).(string); ok {
+		stats.RecordClient(client)
+	}
+	return values, nil
+}
+
+// validationError reports the rejected arguments in the extensions of the
+// GraphQL error, with the codes of the REST problems
+type validationError struct {
+	err        *binding.Error
+	translator i18n.Translator
+}
+
+func (e validationError) Error() string {
+	return e.err.Error()
+}
+
+func (e validationError) Extensions() map[string]any {
+	fields := make([]map[string]string, len(e.err.Fields))
+	for i, fe := range e.err.Fields {
+		fields[i] = map[string]string{"field": fe.Field, "code": fe.Rule, "message": fe.Message(e.translator)}
+		if fe.Param != "" {
+			fields[i]["param"] = fe.Param
+		}
+	}
+	return map[string]any{"code": "validation-error", "errors": fields}
+}
+
+// stats returns the stats of the tenant of ctx
+func (r *Resolver) stats(ctx context.Context) contracts.StatsServiceIface {
+	if name := tenantFrom(ctx); name != "" {
+		return r.Stats.Tenant(name)
+	}
+	return r.Stats
+}
+
+func resolveStats(p graphql.ResolveParams) (any, error) {
+	window, err := parseWindow(p.Args)
+	if err != nil {
+		return nil, err
+	}
+	return statsQuery{window: window}, nil
+}
+
+// subscribeStats sends the stats now and after each change, at most once per
+// subscription interval, until the request context is done
+func (r *Resolver) subscribeStats(p graphql.ResolveParams) (any, error) {
+	window, err := parseWindow(p.Args)
+	if err != nil {
+		return nil, err
+	}
+	interval := r.Limits().SubscriptionInterval
+
+	changed := make(chan struct{}, 1)
+	unsubscribe := r.stats(p.Context).Subscribe(func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+
+	updates := make(chan any)
+	go func() {
+		defer close(updates)
+		defer unsubscribe()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if !send(p.Context, updates, statsQuery{window: window}) {
+				return
+			}
+			select {
+			case <-p.Context.Done():
+				return
+			case <-changed:
+			}
+			select {
+			case <-p.Context.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return updates, nil
+}
+
+func send(ctx context.Context, ch chan<- any, v any) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case ch <- v:
+		return true
+	}
+}
+
+func parseWindow(args map[string]any) (time.Duration, error) {
+	s, _ := args["window"].(string)
+	if s == "" {
+		return 0, nil
+	}
+	window, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid window: %w", err)
+	}
+	if window <= 0 {
+		return 0, fmt.Errorf("invalid window: must be positive")
+	}
+	return window, nil
+}
+
+// responseTooLargeError reports fizzbuzz fields whose estimated size exceeds
+// the response budget, with the code of the REST problem
+type responseTooLargeError struct {
+	size, budget int
+	translator   i18n.Translator
+}
+
+func (e responseTooLargeError) Error() string {
+	return e.translator.T("response_too_large", strconv.Itoa(e.size), strconv.Itoa(e.budget))
+}
+
+func (e responseTooLargeError) Extensions() map[string]any {
+	return map[string]any{"code": "response-too-large", "size": e.size, "budget": e.budget}
+}
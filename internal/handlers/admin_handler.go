@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"fizzbuzz-server/internal/config"
+	"fizzbuzz-server/pkg/ulog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+)
+
+// LogLevelRequest changes the default level, or a single component's level
+// when Component is set. An empty Level removes the component override.
+type LogLevelRequest struct {
+	Level     string `json:"level"`
+	Component string `json:"component"`
+}
+
+// LogLevelResponse reports the default level and per-component overrides
+type LogLevelResponse struct {
+	Level      string            `json:"level"`
+	Components map[string]string `json:"components"`
+}
+
+// GetLogLevel returns the current log levels
+func GetLogLevel(c *fiber.Ctx) error {
+	return c.JSON(currentLogLevels())
+}
+
+// SetLogLevel changes log levels at runtime
+func SetLogLevel(c *fiber.Ctx) error {
+	req := LogLevelRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		return problem(c, fiber.StatusBadRequest, "invalid_request_body")
+	}
+
+	if req.Level == "" {
+		if req.Component == "" {
+			return problem(c, fiber.StatusBadRequest, "level_required")
+		}
+		ulog.ClearComponentLevel(req.Component)
+		return c.JSON(currentLogLevels())
+	}
+
+	level, err := zerolog.ParseLevel(req.Level)
+	if err != nil || level == zerolog.NoLevel {
+		return problem(c, fiber.StatusBadRequest, "invalid_log_level", req.Level)
+	}
+
+	if req.Component == "" {
+		ulog.SetLevel(level)
+	} else {
+		ulog.SetComponentLevel(req.Component, level)
+	}
+	ulog.Infof("log level changed to %s for %q", level, req.Component)
+
+	return c.JSON(currentLogLevels())
+}
+
+func currentLogLevels() LogLevelResponse {
+	resp := LogLevelResponse{
+		Level:      ulog.Level().String(),
+		Components: map[string]string{},
+	}
+	for component, level := range ulog.ComponentLevels() {
+		resp.Components[component] = level.String()
+	}
+	return resp
+}
+
+// ConfigVersionResponse describes the active configuration
+type ConfigVersionResponse struct {
+	Version  uint64    `json:"version"`
+	LoadedAt time.Time `json:"loaded_at"`
+	File     string    `json:"file,omitempty"`
+}
+
+// GetConfig returns the effective configuration with secrets redacted
+func GetConfig(c *fiber.Ctx) error {
+	return c.JSON(config.Redacted(config.Get()))
+}
+
+// GetConfigVersion reports which configuration is active
+func GetConfigVersion(c *fiber.Ctx) error {
+	return c.JSON(currentConfigVersion())
+}
+
+// ReloadConfig reloads the configuration from its sources. An invalid
+// configuration is rejected with 422 and the active one is kept.
+func ReloadConfig(c *fiber.Ctx) error {
+	if _, err := config.Reload(); err != nil {
+		ulog.Errorf("configuration reload rejected: %v", err)
+		return problem(c, fiber.StatusUnprocessableEntity, "rejected_config", err.Error())
+	}
+	ulog.Infof("configuration reloaded, version %d", config.Version())
+	return c.JSON(currentConfigVersion())
+}
+
+func currentConfigVersion() ConfigVersionResponse {
+	return ConfigVersionResponse{
+		Version:  config.Version(),
+		LoadedAt: config.LoadedAt(),
+		File:     config.File(),
+	}
+}
@@ -0,0 +1,133 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fizzbuzz-server/internal/apps"
+	"fizzbuzz-server/internal/config"
+	"fizzbuzz-server/internal/handlers"
+	"fizzbuzz-server/pkg/ulog"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetLogLevel_Default(t *testing.T) {
+	defer ulog.SetLevel(ulog.Level())
+
+	req := asAdmin(httptest.NewRequest(http.MethodPut, "/admin/log-level", strings.NewReader(`{"level":"warn"}`)))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := apps.App().FiberApp.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	var response handlers.LogLevelResponse
+	err = json.Unmarshal(body, &response)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "warn", response.Level)
+	assert.Equal(t, zerolog.WarnLevel, ulog.Level())
+}
+
+func TestSetLogLevel_Component(t *testing.T) {
+	defer ulog.ClearComponentLevel("http")
+
+	req := asAdmin(httptest.NewRequest(http.MethodPut, "/admin/log-level", strings.NewReader(`{"level":"trace","component":"http"}`)))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := apps.App().FiberApp.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Equal(t, zerolog.TraceLevel, ulog.ComponentLevels()["http"])
+	assert.Equal(t, zerolog.TraceLevel, zerolog.GlobalLevel())
+}
+
+func TestSetLogLevel_InvalidLevel(t *testing.T) {
+	req := asAdmin(httptest.NewRequest(http.MethodPut, "/admin/log-level", strings.NewReader(`{"level":"loud"}`)))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := apps.App().FiberApp.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestGetConfig_Redacted(t *testing.T) {
+	t.Cleanup(func() { _, _ = config.Load() })
+	t.Setenv("API_KEYS", "s3cret")
+	_, err := config.Load()
+	assert.NoError(t, err)
+
+	req := asAdmin(httptest.NewRequest(http.MethodGet, "/admin/config", nil))
+	resp, err := apps.App().FiberApp.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(body), "s3cret")
+}
+
+func TestAdminAuth(t *testing.T) {
+	status := func(app *fiber.App, path string, authorized bool) (int, string) {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		if authorized {
+			asAdmin(req)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	code, _ := status(apps.App().FiberApp, "/admin/stats/reset", false)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = status(apps.App().FiberApp, "/admin/stats/reset", true)
+	assert.Equal(t, http.StatusNoContent, code)
+
+	// without a token, the admin endpoints are only served by the admin
+	// listener
+	setConfigEnv(t, map[string]string{"ADMIN_TOKEN": ""})
+	code, body := status(apps.App().FiberApp, "/admin/stats/reset", true)
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Contains(t, body, "The admin endpoints are not served on the public port without an admin token")
+	code, _ = status(handlers.NewAdminApp(), "/admin/stats/reset", false)
+	assert.Equal(t, http.StatusNoContent, code)
+}
+
+func TestAdminListener(t *testing.T) {
+	setConfigEnv(t, map[string]string{"ADMIN_ADDRESS": "127.0.0.1:9090"})
+	public, admin := handlers.NewFiberApp(), handlers.NewAdminApp()
+
+	status := func(app *fiber.App, path string, authorized bool) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if authorized {
+			asAdmin(req)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	for _, path := range []string{"/metrics", "/admin/config", "/debug/pprof/"} {
+		assert.Equal(t, http.StatusNotFound, status(public, path, true), path)
+		assert.Equal(t, http.StatusOK, status(admin, path, true), path)
+	}
+	assert.Equal(t, http.StatusOK, status(public, "/health", false))
+	assert.Equal(t, http.StatusOK, status(admin, "/health", false))
+	assert.Equal(t, http.StatusOK, status(admin, "/metrics", false))
+	assert.Equal(t, http.StatusUnauthorized, status(admin, "/admin/config", false))
+	assert.Equal(t, http.StatusUnauthorized, status(admin, "/debug/pprof/", false))
+	assert.Equal(t, http.StatusNotFound, status(admin, "/fizzbuzz?int1=3&int2=5&limit=15", false))
+}
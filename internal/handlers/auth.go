@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"fizzbuzz-server/internal/config"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// APIKeyMiddleware requires a valid X-API-Key header when API keys are
+// configured, unless the client presented a verified TLS certificate or the
+// key of its tenant, checked by TenantMiddleware.
+// Keys are read on every request so reloads apply immediately.
+func APIKeyMiddleware(c *fiber.Ctx) error {
+	cfg := config.Get()
+	keys := cfg.Auth.APIKeys
+	if len(keys) == 0 || ClientIdentity(c) != "" {
+		return c.Next()
+	}
+	if tenant := cfg.Tenancy.Tenant(Tenant(c)); tenant != nil && len(tenant.APIKeys) > 0 {
+		return c.Next()
+	}
+
+	provided := c.Get("X-API-Key")
+	for _, key := range keys {
+		if secureCompare(provided, key) {
+			return c.Next()
+		}
+	}
+	return problem(c, fiber.StatusUnauthorized, "invalid_api_key")
+}
+
+// AdminAuthMiddleware requires "Authorization: Bearer <token>" on admin
+// routes. Without an admin token they are served on the admin listener
+// only: on the public listener, where public is set, they answer 404.
+// The token is read on every request so reloads apply immediately.
+func AdminAuthMiddleware(public bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token := config.Get().Admin.Token
+		if token == "" {
+			if public {
+				return problem(c, fiber.StatusNotFound, "admin_disabled")
+			}
+			return c.Next()
+		}
+
+		provided, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+		if ok && secureCompare(provided, token) {
+			return c.Next()
+		}
+		return problem(c, fiber.StatusUnauthorized, "invalid_admin_token")
+	}
+}
+
+func secureCompare(provided, expected string) bool {
+	return provided != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(expected)) == 1
+}
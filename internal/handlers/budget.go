@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"fizzbuzz-server/internal/config"
+	"fizzbuzz-server/internal/entities"
+	"fizzbuzz-server/internal/i18n"
+	"fizzbuzz-server/internal/metrics"
+	"fizzbuzz-server/internal/services"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// responseTooLarge is a request whose estimated response exceeds
+// fizzbuzz.max_response_bytes
+type responseTooLarge struct {
+	size   int // estimated response size in bytes
+	budget int
+}
+
+// checkResponseSize estimates the size of the responses to reqs in format
+// before they are generated. When it exceeds the budget, the rejection is
+// counted for endpoint and returned.
+func checkResponseSize(endpoint, format string, reqs ...entities.FizzBuzzRequest) *responseTooLarge {
+	budget := config.Get().FizzBuzz.MaxResponseBytes
+	if budget == 0 {
+		return nil
+	}
+	size := 0
+	for _, req := range reqs {
+		size += services.ResponseSize(format, req.Int1, req.Int2, 1, req.Limit, req.Str1, req.Str2)
+	}
+	if size <= budget {
+		return nil
+	}
+	metrics.ResponseBudgetRejections.WithLabelValues(endpoint).Inc()
+	return &responseTooLarge{size: size, budget: budget}
+}
+
+// Message describes the rejection in the language of t
+func (e *responseTooLarge) Message(t i18n.Translator) string {
+	return t.T("response_too_large", strconv.Itoa(e.size), strconv.Itoa(e.budget))
+}
+
+// responseTooLargeProblem answers with the problem of a request over budget
+func responseTooLargeProblem(c *fiber.Ctx, e *responseTooLarge) error {
+	t := translator(c)
+	return sendProblem(c, t, ErrorResponse{
+		Type:   ProblemResponseTooLarge,
+		Title:  statusTitle(t, fiber.StatusUnprocessableEntity),
+		Status: fiber.StatusUnprocessableEntity,
+		Detail: e.Message(t),
+	})
+}
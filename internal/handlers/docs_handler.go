@@ -7,14 +7,62 @@ func DocHandler(c *fiber.Ctx) error {
 		"fizzbuzz_endpoint": fiber.Map{
 			"path":        "/fizzbuzz",
 			"method":      "GET",
-			"params":      "int1(int), int2(int), limit(int), str1(string), str2(string)",
+			"params":      "int1(int), int2(int), limit(int), str1(string), str2(string), format(json|ndjson|csv|text)",
 			"description": "Returns a FizzBuzz sequence based on parameters",
 		},
+		"fizzbuzz_batch_endpoint": fiber.Map{
+			"path":        "/fizzbuzz/batch",
+			"method":      "POST",
+			"params":      "JSON array of {int1, int2, limit, str1, str2}",
+			"description": "Returns one result or error per parameter set",
+		},
 		"stats_endpoint": fiber.Map{
 			"path":        "/stats",
 			"method":      "GET",
-			"params":      "none",
+			"params":      "window(duration, optional)",
 			"description": "Returns statistics about most frequent request",
 		},
+		"stats_top_endpoint": fiber.Map{
+			"path":        "/stats/top",
+			"method":      "GET",
+			"params":      "n(int, default 10), window(duration, optional)",
+			"description": "Returns the n most frequent requests",
+		},
+		"websocket_endpoint": fiber.Map{
+			"path":        "/ws",
+			"method":      "GET (WebSocket upgrade)",
+			"params":      "JSON messages: generate{id, params}, ack{id}, cancel{id}, subscribe{topic: stats}, unsubscribe{topic: stats}",
+			"description": "Streams FizzBuzz sequences in acknowledged chunks and pushes live stats updates",
+		},
+		"stats_events_endpoint": fiber.Map{
+			"path":        "/stats/events",
+			"method":      "GET (text/event-stream)",
+			"params":      "Last-Event-ID header (optional)",
+			"description": "Streams the most frequent requests as Server-Sent Events when they change",
+		},
+		"graphql_endpoint": fiber.Map{
+			"path":        "/graphql",
+			"method":      "GET, POST, or WebSocket (graphql-transport-ws) for subscriptions",
+			"params":      "query, variables, operationName",
+			"description": "GraphQL API: fizzbuzz(int1, int2, limit, str1, str2, offset, count), stats(window) { window mostFrequent top(n) }, subscription stats(window)",
+		},
+		"rpc_endpoint": fiber.Map{
+			"path":        "/rpc",
+			"method":      "POST",
+			"params":      "JSON-RPC 2.0 call or batch: fizzbuzz.generate{int1, int2, limit, str1, str2}, stats.mostFrequent{window}, stats.top{n, window}",
+			"description": "JSON-RPC 2.0 access to the FizzBuzz and stats methods",
+		},
+		"tenant_endpoints": fiber.Map{
+			"path":        "/tenants/{tenant}/...",
+			"method":      "as the endpoints above",
+			"params":      "as the endpoints above; X-Tenant header or a tenant API key select the tenant at the root paths",
+			"description": "The FizzBuzz, stats, WebSocket, GraphQL and JSON-RPC endpoints in the namespace of a tenant: its own stats, limit cap and default words",
+		},
+		"health_endpoint": fiber.Map{
+			"path":        "/health",
+			"method":      "GET",
+			"params":      "none",
+			"description": "Returns the server status",
+		},
 	})
 }
@@ -1,71 +1,132 @@
 package handlers
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fizzbuzz-server/internal/apps"
+	"fizzbuzz-server/internal/binding"
+	"fizzbuzz-server/internal/config"
 	"fizzbuzz-server/internal/entities"
+	"fizzbuzz-server/internal/output"
+	"fizzbuzz-server/pkg/ulog"
+	"slices"
 	"strconv"
-	"strings"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 )
 
+// contentTypes maps the streamed output formats to their content type
+var contentTypes = map[string]string{
+	output.FormatNDJSON: "application/x-ndjson",
+	output.FormatCSV:    "text/csv; charset=utf-8",
+	output.FormatText:   fiber.MIMETextPlainCharsetUTF8,
+}
+
 func FizzbuzzHandler(c *fiber.Ctx) error {
 
+	format := c.Query("format", output.FormatJSON)
+	if !output.Valid(format) {
+		return problem(c, fiber.StatusBadRequest, "invalid_format", format)
+	}
+
+	// Bind, default and validate query parameters
 	req := entities.FizzBuzzRequest{}
+	tenant := Tenant(c)
+	if err := requestBinder(c).Bind(tenantContext(c.UserContext(), tenant), c, &req); err != nil {
+		return bindingProblem(c, err)
+	}
+	if tooLarge := checkResponseSize("fizzbuzz", format, req); tooLarge != nil {
+		return responseTooLargeProblem(c, tooLarge)
+	}
+
+	// Generate result, stopped once the deadline of the request is exceeded
+	// or its client disconnects
+	result, err := generateFizzBuzzWithContext(c.UserContext(), req)
+	if err != nil {
+		return cancelledProblem(c, err)
+	}
+
+	// Update stats
+	updateStats(tenant, ClientIdentity(c), req)
 
-	// Bind query parameters
-	if err := c.QueryParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
-			Error: "Invalid parameter format",
+	if format == output.FormatJSON {
+		return c.JSON(entities.FizzBuzzResponse{
+			Result: result,
 		})
 	}
 
-	// Set default values if empty
-	if req.Str1 == "" {
-		req.Str1 = "fizz"
+	// Other formats are streamed as they are encoded
+	c.Set(fiber.HeaderContentType, contentTypes[format])
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := output.Write(w, format, slices.Values(result)); err != nil {
+			ulog.Errorf("streaming fizzbuzz result: %v", err)
+		}
+	})
+	return nil
+}
+
+// FizzbuzzBatchHandler generates several sequences from a JSON array of
+// parameter sets. Each set is validated and counted on its own, the
+// problems of the rejected ones are reported in place without failing the
+// whole batch.
+func FizzbuzzBatchHandler(c *fiber.Ctx) error {
+	var reqs []entities.FizzBuzzRequest
+	if err := c.BodyParser(&reqs); err != nil {
+		return problem(c, fiber.StatusBadRequest, "invalid_request_body")
 	}
-	if req.Str2 == "" {
-		req.Str2 = "buzz"
+	if maxSize := config.Get().FizzBuzz.MaxBatchSize; len(reqs) == 0 || len(reqs) > maxSize {
+		return problem(c, fiber.StatusBadRequest, "batch_size", strconv.Itoa(maxSize))
 	}
 
-	// Validate
-	validate := c.Locals("validator").(*validator.Validate)
-	if err := validate.Struct(req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
-			Error: err.Error(),
-		})
+	binder := requestBinder(c)
+	tenant := Tenant(c)
+	ctx := tenantContext(c.UserContext(), tenant)
+	results := make([]entities.BatchResult, len(reqs))
+	valid := make([]entities.FizzBuzzRequest, 0, len(reqs))
+	for i := range reqs {
+		if err := binder.Complete(ctx, &reqs[i]); err != nil {
+			var bindErr *binding.Error
+			if !errors.As(err, &bindErr) {
+				return problem(c, fiber.StatusInternalServerError, "internal_error")
+			}
+			p := bindingErrorProblem(translator(c), bindErr)
+			results[i].Error = &p
+			continue
+		}
+		valid = append(valid, reqs[i])
+	}
+	if tooLarge := checkResponseSize("batch", output.FormatJSON, valid...); tooLarge != nil {
+		return responseTooLargeProblem(c, tooLarge)
 	}
 
-	// Generate result with context for potential tracing in the service layer
-	result := generateFizzBuzzWithContext(c.Context(), req)
-
-	// Update stats
-	updateStats(req)
+	for i, req := range reqs {
+		if results[i].Error != nil {
+			continue
+		}
+		result, err := generateFizzBuzzWithContext(c.UserContext(), req)
+		if err != nil {
+			return cancelledProblem(c, err)
+		}
+		results[i].Result = result
+		updateStats(tenant, ClientIdentity(c), req)
+	}
 
-	return c.JSON(fiber.Map{
-		"result": result,
-	})
+	return c.JSON(results)
 }
 
-// generateFizzBuzzWithContext calls the FizzBuzz service with context for tracing
-func generateFizzBuzzWithContext(ctx context.Context, req entities.FizzBuzzRequest) []string {
-	result := apps.App().FizzBuzzService.GenerateFizzBuzz(req.Int1, req.Int2, req.Limit, req.Str1, req.Str2)
-	return result
+// generateFizzBuzzWithContext calls the FizzBuzz service, which stops with
+// the error of ctx once it is done
+func generateFizzBuzzWithContext(ctx context.Context, req entities.FizzBuzzRequest) ([]string, error) {
+	return apps.App().FizzBuzzService.GenerateFizzBuzzContext(ctx, req.Int1, req.Int2, req.Limit, req.Str1, req.Str2)
 }
 
-// updateStats updates the request statistics
-func updateStats(req entities.FizzBuzzRequest) {
-	stats.Mutex.Lock()
-	defer stats.Mutex.Unlock()
-
-	key := strings.Join([]string{
-		strconv.Itoa(req.Int1),
-		strconv.Itoa(req.Int2),
-		strconv.Itoa(req.Limit),
-		req.Str1,
-		req.Str2,
-	}, ",")
-	stats.Counts[key]++
+// updateStats updates the request statistics of the tenant, attributing the
+// request to the client when it is identified
+func updateStats(tenant, client string, req entities.FizzBuzzRequest) {
+	stats := tenantStats(tenant)
+	stats.Record(req)
+	if client != "" {
+		stats.RecordClient(client)
+	}
 }
@@ -3,12 +3,18 @@ package handlers_test
 import (
 	"encoding/json"
 	"fizzbuzz-server/internal/apps"
+	"fizzbuzz-server/internal/entities"
+	"fizzbuzz-server/internal/handlers"
+	"fizzbuzz-server/internal/metrics"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestFizzbuzzHandler_ValidRequest(t *testing.T) {
@@ -66,14 +72,18 @@ func TestFizzbuzzHandler_MissingParameters(t *testing.T) {
 	body, err := io.ReadAll(resp.Body)
 	assert.NoError(t, err)
 
-	var response map[string]string
+	assert.Equal(t, handlers.MIMEApplicationProblemJSON, resp.Header.Get("Content-Type"))
+	var response handlers.ErrorResponse
 	err = json.Unmarshal(body, &response)
 	assert.NoError(t, err)
 
-	// Verify the error message
-	errorMsg, exists := response["error"]
-	assert.True(t, exists)
-	assert.Contains(t, errorMsg, "validation")
+	// Verify the problem lists the rejected parameters
+	assert.Equal(t, handlers.ProblemValidation, response.Type)
+	assert.Equal(t, http.StatusBadRequest, response.Status)
+	assert.Equal(t, []handlers.ProblemFieldError{
+		{Field: "int2", Code: "required", Message: "int2 is required"},
+		{Field: "limit", Code: "required", Message: "limit is required"},
+	}, response.Errors)
 }
 
 func TestFizzbuzzHandler_InvalidParameters(t *testing.T) {
@@ -90,14 +100,17 @@ func TestFizzbuzzHandler_InvalidParameters(t *testing.T) {
 	body, err := io.ReadAll(resp.Body)
 	assert.NoError(t, err)
 
-	var response map[string]string
+	assert.Equal(t, handlers.MIMEApplicationProblemJSON, resp.Header.Get("Content-Type"))
+	var response handlers.ErrorResponse
 	err = json.Unmarshal(body, &response)
 	assert.NoError(t, err)
 
-	// Verify the error message
-	errorMsg, exists := response["error"]
-	assert.True(t, exists)
-	assert.Contains(t, errorMsg, "validation")
+	// Verify the problem lists the rejected parameters
+	assert.Equal(t, handlers.ProblemValidation, response.Type)
+	assert.Equal(t, http.StatusBadRequest, response.Status)
+	assert.Equal(t, []handlers.ProblemFieldError{
+		{Field: "int1", Code: "required", Message: "int1 is required"},
+	}, response.Errors)
 }
 
 func TestFizzbuzzHandler_DefaultValues(t *testing.T) {
@@ -169,14 +182,17 @@ func TestFizzbuzzHandler_ExceedMaxLimit(t *testing.T) {
 	body, err := io.ReadAll(resp.Body)
 	assert.NoError(t, err)
 
-	var response map[string]string
+	assert.Equal(t, handlers.MIMEApplicationProblemJSON, resp.Header.Get("Content-Type"))
+	var response handlers.ErrorResponse
 	err = json.Unmarshal(body, &response)
 	assert.NoError(t, err)
 
-	// Verify the error message
-	errorMsg, exists := response["error"]
-	assert.True(t, exists)
-	assert.Contains(t, errorMsg, "validation")
+	// Verify the problem lists the rejected parameters
+	assert.Equal(t, handlers.ProblemValidation, response.Type)
+	assert.Equal(t, http.StatusBadRequest, response.Status)
+	assert.Equal(t, []handlers.ProblemFieldError{
+		{Field: "limit", Code: "maxlimit", Message: "limit exceeds the maximum limit"},
+	}, response.Errors)
 }
 
 func TestFizzbuzzHandler_CustomStrings(t *testing.T) {
@@ -219,3 +235,141 @@ func TestFizzbuzzHandler_CustomStrings(t *testing.T) {
 	assert.Equal(t, "14", result[13])
 	assert.Equal(t, "helloworld", result[14])
 }
+
+func TestFizzbuzzHandler_NDJSONFormat(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/fizzbuzz?int1=3&int2=5&limit=5&format=ndjson", nil)
+
+	resp, err := apps.App().FiberApp.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "\"1\"\n\"2\"\n\"fizz\"\n\"4\"\n\"buzz\"\n", string(body))
+}
+
+func TestFizzbuzzHandler_InvalidFormat(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/fizzbuzz?int1=3&int2=5&limit=5&format=xml", nil)
+
+	resp, err := apps.App().FiberApp.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestFizzbuzzBatchHandler(t *testing.T) {
+	body := `[{"int1":3,"int2":5,"limit":3},{"int1":0,"int2":5,"limit":3}]`
+	req := httptest.NewRequest(http.MethodPost, "/fizzbuzz/batch", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := apps.App().FiberApp.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var results []entities.BatchResult
+	err = json.NewDecoder(resp.Body).Decode(&results)
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, []string{"1", "2", "fizz"}, results[0].Result)
+	assert.Nil(t, results[0].Error)
+	assert.Nil(t, results[1].Result)
+	assert.Equal(t, &handlers.ErrorResponse{
+		Type:   handlers.ProblemValidation,
+		Title:  "Invalid parameters",
+		Status: http.StatusBadRequest,
+		Detail: "int1 is required",
+		Errors: []handlers.ProblemFieldError{{Field: "int1", Code: "required", Message: "int1 is required"}},
+	}, results[1].Error)
+
+	// the problems are translated like the other errors
+	req = httptest.NewRequest(http.MethodPost, "/fizzbuzz/batch", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", "fr")
+	resp, err = apps.App().FiberApp.Test(req)
+	require.NoError(t, err)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&results))
+	require.NotNil(t, results[1].Error)
+	assert.Equal(t, "Paramètres invalides", results[1].Error.Title)
+	assert.Equal(t, "int1 est obligatoire", results[1].Error.Errors[0].Message)
+}
+
+func TestFizzbuzzBatchHandler_Empty(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/fizzbuzz/batch", strings.NewReader(`[]`))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := apps.App().FiberApp.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestFizzbuzzHandler_WordBounds(t *testing.T) {
+	setConfigEnv(t, map[string]string{"FIZZBUZZ_MAX_WORD_LENGTH": "5", "FIZZBUZZ_WORD_CHARACTERS": "letters"})
+
+	problem := problemRequest(t, "/fizzbuzz?int1=3&int2=5&limit=15&str1=fizzes&str2=b%C3%BCzz")
+	assert.Equal(t, []handlers.ProblemFieldError{
+		{Field: "str1", Code: "wordlen", Message: "str1 exceeds the maximum word length"},
+	}, problem.Errors)
+
+	problem = problemRequest(t, "/fizzbuzz?int1=3&int2=5&limit=15&str2=buzz!")
+	assert.Equal(t, []handlers.ProblemFieldError{
+		{Field: "str2", Code: "wordchars", Message: "str2 contains characters that are not allowed"},
+	}, problem.Errors)
+}
+
+func TestFizzbuzzHandler_WordWithComma(t *testing.T) {
+	// punctuation is allowed by default, commas included
+	apps.App().StatsService.Reset()
+
+	resp, err := apps.App().FiberApp.Test(httptest.NewRequest(http.MethodGet, "/fizzbuzz?int1=3&int2=5&limit=15&str1=a%2Cb&str2=c", nil))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var result entities.FizzBuzzResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, "a,b", result.Result[2])
+	assert.Equal(t, "a,bc", result.Result[14])
+
+	resp, err = apps.App().FiberApp.Test(httptest.NewRequest(http.MethodGet, "/stats", nil))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var stats handlers.StatsResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&stats))
+	assert.Equal(t, entities.StatsEntry{Int1: 3, Int2: 5, Limit: 15, Str1: "a,b", Str2: "c", Hits: 1}, stats.MostFrequentRequest)
+
+	resp, err = apps.App().FiberApp.Test(httptest.NewRequest(http.MethodGet, "/stats/top", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestFizzbuzzHandler_ResponseBudget(t *testing.T) {
+	setConfigEnv(t, map[string]string{"FIZZBUZZ_MAX_RESPONSE_BYTES": "1000"})
+	rejections := testutil.ToFloat64(metrics.ResponseBudgetRejections.WithLabelValues("fizzbuzz"))
+
+	resp, err := apps.App().FiberApp.Test(httptest.NewRequest(http.MethodGet, "/fizzbuzz?int1=3&int2=5&limit=100", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	problem := problemRequest(t, "/fizzbuzz?int1=3&int2=5&limit=100&str1=fizzfizzfizzfizz")
+	assert.Equal(t, handlers.ErrorResponse{
+		Type:   handlers.ProblemResponseTooLarge,
+		Title:  "Unprocessable Entity",
+		Status: http.StatusUnprocessableEntity,
+		Detail: "The estimated response size of 1081 bytes exceeds the budget of 1000 bytes",
+	}, problem)
+	assert.Equal(t, rejections+1, testutil.ToFloat64(metrics.ResponseBudgetRejections.WithLabelValues("fizzbuzz")))
+
+	// the size is estimated in the requested format, csv rows being larger
+	// than the JSON values
+	resp, err = apps.App().FiberApp.Test(httptest.NewRequest(http.MethodGet, "/fizzbuzz?int1=3&int2=5&limit=120", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	problem = problemRequest(t, "/fizzbuzz?int1=3&int2=5&limit=120&format=csv")
+	assert.Equal(t, "The estimated response size of 1056 bytes exceeds the budget of 1000 bytes", problem.Detail)
+
+	// the sets of a batch share the budget
+	body := `[{"int1":3,"int2":5,"limit":90},{"int1":3,"int2":5,"limit":90}]`
+	req := httptest.NewRequest(http.MethodPost, "/fizzbuzz/batch", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err = apps.App().FiberApp.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+}
@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fizzbuzz-server/internal/apps"
+	"fizzbuzz-server/internal/config"
+	"fizzbuzz-server/internal/graph"
+	"sync"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/graphql-go/graphql"
+)
+
+// graphqlWSProtocol is the WebSocket subprotocol of GraphQL subscriptions
+// https://github.com/enisdenjo/graphql-ws/blob/master/PROTOCOL.md
+const graphqlWSProtocol = "graphql-transport-ws"
+
+// graphqlInitTimeout bounds the wait for connection_init
+const graphqlInitTimeout = 10 * time.Second
+
+// Close codes of the graphql-transport-ws protocol
+const (
+	graphqlCloseBadRequest   = 4400
+	graphqlCloseUnauthorized = 4401
+	graphqlCloseInitTimeout  = 4408
+	graphqlCloseDuplicateID  = 4409
+	graphqlCloseTooManyInits = 4429
+)
+
+// graphqlWSMessage is a message of the graphql-transport-ws protocol
+type graphqlWSMessage struct {
+	Type    string          `json:"type"`
+	ID      string          `json:"id,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// GraphQLHandler serves GraphQL queries on GET and POST, and subscriptions
+// over WebSocket with the graphql-transport-ws protocol
+func GraphQLHandler() fiber.Handler {
+	executor, err := graph.New(graph.Resolver{
+		FizzBuzz: apps.App().FizzBuzzService,
+		Stats:    apps.App().StatsService,
+		Binder:   NewBinder(),
+		Limits: func() graph.Limits {
+			cfg := config.Get()
+			return graph.Limits{
+				MaxDepth:             cfg.GraphQL.MaxDepth,
+				MaxCost:              cfg.FizzBuzz.MaxLimit,
+				MaxResponseBytes:     cfg.FizzBuzz.MaxResponseBytes,
+				SubscriptionInterval: cfg.GraphQL.SubscriptionInterval,
+			}
+		},
+	})
+	if err != nil {
+		// the schema is static, failing to build it is a programming error
+		panic(err)
+	}
+
+	subscriptions := websocket.New(func(conn *websocket.Conn) {
+		serveGraphQLWS(conn, executor)
+	}, websocket.Config{Subprotocols: []string{graphqlWSProtocol}})
+
+	return func(c *fiber.Ctx) error {
+		if websocket.IsWebSocketUpgrade(c) {
+			return subscriptions(c)
+		}
+
+		req := graph.Request{}
+		if c.Method() == fiber.MethodPost {
+			if err := c.BodyParser(&req); err != nil {
+				return problem(c, fiber.StatusBadRequest, "invalid_request_body")
+			}
+		} else {
+			req.Query = c.Query("query")
+			req.OperationName = c.Query("operationName")
+			if variables := c.Query("variables"); variables != "" {
+				if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+					return problem(c, fiber.StatusBadRequest, "invalid_variables", err.Error())
+				}
+			}
+		}
+		if req.Query == "" {
+			return problem(c, fiber.StatusBadRequest, "missing_query")
+		}
+
+		result := executor.Execute(graphContext(c.UserContext(), ClientIdentity(c), Tenant(c)), req)
+		if err := c.UserContext().Err(); err != nil {
+			return cancelledProblem(c, err)
+		}
+		return c.JSON(result)
+	}
+}
+
+// graphContext returns ctx running GraphQL requests for client in tenant
+func graphContext(ctx context.Context, client, tenant string) context.Context {
+	ctx = graph.WithClient(ctx, client)
+	if tenant == "" {
+		return ctx
+	}
+	return graph.WithTenant(tenantContext(ctx, tenant), tenant)
+}
+
+// serveGraphQLWS runs a graphql-transport-ws connection: after the
+// connection_init/connection_ack handshake, every subscribe message starts
+// an operation whose results are sent as next messages until complete
+func serveGraphQLWS(conn *websocket.Conn, executor *graph.Executor) {
+	conn.SetReadLimit(wsMaxMessageSize)
+
+	ctx, cancel := context.WithCancel(graphContext(wsContext(conn), wsClient(conn), wsTenant(conn)))
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	var writeMu sync.Mutex
+	send := func(msg graphqlWSMessage) {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		_ = conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		_ = conn.WriteJSON(msg)
+	}
+	closeWith := func(code int, reason string) {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(wsWriteTimeout))
+	}
+
+	var mu sync.Mutex
+	operations := map[string]context.CancelFunc{}
+	acknowledged := false
+
+	_ = conn.SetReadDeadline(time.Now().Add(graphqlInitTimeout))
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if !acknowledged {
+				closeWith(graphqlCloseInitTimeout, "Connection initialisation timeout")
+			}
+			return
+		}
+		var msg graphqlWSMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			closeWith(graphqlCloseBadRequest, "Invalid message")
+			return
+		}
+
+		switch msg.Type {
+		case "connection_init":
+			if acknowledged {
+				closeWith(graphqlCloseTooManyInits, "Too many initialisation requests")
+				return
+			}
+			acknowledged = true
+			_ = conn.SetReadDeadline(time.Time{})
+			send(graphqlWSMessage{Type: "connection_ack"})
+		case "ping":
+			send(graphqlWSMessage{Type: "pong"})
+		case "pong":
+		case "subscribe":
+			if !acknowledged {
+				closeWith(graphqlCloseUnauthorized, "Unauthorized")
+				return
+			}
+			req := graph.Request{}
+			if msg.ID == "" || json.Unmarshal(msg.Payload, &req) != nil {
+				closeWith(graphqlCloseBadRequest, "Invalid subscribe message")
+				return
+			}
+
+			mu.Lock()
+			if _, ok := operations[msg.ID]; ok {
+				mu.Unlock()
+				closeWith(graphqlCloseDuplicateID, "Subscriber for "+msg.ID+" already exists")
+				return
+			}
+			opCtx, opCancel := context.WithCancel(ctx)
+			operations[msg.ID] = opCancel
+			mu.Unlock()
+
+			wg.Add(1)
+			go func(id string) {
+				defer wg.Done()
+				defer opCancel()
+
+				// results are drained even once cancelled, as the executor
+				// only stops after its next send
+				failed := false
+				for result := range executor.Subscribe(opCtx, req) {
+					if opCtx.Err() == nil {
+						failed = graphqlResultType(result) == "error"
+						send(graphqlWSMessage{Type: graphqlResultType(result), ID: id, Payload: marshalResult(result)})
+					}
+				}
+
+				mu.Lock()
+				_, active := operations[id]
+				delete(operations, id)
+				mu.Unlock()
+				// an error message already terminates the operation
+				if active && !failed && ctx.Err() == nil {
+					send(graphqlWSMessage{Type: "complete", ID: id})
+				}
+			}(msg.ID)
+		case "complete":
+			mu.Lock()
+			if opCancel, ok := operations[msg.ID]; ok {
+				delete(operations, msg.ID)
+				opCancel()
+			}
+			mu.Unlock()
+		default:
+			closeWith(graphqlCloseBadRequest, "Unknown message type "+msg.Type)
+			return
+		}
+	}
+}
+
+// graphqlResultType is "error" for requests rejected before execution, which
+// have errors and no data, and "next" otherwise
+func graphqlResultType(result *graphql.Result) string {
+	if result.Data == nil && len(result.Errors) > 0 {
+		return "error"
+	}
+	return "next"
+}
+
+func marshalResult(result *graphql.Result) json.RawMessage {
+	if graphqlResultType(result) == "error" {
+		data, _ := json.Marshal(result.Errors)
+		return data
+	}
+	data, _ := json.Marshal(result)
+	return data
+}
@@ -0,0 +1,181 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fizzbuzz-server/internal/apps"
+	"fizzbuzz-server/internal/entities"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type graphqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func postGraphQL(t *testing.T, query string, variables map[string]any) graphqlResponse {
+	body, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := apps.App().FiberApp.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var response graphqlResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	return response
+}
+
+func TestGraphQL_FizzBuzzAndStats(t *testing.T) {
+	apps.App().StatsService.Reset()
+
+	response := postGraphQL(t, `query ($limit: Int!) {
+		fizzbuzz(int1: 3, int2: 5, limit: $limit, offset: 9, count: 6)
+	}`, map[string]any{"limit": 15})
+	require.Empty(t, response.Errors)
+	assert.JSONEq(t, `{"fizzbuzz":["buzz","11","fizz","13","14","fizzbuzz"]}`, string(response.Data))
+
+	// the fields of a query are not resolved in order, the stats are read
+	// by a second one
+	response = postGraphQL(t, `{ stats { window mostFrequent { limit hits } top(n: 5) { str1 } } }`, nil)
+	require.Empty(t, response.Errors)
+
+	var data struct {
+		Stats struct {
+			Window       *string               `json:"window"`
+			MostFrequent *entities.StatsEntry  `json:"mostFrequent"`
+			Top          []entities.StatsEntry `json:"top"`
+		} `json:"stats"`
+	}
+	require.NoError(t, json.Unmarshal(response.Data, &data))
+	assert.Nil(t, data.Stats.Window)
+	require.NotNil(t, data.Stats.MostFrequent)
+	assert.Equal(t, 15, data.Stats.MostFrequent.Limit)
+	assert.Equal(t, 1, data.Stats.MostFrequent.Hits)
+	assert.Equal(t, []entities.StatsEntry{{Str1: "fizz"}}, data.Stats.Top)
+}
+
+func TestGraphQL_GetQuery(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/graphql?query={fizzbuzz(int1:2,int2:7,limit:3)}", nil)
+
+	resp, err := apps.App().FiberApp.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var response graphqlResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	assert.JSONEq(t, `{"fizzbuzz":["1","fizz","3"]}`, string(response.Data))
+}
+
+func TestGraphQL_InvalidParameters(t *testing.T) {
+	response := postGraphQL(t, `{ fizzbuzz(int1: 0, int2: 5, limit: 15) }`, nil)
+	require.Len(t, response.Errors, 1)
+	assert.Equal(t, "int1 is required", response.Errors[0].Message)
+}
+
+func TestGraphQL_CostLimit(t *testing.T) {
+	// each field stays within the max limit, together they exceed it
+	response := postGraphQL(t, `{
+		a: fizzbuzz(int1: 3, int2: 5, limit: 10000)
+		b: fizzbuzz(int1: 3, int2: 5, limit: 10000)
+	}`, nil)
+	require.NotEmpty(t, response.Errors)
+	assert.Contains(t, response.Errors[0].Message, "query cost exceeds the limit of 10000")
+}
+
+func TestGraphQL_ResponseBudget(t *testing.T) {
+	setConfigEnv(t, map[string]string{"FIZZBUZZ_MAX_RESPONSE_BYTES": "1000"})
+	response := postGraphQL(t, `{ fizzbuzz(int1: 3, int2: 5, limit: 90) }`, nil)
+	assert.Empty(t, response.Errors)
+
+	// only the values selected by offset and count are estimated, for all
+	// fields together
+	response = postGraphQL(t, `{
+		a: fizzbuzz(int1: 3, int2: 5, limit: 1000, count: 90)
+		b: fizzbuzz(int1: 3, int2: 5, limit: 90)
+	}`, nil)
+	require.Len(t, response.Errors, 1)
+	assert.Contains(t, response.Errors[0].Message, "exceeds the budget of 1000 bytes")
+}
+
+func TestGraphQL_DepthLimit(t *testing.T) {
+	response := postGraphQL(t, `{ stats { mostFrequent { ...entry } } } fragment entry on StatsEntry { hits }`, nil)
+	assert.Empty(t, response.Errors)
+
+	setConfigEnv(t, map[string]string{"GRAPHQL_MAX_DEPTH": "2"})
+	response = postGraphQL(t, `{ stats { mostFrequent { ...entry } } } fragment entry on StatsEntry { hits }`, nil)
+	require.Len(t, response.Errors, 1)
+	assert.Equal(t, "query depth 3 exceeds the limit of 2", response.Errors[0].Message)
+}
+
+func TestGraphQL_RejectsSubscriptionOverHTTP(t *testing.T) {
+	response := postGraphQL(t, `subscription { stats { window } }`, nil)
+	require.Len(t, response.Errors, 1)
+	assert.Contains(t, response.Errors[0].Message, "WebSocket")
+}
+
+func TestGraphQL_Subscription(t *testing.T) {
+	apps.App().StatsService.Reset()
+
+	dialer := websocket.Dialer{Subprotocols: []string{"graphql-transport-ws"}}
+	conn, resp, err := dialer.Dial("ws://"+startServer(t)+"/graphql", nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	assert.Equal(t, "graphql-transport-ws", resp.Header.Get("Sec-WebSocket-Protocol"))
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	type message struct {
+		Type    string          `json:"type"`
+		ID      string          `json:"id,omitempty"`
+		Payload json.RawMessage `json:"payload,omitempty"`
+	}
+	read := func() message {
+		var msg message
+		require.NoError(t, conn.ReadJSON(&msg))
+		return msg
+	}
+
+	require.NoError(t, conn.WriteJSON(message{Type: "connection_init"}))
+	assert.Equal(t, "connection_ack", read().Type)
+
+	require.NoError(t, conn.WriteJSON(message{
+		Type:    "subscribe",
+		ID:      "1",
+		Payload: json.RawMessage(`{"query":"subscription { stats { mostFrequent { limit hits } } }"}`),
+	}))
+	next := read()
+	assert.Equal(t, "next", next.Type)
+	assert.Equal(t, "1", next.ID)
+	assert.JSONEq(t, `{"data":{"stats":{"mostFrequent":null}}}`, string(next.Payload))
+
+	apps.App().StatsService.Record(entities.FizzBuzzRequest{Int1: 3, Int2: 5, Limit: 15, Str1: "fizz", Str2: "buzz"})
+	next = read()
+	assert.JSONEq(t, `{"data":{"stats":{"mostFrequent":{"limit":15,"hits":1}}}}`, string(next.Payload))
+
+	require.NoError(t, conn.WriteJSON(message{Type: "complete", ID: "1"}))
+
+	// queries are answered once and completed
+	require.NoError(t, conn.WriteJSON(message{
+		Type:    "subscribe",
+		ID:      "2",
+		Payload: json.RawMessage(`{"query":"{ fizzbuzz(int1: 3, int2: 5, limit: 3) }"}`),
+	}))
+	next = read()
+	assert.Equal(t, "2", next.ID)
+	assert.JSONEq(t, `{"data":{"fizzbuzz":["1","2","fizz"]}}`, string(next.Payload))
+	assert.Equal(t, message{Type: "complete", ID: "2"}, read())
+
+	require.NoError(t, conn.WriteJSON(message{Type: "ping"}))
+	assert.Equal(t, "pong", read().Type)
+}
@@ -2,23 +2,20 @@ package handlers
 
 import "fizzbuzz-server/internal/entities"
 
-var stats = entities.RequestStats{
-	Counts: make(map[string]int),
-}
-
 // StatsResponse represents the stats endpoint response
-type StatsResponse struct {
-	MostFrequentRequest struct {
-		Int1  int    `json:"int1"`
-		Int2  int    `json:"int2"`
-		Limit int    `json:"limit"`
-		Str1  string `json:"str1"`
-		Str2  string `json:"str2"`
-		Hits  int    `json:"hits"`
-	} `json:"most_frequent_request"`
-}
+type StatsResponse = entities.StatsResponse
+
+// TopStatsResponse represents the stats top endpoint response
+type TopStatsResponse = entities.TopStatsResponse
+
+// ClientStatsResponse represents the client stats endpoint response
+type ClientStatsResponse = entities.ClientStatsResponse
+
+// TenantStatsResponse represents the tenant stats endpoint response
+type TenantStatsResponse = entities.TenantStatsResponse
+
+// ErrorResponse is the problem details body of every error response
+type ErrorResponse = entities.Problem
 
-// ErrorResponse standardizes error responses
-type ErrorResponse struct {
-	Error string `json:"error"`
-}
+// ProblemFieldError describes a parameter rejected by validation
+type ProblemFieldError = entities.ProblemFieldError
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"fizzbuzz-server/internal/config"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var startedAt = time.Now()
+
+// HealthResponse represents the health endpoint response
+type HealthResponse struct {
+	Status        string `json:"status"`
+	Uptime        string `json:"uptime"`
+	ConfigVersion uint64 `json:"config_version"`
+}
+
+// HealthHandler reports that the server is up
+func HealthHandler(c *fiber.Ctx) error {
+	return c.JSON(HealthResponse{
+		Status:        "ok",
+		Uptime:        time.Since(startedAt).Round(time.Second).String(),
+		ConfigVersion: config.Version(),
+	})
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// clientLocal is the key of the client identity in the request locals
+const clientLocal = "client"
+
+// ClientIdentityMiddleware identifies clients by the subject common name of
+// their verified TLS certificate, or by the full subject when it has no
+// common name. Requests without a verified certificate are anonymous.
+func ClientIdentityMiddleware(c *fiber.Ctx) error {
+	if state := c.Context().TLSConnectionState(); state != nil && len(state.VerifiedChains) > 0 {
+		subject := state.VerifiedChains[0][0].Subject
+		identity := subject.CommonName
+		if identity == "" {
+			identity = subject.String()
+		}
+		c.Locals(clientLocal, identity)
+	}
+	return c.Next()
+}
+
+// ClientIdentity returns the identity of the client, empty when anonymous
+func ClientIdentity(c *fiber.Ctx) string {
+	identity, _ := c.Locals(clientLocal).(string)
+	return identity
+}
@@ -0,0 +1,76 @@
+package handlers_test
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fizzbuzz-server/internal/apps"
+	"fizzbuzz-server/internal/config"
+	"fizzbuzz-server/internal/entities"
+	"fizzbuzz-server/internal/handlers"
+	"fizzbuzz-server/internal/tlsconfig"
+	"fizzbuzz-server/internal/tlsconfig/tlstest"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientCertificateIdentity(t *testing.T) {
+	setConfigEnv(t, map[string]string{"API_KEYS": "secret"})
+	apps.App().StatsService.Reset()
+
+	ca := tlstest.NewCA(t)
+	dir := t.TempDir()
+	certFile, keyFile := ca.WriteCert(t, dir, "server")
+	reloader, err := tlsconfig.New(config.TLSConfig{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		MinVersion:   "1.2",
+		ClientCAFile: ca.WriteCA(t, dir),
+		ClientAuth:   "optional",
+	})
+	require.NoError(t, err)
+
+	app := handlers.NewFiberApp()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = app.Listener(tls.NewListener(ln, reloader.Config())) }()
+	t.Cleanup(func() { _ = app.ShutdownWithTimeout(shutdownTimeout) })
+	url := "https://" + ln.Addr().String()
+
+	newClient := func(certs ...tls.Certificate) *http.Client {
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: ca.Pool(), ServerName: "localhost", Certificates: certs},
+		}}
+		// idle keep-alive connections would otherwise delay the shutdown,
+		// this cleanup running before the one of the app
+		t.Cleanup(client.CloseIdleConnections)
+		return client
+	}
+
+	// anonymous clients need an API key
+	resp, err := newClient().Get(url + "/fizzbuzz?int1=3&int2=5&limit=15")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// the certificate authenticates the client and is counted in its name
+	alice := newClient(ca.Issue(t, "alice"))
+	for range 2 {
+		resp, err = alice.Get(url + "/fizzbuzz?int1=3&int2=5&limit=15")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url+"/admin/stats/clients", nil)
+	require.NoError(t, err)
+	resp, err = alice.Do(asAdmin(req))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var clients handlers.ClientStatsResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&clients))
+	assert.Equal(t, []entities.ClientStats{{Client: "alice", Requests: 2}}, clients.Clients)
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"fizzbuzz-server/internal/i18n"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// translatorLocal is the key of the translator of the request in the locals
+const translatorLocal = "translator"
+
+// LocaleMiddleware selects the language of the messages returned to the
+// client from its Accept-Language header. The translator is also set on the
+// user context, for the messages of the binder and of GraphQL.
+func LocaleMiddleware(c *fiber.Ctx) error {
+	t := i18n.ForAcceptLanguage(c.Get(fiber.HeaderAcceptLanguage))
+	c.Locals(translatorLocal, t)
+	c.SetUserContext(i18n.WithTranslator(c.UserContext(), t))
+	return c.Next()
+}
+
+// translator returns the translator of the request, English when
+// LocaleMiddleware did not run
+func translator(c *fiber.Ctx) i18n.Translator {
+	t, _ := c.Locals(translatorLocal).(i18n.Translator)
+	return t
+}
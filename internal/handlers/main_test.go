@@ -0,0 +1,64 @@
+package handlers_test
+
+import (
+	"fizzbuzz-server/internal/apps"
+	"fizzbuzz-server/internal/config"
+	"fizzbuzz-server/internal/handlers"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+)
+
+// adminToken is the admin token of the tests, without which the public app
+// does not serve the admin endpoints
+const adminToken = "admin-token"
+
+// adminHeaders authorize the requests of tenantRequest on admin endpoints
+var adminHeaders = map[string]string{"Authorization": "Bearer " + adminToken}
+
+func TestMain(m *testing.M) {
+	_ = os.Setenv("ADMIN_TOKEN", adminToken)
+	if _, err := config.Load(); err != nil {
+		panic(err)
+	}
+	apps.App().FiberApp = handlers.NewFiberApp()
+	os.Exit(m.Run())
+}
+
+// asAdmin authorizes req on the admin endpoints
+func asAdmin(req *http.Request) *http.Request {
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer "+adminToken)
+	return req
+}
+
+// shutdownTimeout bounds the shutdown of the servers started by tests, so
+// that a connection left open cannot hang them
+const shutdownTimeout = 5 * time.Second
+
+// startServer serves a new app on a free port, for tests needing a real
+// connection, and returns its address
+func startServer(t *testing.T) string {
+	app := handlers.NewFiberApp()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = app.Listener(ln) }()
+	t.Cleanup(func() { _ = app.ShutdownWithTimeout(shutdownTimeout) })
+	return ln.Addr().String()
+}
+
+// setConfigEnv sets environment variables and reloads the configuration,
+// restoring both when the test ends
+func setConfigEnv(t *testing.T, env map[string]string) {
+	// registered first so that it runs after the variables are restored
+	t.Cleanup(func() { _, _ = config.Load() })
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+	_, err := config.Load()
+	require.NoError(t, err)
+}
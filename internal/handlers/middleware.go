@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"fizzbuzz-server/internal/binding"
+	"fizzbuzz-server/internal/config"
+	"fizzbuzz-server/pkg/ulog"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+)
+
+// BinderMiddleware makes the shared binder available to handlers
+func BinderMiddleware(binder *binding.Binder) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals(binderLocal, binder)
+		return c.Next()
+	}
+}
+
+// AccessLogMiddleware logs every request through the "http" component logger.
+// Successful requests are logged at info level and sampled according to the
+// logging configuration, client and server errors are always kept. stop ends
+// the following of configuration reloads.
+func AccessLogMiddleware(cfg config.LogConfig) (handler fiber.Handler, stop func()) {
+	var current atomic.Pointer[zerolog.Logger]
+	build := func(cfg config.LogConfig) {
+		log := ulog.Component("http").Sample(zerolog.LevelSampler{
+			InfoSampler: accessSampler(cfg),
+		})
+		current.Store(&log)
+	}
+	build(cfg)
+
+	stop = config.Subscribe(func(old, new *config.Config) {
+		if old.Log.AccessSampleEvery != new.Log.AccessSampleEvery ||
+			old.Log.AccessSampleBurst != new.Log.AccessSampleBurst ||
+			old.Log.AccessSamplePeriod != new.Log.AccessSamplePeriod {
+			build(new.Log)
+		}
+	})
+
+	return func(c *fiber.Ctx) error {
+		log := current.Load()
+		start := time.Now()
+		err := c.Next()
+		if err != nil {
+			// let the error handler set the final status before logging
+			if handlerErr := c.App().ErrorHandler(c, err); handlerErr != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		status := c.Response().StatusCode()
+		var event *zerolog.Event
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			event = log.Error()
+		case status >= fiber.StatusBadRequest:
+			event = log.Warn()
+		default:
+			event = log.Info()
+		}
+		if client := ClientIdentity(c); client != "" {
+			event.Str("client", client)
+		}
+		event.
+			Str("method", c.Method()).
+			Str("path", c.Path()).
+			Int("status", status).
+			Dur("latency", time.Since(start)).
+			Str("ip", c.IP()).
+			Msg("request")
+		return nil
+	}, stop
+}
+
+func accessSampler(cfg config.LogConfig) zerolog.Sampler {
+	if cfg.AccessSampleEvery <= 1 && cfg.AccessSampleBurst == 0 {
+		return nil
+	}
+	next := &zerolog.BasicSampler{N: max(cfg.AccessSampleEvery, 1)}
+	if cfg.AccessSampleBurst == 0 {
+		return next
+	}
+	return &zerolog.BurstSampler{
+		Burst:       cfg.AccessSampleBurst,
+		Period:      cfg.AccessSamplePeriod,
+		NextSampler: next,
+	}
+}
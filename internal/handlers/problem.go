@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"errors"
+	"fizzbuzz-server/internal/binding"
+	"fizzbuzz-server/internal/i18n"
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// MIMEApplicationProblemJSON is the content type of error responses
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// problemTypePrefix prefixes the stable type of every problem
+const problemTypePrefix = "urn:fizzbuzz:problem:"
+
+// Problem types other than the generic ones derived from the status
+const (
+	ProblemValidation       = problemTypePrefix + "validation-error"
+	ProblemResponseTooLarge = problemTypePrefix + "response-too-large"
+)
+
+// problemTypes are the types of the problems of a status, when not more
+// specific. Statuses without one are reported as about:blank.
+var problemTypes = map[int]string{
+	fiber.StatusBadRequest:            problemTypePrefix + "bad-request",
+	fiber.StatusUnauthorized:          problemTypePrefix + "unauthorized",
+	fiber.StatusNotFound:              problemTypePrefix + "not-found",
+	fiber.StatusMethodNotAllowed:      problemTypePrefix + "method-not-allowed",
+	fiber.StatusRequestEntityTooLarge: problemTypePrefix + "payload-too-large",
+	fiber.StatusUnprocessableEntity:   problemTypePrefix + "unprocessable-entity",
+	fiber.StatusUpgradeRequired:       problemTypePrefix + "upgrade-required",
+	fiber.StatusTooManyRequests:       problemTypePrefix + "too-many-requests",
+	fiber.StatusInternalServerError:   problemTypePrefix + "internal-error",
+	fiber.StatusServiceUnavailable:    problemTypePrefix + "service-unavailable",
+	fiber.StatusGatewayTimeout:        problemTypePrefix + "timeout",
+}
+
+// problem answers with a problem of status, its detail being the message
+// key translated with params
+func problem(c *fiber.Ctx, status int, key string, params ...string) error {
+	t := translator(c)
+	problemType, ok := problemTypes[status]
+	if !ok {
+		problemType = "about:blank"
+	}
+	return sendProblem(c, t, ErrorResponse{
+		Type:   problemType,
+		Title:  statusTitle(t, status),
+		Status: status,
+		Detail: t.T(key, params...),
+	})
+}
+
+// bindingProblem answers with the problem of a request the binder rejected:
+// a validation problem listing the rejected fields, else a bad request
+func bindingProblem(c *fiber.Ctx, err error) error {
+	var bindErr *binding.Error
+	if !errors.As(err, &bindErr) {
+		return problem(c, fiber.StatusInternalServerError, "internal_error")
+	}
+	t := translator(c)
+	return sendProblem(c, t, bindingErrorProblem(t, bindErr))
+}
+
+// bindingErrorProblem returns the problem of a binding error translated by t
+func bindingErrorProblem(t i18n.Translator, bindErr *binding.Error) ErrorResponse {
+	if len(bindErr.Fields) == 0 {
+		return ErrorResponse{
+			Type:   problemTypes[fiber.StatusBadRequest],
+			Title:  statusTitle(t, fiber.StatusBadRequest),
+			Status: fiber.StatusBadRequest,
+			Detail: bindErr.Message,
+		}
+	}
+	fields := make([]ProblemFieldError, len(bindErr.Fields))
+	for i, fe := range bindErr.Fields {
+		fields[i] = ProblemFieldError{Field: fe.Field, Code: fe.Rule, Message: fe.Message(t), Param: fe.Param}
+	}
+	return ErrorResponse{
+		Type:   ProblemValidation,
+		Title:  t.T("invalid_parameters"),
+		Status: fiber.StatusBadRequest,
+		Detail: bindErr.Message,
+		Errors: fields,
+	}
+}
+
+// statusTitle translates the reason phrase of status
+func statusTitle(t i18n.Translator, status int) string {
+	key := "status." + strconv.Itoa(status)
+	if title := t.T(key); title != key {
+		return title
+	}
+	return http.StatusText(status)
+}
+
+func sendProblem(c *fiber.Ctx, t i18n.Translator, body ErrorResponse) error {
+	c.Set(fiber.HeaderContentLanguage, t.Locale())
+	return c.Status(body.Status).JSON(body, MIMEApplicationProblemJSON)
+}
+
+// ProblemErrorHandler answers the errors returned by handlers, unknown
+// routes and recovered panics with a problem. Fiber errors keep their
+// status and message, other errors are internal and their text is not
+// exposed.
+func ProblemErrorHandler(c *fiber.Ctx, err error) error {
+	var fiberErr *fiber.Error
+	if !errors.As(err, &fiberErr) {
+		return problem(c, fiber.StatusInternalServerError, "internal_error")
+	}
+	if fiberErr.Code == fiber.StatusNotFound {
+		return problem(c, fiber.StatusNotFound, "route_not_found", c.Method(), c.Path())
+	}
+	return problem(c, fiberErr.Code, fiberErr.Message)
+}
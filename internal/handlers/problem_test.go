@@ -0,0 +1,103 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fizzbuzz-server/internal/apps"
+	"fizzbuzz-server/internal/handlers"
+	"fizzbuzz-server/mocks"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// problemRequest performs a GET and returns the problem it is answered with
+func problemRequest(t *testing.T, target string) handlers.ErrorResponse {
+	resp, err := apps.App().FiberApp.Test(httptest.NewRequest(http.MethodGet, target, nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, handlers.MIMEApplicationProblemJSON, resp.Header.Get("Content-Type"))
+
+	var problem handlers.ErrorResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+	assert.Equal(t, resp.StatusCode, problem.Status)
+	return problem
+}
+
+func TestProblem_Validation(t *testing.T) {
+	problem := problemRequest(t, "/fizzbuzz?int1=3&int2=-1&limit=15&str1=+")
+	assert.Equal(t, handlers.ErrorResponse{
+		Type:   handlers.ProblemValidation,
+		Title:  "Invalid parameters",
+		Status: http.StatusBadRequest,
+		Detail: "int2 must be greater than 0",
+		Errors: []handlers.ProblemFieldError{
+			{Field: "int2", Code: "gt", Message: "int2 must be greater than 0", Param: "0"},
+		},
+	}, problem)
+
+	problem = problemRequest(t, "/fizzbuzz?int1=x")
+	assert.Equal(t, "urn:fizzbuzz:problem:bad-request", problem.Type)
+	assert.Equal(t, "Invalid parameter format", problem.Detail)
+	assert.Empty(t, problem.Errors)
+}
+
+func TestProblem_OtherErrors(t *testing.T) {
+	problem := problemRequest(t, "/unknown")
+	assert.Equal(t, "urn:fizzbuzz:problem:not-found", problem.Type)
+	assert.Equal(t, "Not Found", problem.Title)
+
+	problem = problemRequest(t, "/stats/top?n=0")
+	assert.Equal(t, "urn:fizzbuzz:problem:bad-request", problem.Type)
+
+	// the stats failing is an internal error
+	stats := mocks.NewStatsServiceIface(t)
+	stats.On("Top", 1, time.Duration(0)).Return(nil, errors.New("boom"))
+	previous := apps.App().StatsService
+	apps.App().StatsService = stats
+	t.Cleanup(func() { apps.App().StatsService = previous })
+
+	problem = problemRequest(t, "/stats")
+	assert.Equal(t, handlers.ErrorResponse{
+		Type:   "urn:fizzbuzz:problem:internal-error",
+		Title:  "Internal Server Error",
+		Status: http.StatusInternalServerError,
+		Detail: "Internal stats error",
+	}, problem)
+}
+
+func TestProblem_Localised(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/fizzbuzz?int1=3&int2=5", nil)
+	req.Header.Set("Accept-Language", "fr-FR,fr;q=0.9,en;q=0.8")
+	resp, err := apps.App().FiberApp.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "fr", resp.Header.Get("Content-Language"))
+
+	var problem handlers.ErrorResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+	// messages are translated, types and codes are not
+	assert.Equal(t, handlers.ErrorResponse{
+		Type:   handlers.ProblemValidation,
+		Title:  "Paramètres invalides",
+		Status: http.StatusBadRequest,
+		Detail: "limit est obligatoire",
+		Errors: []handlers.ProblemFieldError{
+			{Field: "limit", Code: "required", Message: "limit est obligatoire"},
+		},
+	}, problem)
+
+	req = httptest.NewRequest(http.MethodGet, "/unknown", nil)
+	req.Header.Set("Accept-Language", "pt-BR")
+	resp, err = apps.App().FiberApp.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+	assert.Equal(t, "urn:fizzbuzz:problem:not-found", problem.Type)
+	assert.Equal(t, "Não encontrado", problem.Title)
+	assert.Equal(t, "Não é possível processar GET /unknown", problem.Detail)
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"fizzbuzz-server/internal/config"
+	"sync/atomic"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+)
+
+// RateLimitMiddleware limits requests per client according to the rate limit
+// configuration. Clients are told apart by their certificate identity, or by
+// IP when anonymous. The limiter is rebuilt when the configuration is
+// reloaded, which also resets the current windows, until stop is called.
+func RateLimitMiddleware() (handler fiber.Handler, stop func()) {
+	var current atomic.Pointer[fiber.Handler]
+	build := func(cfg config.RateLimitConfig) {
+		handler := newLimiter(cfg)
+		current.Store(&handler)
+	}
+	build(config.Get().RateLimit)
+
+	stop = config.Subscribe(func(old, new *config.Config) {
+		if old.RateLimit != new.RateLimit {
+			build(new.RateLimit)
+		}
+	})
+
+	return func(c *fiber.Ctx) error {
+		return (*current.Load())(c)
+	}, stop
+}
+
+func newLimiter(cfg config.RateLimitConfig) fiber.Handler {
+	if !cfg.Enabled {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+	return limiter.New(limiter.Config{
+		Max:        cfg.Max,        // Maximum number of requests
+		Expiration: cfg.Expiration, // Time frame for the rate limit
+		KeyGenerator: func(c *fiber.Ctx) string {
+			if client := ClientIdentity(c); client != "" {
+				return "client:" + client
+			}
+			return c.IP()
+		},
+		LimitReached: func(c *fiber.Ctx) error {
+			return problem(c, fiber.StatusTooManyRequests, "too_many_requests")
+		},
+	})
+}
@@ -0,0 +1,31 @@
+package handlers_test
+
+import (
+	"fizzbuzz-server/internal/apps"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimit(t *testing.T) {
+	get := func() int {
+		resp, err := apps.App().FiberApp.Test(httptest.NewRequest(http.MethodGet, "/fizzbuzz?int1=3&int2=5&limit=15", nil))
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// off by default
+	for range 3 {
+		assert.Equal(t, http.StatusOK, get())
+	}
+
+	// the limiter follows configuration reloads
+	setConfigEnv(t, map[string]string{"RATE_LIMIT_ENABLED": "true", "RATE_LIMIT_MAX": "2", "RATE_LIMIT_EXPIRATION": "1m"})
+	assert.Equal(t, http.StatusOK, get())
+	assert.Equal(t, http.StatusOK, get())
+	assert.Equal(t, http.StatusTooManyRequests, get())
+}
@@ -1,33 +1,91 @@
 package handlers
 
 import (
+	"fizzbuzz-server/internal/config"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/limiter"
+	"github.com/gofiber/fiber/v2/middleware/pprof"
 )
 
+// RegisterRoutes registers the public API, at the root and under
+// /tenants/<name> for each tenant, and the operational endpoints unless they
+// are served by the admin listener. The admin endpoints then need the admin
+// token, without one they are not served.
 func RegisterRoutes(fiberApp *fiber.App) {
-	// Define the rate limiting configuration
-	rateLimitConfig := limiter.Config{
-		Max:        100,             // Maximum number of requests
-		Expiration: 1 * time.Second, // Time frame for the rate limit
-	}
+	// Rate limiting for the public API, driven by the configuration
+	rateLimit, stopRateLimit := RateLimitMiddleware()
+	onShutdown(fiberApp, stopRateLimit)
+	// Stats events are published per tenant, whatever the route
+	statsEvents := StatsEventsHandler()
+	graphql := GraphQLHandler()
 
 	// API Documentation route
 	fiberApp.Get("/docs", DocHandler)
+	registerAPIRoutes(fiberApp, rateLimit, statsEvents, graphql)
+	registerAPIRoutes(fiberApp.Group("/tenants/:tenant"), rateLimit, statsEvents, graphql)
+	// Health check, also used by the public load balancer
+	fiberApp.Get("/health", HealthHandler)
+
+	if config.Get().Admin.Address == "" {
+		RegisterOperationalRoutes(fiberApp, AdminAuthMiddleware(true))
+	}
+}
+
+// registerAPIRoutes registers the routes of the public API whose requests
+// belong to a tenant
+func registerAPIRoutes(router fiber.Router, rateLimit, statsEvents, graphql fiber.Handler) {
+	// Deadlines of the routes generating sequences
+	fizzbuzzTimeout := TimeoutMiddleware(func(cfg config.TimeoutConfig) time.Duration { return cfg.FizzBuzz })
+	batchTimeout := TimeoutMiddleware(func(cfg config.TimeoutConfig) time.Duration { return cfg.Batch })
+	graphqlTimeout := TimeoutMiddleware(func(cfg config.TimeoutConfig) time.Duration { return cfg.GraphQL })
+	rpcTimeout := TimeoutMiddleware(func(cfg config.TimeoutConfig) time.Duration { return cfg.RPC })
+
 	// FizzBuzz endpoint
-	fiberApp.Get("/fizzbuzz", FizzbuzzHandler)
+	router.Get("/fizzbuzz", rateLimit, TenantMiddleware, APIKeyMiddleware, fizzbuzzTimeout, FizzbuzzHandler)
+	router.Post("/fizzbuzz/batch", rateLimit, TenantMiddleware, APIKeyMiddleware, batchTimeout, FizzbuzzBatchHandler)
 	// Stats endpoint
-	fiberApp.Get("/stats", Stats)
+	router.Get("/stats", rateLimit, TenantMiddleware, APIKeyMiddleware, Stats)
+	router.Get("/stats/top", rateLimit, TenantMiddleware, APIKeyMiddleware, StatsTop)
+	router.Get("/stats/events", rateLimit, TenantMiddleware, APIKeyMiddleware, statsEvents)
+	// Interactive sessions
+	router.Get("/ws", rateLimit, TenantMiddleware, APIKeyMiddleware, WebSocketUpgrade, WebSocketHandler())
+	// GraphQL queries, and subscriptions over WebSocket
+	router.Get("/graphql", rateLimit, TenantMiddleware, APIKeyMiddleware, graphqlTimeout, graphql)
+	router.Post("/graphql", rateLimit, TenantMiddleware, APIKeyMiddleware, graphqlTimeout, graphql)
+	// JSON-RPC 2.0
+	router.Post("/rpc", rateLimit, TenantMiddleware, APIKeyMiddleware, rpcTimeout, RPCHandler)
+}
+
+// RegisterAdminRoutes registers the routes of the admin listener: the
+// operational endpoints, the health check and the profiler
+func RegisterAdminRoutes(fiberApp *fiber.App) {
+	adminAuth := AdminAuthMiddleware(false)
+	fiberApp.Get("/health", HealthHandler)
+	// Profiling data, under the admin token like the admin endpoints
+	fiberApp.Use("/debug/pprof", adminAuth)
+	fiberApp.Use(pprof.New())
+
+	RegisterOperationalRoutes(fiberApp, adminAuth)
+}
+
+// RegisterOperationalRoutes registers the metrics and the admin endpoints,
+// the latter behind adminAuth
+func RegisterOperationalRoutes(fiberApp *fiber.App, adminAuth fiber.Handler) {
 	// Prometheus metrics endpoint
 	fiberApp.Get("/metrics", MetricsHandler)
 
-	_ = rateLimitConfig
-	/*
-		fiberApp.Get("senior-rh-emp/:cpf", limiter.New(rateLimitConfig), TokenMiddleware(func(ctx *fiber.Ctx) error {
-			ulog.Info("senior-rh-emp", "cpf", ctx.Params("cpf"))
-			return SeniorRhEmp(ctx)
-		}))
-	*/
+	// Admin endpoints
+	admin := fiberApp.Group("/admin", adminAuth)
+	admin.Get("/log-level", GetLogLevel)
+	admin.Put("/log-level", SetLogLevel)
+	admin.Get("/config", GetConfig)
+	admin.Get("/config/version", GetConfigVersion)
+	admin.Post("/config/reload", ReloadConfig)
+	admin.Post("/stats/reset", ResetStats)
+	admin.Get("/stats/export", ExportStats)
+	admin.Get("/stats/clients", ClientStats)
+	admin.Post("/stats/import", ImportStats)
+	admin.Get("/stats/tenants", TenantStats)
+	admin.Get("/stats/top", AdminStatsTop)
 }
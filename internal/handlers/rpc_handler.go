@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fizzbuzz-server/internal/binding"
+	"fizzbuzz-server/internal/config"
+	"fizzbuzz-server/internal/entities"
+	"fizzbuzz-server/internal/output"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// JSON-RPC 2.0 error codes
+const (
+	RPCParseError     = -32700
+	RPCInvalidRequest = -32600
+	RPCMethodNotFound = -32601
+	RPCInvalidParams  = -32602
+	RPCInternalError  = -32603
+)
+
+// RPCRequest is a JSON-RPC 2.0 call. A call without id is a notification,
+// which gets no response.
+type RPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+}
+
+// RPCResponse is the response to a JSON-RPC 2.0 call
+type RPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// RPCError is the error member of a JSON-RPC 2.0 response
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// RPCFieldError describes a parameter rejected by validation
+type RPCFieldError = binding.FieldError
+
+// rpcMethod runs a method with its raw params
+type rpcMethod func(c *fiber.Ctx, params json.RawMessage) (any, *RPCError)
+
+var rpcMethods = map[string]rpcMethod{
+	"fizzbuzz.generate":  rpcGenerate,
+	"stats.mostFrequent": rpcMostFrequent,
+	"stats.top":          rpcTop,
+}
+
+// RPCHandler serves JSON-RPC 2.0 single and batch calls. Requests are
+// counted in the stats like their REST counterparts, notifications included.
+// Calls interrupted by the deadline of the request or the client
+// disconnecting are answered like REST requests, with a problem.
+func RPCHandler(c *fiber.Ctx) error {
+	body := bytes.TrimSpace(c.Body())
+	if !json.Valid(body) {
+		return c.JSON(rpcErrorResponse(nil, RPCParseError, "Parse error"))
+	}
+
+	if len(body) == 0 || body[0] != '[' {
+		resp, ok := rpcCall(c, body)
+		if err := c.UserContext().Err(); err != nil {
+			return cancelledProblem(c, err)
+		}
+		if !ok {
+			return c.SendStatus(fiber.StatusNoContent)
+		}
+		return c.JSON(resp)
+	}
+
+	var batch []json.RawMessage
+	_ = json.Unmarshal(body, &batch)
+	if len(batch) == 0 {
+		return c.JSON(rpcErrorResponse(nil, RPCInvalidRequest, "Invalid Request"))
+	}
+	if maxSize := config.Get().FizzBuzz.MaxBatchSize; len(batch) > maxSize {
+		return c.JSON(rpcErrorResponse(nil, RPCInvalidRequest, fmt.Sprintf("Batch must contain at most %d calls", maxSize)))
+	}
+
+	responses := make([]RPCResponse, 0, len(batch))
+	for _, call := range batch {
+		if resp, ok := rpcCall(c, call); ok {
+			responses = append(responses, resp)
+		}
+	}
+	if err := c.UserContext().Err(); err != nil {
+		return cancelledProblem(c, err)
+	}
+	if len(responses) == 0 {
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+	return c.JSON(responses)
+}
+
+// rpcCall runs one call, reporting false for notifications
+func rpcCall(c *fiber.Ctx, data json.RawMessage) (RPCResponse, bool) {
+	var req RPCRequest
+	if err := json.Unmarshal(data, &req); err != nil || req.JSONRPC != "2.0" || req.Method == "" {
+		return rpcErrorResponse(nil, RPCInvalidRequest, "Invalid Request"), true
+	}
+	if req.ID != nil && !validRPCID(req.ID) {
+		return rpcErrorResponse(nil, RPCInvalidRequest, "Invalid Request"), true
+	}
+	notification := req.ID == nil
+
+	method, ok := rpcMethods[req.Method]
+	if !ok {
+		return rpcErrorResponse(req.ID, RPCMethodNotFound, "Method not found"), !notification
+	}
+	result, rpcErr := method(c, req.Params)
+	if rpcErr != nil {
+		return RPCResponse{JSONRPC: "2.0", Error: rpcErr, ID: req.ID}, !notification
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return rpcErrorResponse(req.ID, RPCInternalError, "Internal error"), !notification
+	}
+	return RPCResponse{JSONRPC: "2.0", Result: data, ID: req.ID}, !notification
+}
+
+// validRPCID reports whether id is a string, a number or null
+func validRPCID(id json.RawMessage) bool {
+	switch id[0] {
+	case '"', 'n', '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return true
+	}
+	return false
+}
+
+func rpcErrorResponse(id json.RawMessage, code int, message string) RPCResponse {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return RPCResponse{JSONRPC: "2.0", Error: &RPCError{Code: code, Message: message}, ID: id}
+}
+
+// decodeRPCParams decodes by-name params, or by-position params taken in
+// the order of names, into dst. Absent params leave dst unchanged.
+func decodeRPCParams(params json.RawMessage, names []string, dst any) *RPCError {
+	params = bytes.TrimSpace(params)
+	if len(params) == 0 || bytes.Equal(params, []byte("null")) {
+		return nil
+	}
+
+	if params[0] == '[' {
+		var values []json.RawMessage
+		if err := json.Unmarshal(params, &values); err != nil || len(values) > len(names) {
+			return &RPCError{Code: RPCInvalidParams, Message: "Invalid params", Data: fmt.Sprintf("at most %d positional params", len(names))}
+		}
+		byName := make(map[string]json.RawMessage, len(values))
+		for i, value := range values {
+			byName[names[i]] = value
+		}
+		params, _ = json.Marshal(byName)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(params))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		return &RPCError{Code: RPCInvalidParams, Message: "Invalid params", Data: err.Error()}
+	}
+	return nil
+}
+
+// rpcValidationError maps binding errors to an invalid params error
+// listing the rejected fields
+func rpcValidationError(err error) *RPCError {
+	var bindErr *binding.Error
+	if !errors.As(err, &bindErr) || len(bindErr.Fields) == 0 {
+		return &RPCError{Code: RPCInvalidParams, Message: "Invalid params", Data: err.Error()}
+	}
+	return &RPCError{Code: RPCInvalidParams, Message: "Invalid params", Data: bindErr.Fields}
+}
+
+func rpcGenerate(c *fiber.Ctx, params json.RawMessage) (any, *RPCError) {
+	req := entities.FizzBuzzRequest{}
+	if err := decodeRPCParams(params, []string{"int1", "int2", "limit", "str1", "str2"}, &req); err != nil {
+		return nil, err
+	}
+	tenant := Tenant(c)
+	if err := requestBinder(c).Complete(tenantContext(c.UserContext(), tenant), &req); err != nil {
+		return nil, rpcValidationError(err)
+	}
+	if tooLarge := checkResponseSize("rpc", output.FormatJSON, req); tooLarge != nil {
+		return nil, &RPCError{Code: RPCInvalidParams, Message: "Invalid params", Data: tooLarge.Message(translator(c))}
+	}
+
+	result, err := generateFizzBuzzWithContext(c.UserContext(), req)
+	if err != nil {
+		return nil, &RPCError{Code: RPCInternalError, Message: "Internal error", Data: err.Error()}
+	}
+	updateStats(tenant, ClientIdentity(c), req)
+	return result, nil
+}
+
+// rpcWindowParams are the params of stats.mostFrequent
+type rpcWindowParams struct {
+	Window string `json:"window"`
+}
+
+// rpcTopParams are the params of stats.top
+type rpcTopParams struct {
+	N      int    `json:"n"`
+	Window string `json:"window"`
+}
+
+func rpcMostFrequent(c *fiber.Ctx, params json.RawMessage) (any, *RPCError) {
+	var p rpcWindowParams
+	if err := decodeRPCParams(params, []string{"window"}, &p); err != nil {
+		return nil, err
+	}
+	top, err := rpcTopEntries(Tenant(c), 1, p.Window)
+	if err != nil || len(top) == 0 {
+		return nil, err
+	}
+	return top[0], nil
+}
+
+func rpcTop(c *fiber.Ctx, params json.RawMessage) (any, *RPCError) {
+	p := rpcTopParams{N: 10}
+	if err := decodeRPCParams(params, []string{"n", "window"}, &p); err != nil {
+		return nil, err
+	}
+	if p.N <= 0 || p.N > maxTopEntries {
+		return nil, &RPCError{Code: RPCInvalidParams, Message: "Invalid params", Data: fmt.Sprintf("n must be between 1 and %d", maxTopEntries)}
+	}
+	return rpcTopEntries(Tenant(c), p.N, p.Window)
+}
+
+func rpcTopEntries(tenant string, n int, window string) ([]entities.StatsEntry, *RPCError) {
+	var d time.Duration
+	if window != "" {
+		var err error
+		if d, err = time.ParseDuration(window); err != nil || d <= 0 {
+			return nil, &RPCError{Code: RPCInvalidParams, Message: "Invalid params", Data: "window must be a positive duration"}
+		}
+	}
+	top, err := tenantStats(tenant).Top(n, d)
+	if err != nil {
+		return nil, &RPCError{Code: RPCInvalidParams, Message: "Invalid params", Data: err.Error()}
+	}
+	return top, nil
+}
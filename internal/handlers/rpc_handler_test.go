@@ -0,0 +1,118 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fizzbuzz-server/internal/apps"
+	"fizzbuzz-server/internal/handlers"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func postRPC(t *testing.T, body string) *http.Response {
+	req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := apps.App().FiberApp.Test(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func decodeRPC[T any](t *testing.T, resp *http.Response) T {
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var v T
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&v))
+	return v
+}
+
+func TestRPC_Generate(t *testing.T) {
+	resp := postRPC(t, `{"jsonrpc":"2.0","method":"fizzbuzz.generate","params":{"int1":3,"int2":5,"limit":5},"id":1}`)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"jsonrpc":"2.0","result":["1","2","fizz","4","buzz"],"id":1}`, string(body))
+}
+
+func TestRPC_PositionalParams(t *testing.T) {
+	resp := postRPC(t, `{"jsonrpc":"2.0","method":"fizzbuzz.generate","params":[2,3,3,"a","b"],"id":"x"}`)
+
+	response := decodeRPC[handlers.RPCResponse](t, resp)
+	assert.Nil(t, response.Error)
+	assert.JSONEq(t, `["1","a","b"]`, string(response.Result))
+	assert.JSONEq(t, `"x"`, string(response.ID))
+}
+
+func TestRPC_ValidationError(t *testing.T) {
+	resp := postRPC(t, `{"jsonrpc":"2.0","method":"fizzbuzz.generate","params":{"int1":3,"int2":5,"limit":0},"id":1}`)
+
+	response := decodeRPC[struct {
+		Error struct {
+			Code int                      `json:"code"`
+			Data []handlers.RPCFieldError `json:"data"`
+		} `json:"error"`
+	}](t, resp)
+	assert.Equal(t, handlers.RPCInvalidParams, response.Error.Code)
+	assert.Equal(t, []handlers.RPCFieldError{{Field: "limit", Rule: "required"}}, response.Error.Data)
+}
+
+func TestRPC_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		code int
+	}{
+		{"parse error", `{"jsonrpc":"2.0","method"`, handlers.RPCParseError},
+		{"invalid request", `{"jsonrpc":"1.0","method":"stats.top","id":1}`, handlers.RPCInvalidRequest},
+		{"empty batch", `[]`, handlers.RPCInvalidRequest},
+		{"method not found", `{"jsonrpc":"2.0","method":"nope","id":1}`, handlers.RPCMethodNotFound},
+		{"unknown param", `{"jsonrpc":"2.0","method":"stats.top","params":{"m":1},"id":1}`, handlers.RPCInvalidParams},
+		{"invalid window", `{"jsonrpc":"2.0","method":"stats.top","params":{"window":"soon"},"id":1}`, handlers.RPCInvalidParams},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := decodeRPC[handlers.RPCResponse](t, postRPC(t, tt.body))
+			require.NotNil(t, response.Error)
+			assert.Equal(t, tt.code, response.Error.Code)
+		})
+	}
+}
+
+func TestRPC_BatchAndNotifications(t *testing.T) {
+	apps.App().StatsService.Reset()
+
+	resp := postRPC(t, `[
+		{"jsonrpc":"2.0","method":"fizzbuzz.generate","params":{"int1":3,"int2":5,"limit":15}},
+		{"jsonrpc":"2.0","method":"fizzbuzz.generate","params":{"int1":3,"int2":5,"limit":15},"id":1},
+		{"jsonrpc":"2.0","method":"stats.mostFrequent","id":2},
+		{"jsonrpc":"2.0","method":"nope","id":3},
+		1
+	]`)
+
+	responses := decodeRPC[[]handlers.RPCResponse](t, resp)
+	require.Len(t, responses, 4)
+	assert.JSONEq(t, `1`, string(responses[0].ID))
+	assert.JSONEq(t, `2`, string(responses[1].ID))
+	// the notification counts in the stats like the call
+	assert.JSONEq(t, `{"int1":3,"int2":5,"limit":15,"str1":"fizz","str2":"buzz","hits":2}`, string(responses[1].Result))
+	assert.Equal(t, handlers.RPCMethodNotFound, responses[2].Error.Code)
+	assert.Equal(t, handlers.RPCInvalidRequest, responses[3].Error.Code)
+	assert.JSONEq(t, `null`, string(responses[3].ID))
+}
+
+func TestRPC_OnlyNotifications(t *testing.T) {
+	resp := postRPC(t, `[{"jsonrpc":"2.0","method":"stats.top"}]`)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+}
+
+func TestRPC_MostFrequentWithoutRequests(t *testing.T) {
+	apps.App().StatsService.Reset()
+
+	response := decodeRPC[handlers.RPCResponse](t, postRPC(t, `{"jsonrpc":"2.0","method":"stats.mostFrequent","id":1}`))
+	assert.Nil(t, response.Error)
+	assert.JSONEq(t, `null`, string(response.Result))
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"fizzbuzz-server/internal/config"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+)
+
+// NewFiberApp builds the Fiber application with the shared middleware and
+// every route registered
+func NewFiberApp() *fiber.App {
+	cfg := config.Get()
+
+	fiberApp := fiber.New(fiber.Config{
+		AppName:      cfg.Telemetry.ServiceName,
+		ErrorHandler: ProblemErrorHandler,
+	})
+	fiberApp.Use(recover.New())
+	fiberApp.Use(LocaleMiddleware)
+	fiberApp.Use(ClientIdentityMiddleware)
+	accessLog, stopAccessLog := AccessLogMiddleware(cfg.Log)
+	onShutdown(fiberApp, stopAccessLog)
+	fiberApp.Use(accessLog)
+	fiberApp.Use(BinderMiddleware(NewBinder()))
+
+	RegisterRoutes(fiberApp)
+	return fiberApp
+}
+
+// NewAdminApp builds the Fiber application of the admin listener
+func NewAdminApp() *fiber.App {
+	cfg := config.Get()
+
+	adminApp := fiber.New(fiber.Config{
+		AppName:               cfg.Telemetry.ServiceName + " admin",
+		DisableStartupMessage: true,
+		ErrorHandler:          ProblemErrorHandler,
+	})
+	adminApp.Use(recover.New())
+	adminApp.Use(LocaleMiddleware)
+	accessLog, stopAccessLog := AccessLogMiddleware(cfg.Log)
+	onShutdown(adminApp, stopAccessLog)
+	adminApp.Use(accessLog)
+
+	RegisterAdminRoutes(adminApp)
+	return adminApp
+}
+
+// onShutdown calls stop once app is shut down, ending the configuration
+// subscriptions of its middleware
+func onShutdown(app *fiber.App, stop func()) {
+	app.Hooks().OnShutdown(func() error {
+		stop()
+		return nil
+	})
+}
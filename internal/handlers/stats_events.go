@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fizzbuzz-server/internal/config"
+	"fizzbuzz-server/internal/services"
+	"fizzbuzz-server/pkg/ulog"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// statsFeedHistory is the number of events kept for Last-Event-ID resumes
+	statsFeedHistory = 64
+	// statsFeedMinGap bounds how often bursts of changes are evaluated
+	statsFeedMinGap = 100 * time.Millisecond
+	// statsFeedBuffer is the number of events a slow client may lag behind
+	// before it is disconnected, to resume with Last-Event-ID
+	statsFeedBuffer = 16
+	// statsEventsRetry is the reconnection delay advised to clients
+	statsEventsRetry = 3 * time.Second
+)
+
+// statsEvent is one event of the /stats/events stream
+type statsEvent struct {
+	id   uint64
+	data []byte
+}
+
+// statsFeed publishes the most frequent requests to /stats/events clients.
+// It runs while clients are connected, evaluating the top entries when the
+// stats change and publishing them when they differ from the last event,
+// and at least every events interval. Each tenant has its own feed.
+type statsFeed struct {
+	tenant  string
+	mu      sync.Mutex
+	nextID  uint64
+	history []statsEvent
+	leaders string // keys of the last published entries, in order
+	subs    map[chan statsEvent]struct{}
+	stop    context.CancelFunc
+}
+
+// subscribe registers a client that saw the events up to lastID, 0 for none.
+// It returns the events to send first, the channel of the next ones, closed
+// when the client falls too far behind, and a function ending the
+// subscription.
+func (f *statsFeed) subscribe(lastID uint64) ([]statsEvent, <-chan statsEvent, func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.subs == nil {
+		f.subs = map[chan statsEvent]struct{}{}
+	}
+	if len(f.subs) == 0 {
+		// changes made while nobody listened are published now
+		f.evaluate(len(f.history) == 0)
+		ctx, cancel := context.WithCancel(context.Background())
+		f.stop = cancel
+		go f.run(ctx, config.Get().Stats.EventsInterval)
+	}
+
+	ch := make(chan statsEvent, statsFeedBuffer)
+	f.subs[ch] = struct{}{}
+	unsubscribe := func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if _, ok := f.subs[ch]; !ok {
+			return
+		}
+		delete(f.subs, ch)
+		if len(f.subs) == 0 {
+			f.stop()
+		}
+	}
+
+	return f.replay(lastID), ch, unsubscribe
+}
+
+// replay returns the events after lastID, or only the latest one when the
+// client has not seen any event or missed more than the history holds.
+// It must be called with f.mu held.
+func (f *statsFeed) replay(lastID uint64) []statsEvent {
+	n := len(f.history)
+	if n == 0 {
+		return nil
+	}
+	latest := f.history[n-1].id
+	if lastID >= latest {
+		return nil
+	}
+	if lastID == 0 || lastID < f.history[0].id-1 {
+		return []statsEvent{f.history[n-1]}
+	}
+	return append([]statsEvent(nil), f.history[n-int(latest-lastID):]...)
+}
+
+func (f *statsFeed) run(ctx context.Context, interval time.Duration) {
+	changed := make(chan struct{}, 1)
+	unsubscribe := tenantStats(f.tenant).Subscribe(func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+	defer unsubscribe()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			f.mu.Lock()
+			f.evaluate(true)
+			f.mu.Unlock()
+		case <-changed:
+			f.mu.Lock()
+			f.evaluate(false)
+			f.mu.Unlock()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(statsFeedMinGap):
+			}
+		}
+	}
+}
+
+// evaluate publishes the current top entries if they changed, or always when
+// force is set. It must be called with f.mu held.
+func (f *statsFeed) evaluate(force bool) {
+	top, err := tenantStats(f.tenant).Top(config.Get().Stats.EventsTop, 0)
+	if err != nil {
+		ulog.Errorf("stats events: %v", err)
+		return
+	}
+
+	keys := make([]string, len(top))
+	for i, e := range top {
+		keys[i] = services.StatsKey(e.Int1, e.Int2, e.Limit, e.Str1, e.Str2)
+	}
+	leaders := strings.Join(keys, "\n")
+	if !force && leaders == f.leaders {
+		return
+	}
+	f.leaders = leaders
+
+	data, err := json.Marshal(TopStatsResponse{Top: top})
+	if err != nil {
+		ulog.Errorf("stats events: %v", err)
+		return
+	}
+	f.nextID++
+	event := statsEvent{id: f.nextID, data: data}
+	f.history = append(f.history, event)
+	if len(f.history) > statsFeedHistory {
+		f.history = f.history[len(f.history)-statsFeedHistory:]
+	}
+
+	for ch := range f.subs {
+		select {
+		case ch <- event:
+		default:
+			close(ch)
+			delete(f.subs, ch)
+		}
+	}
+	if len(f.subs) == 0 && f.stop != nil {
+		f.stop()
+	}
+}
+
+// StatsEventsHandler streams the most frequent requests as Server-Sent
+// Events. An event is sent when the top entries change and at the configured
+// interval; heartbeat comments keep idle connections open. Clients
+// reconnecting with Last-Event-ID receive the events they missed.
+func StatsEventsHandler() fiber.Handler {
+	var mu sync.Mutex
+	feeds := map[string]*statsFeed{}
+
+	return func(c *fiber.Ctx) error {
+		tenant := Tenant(c)
+		mu.Lock()
+		feed, ok := feeds[tenant]
+		if !ok {
+			feed = &statsFeed{tenant: tenant}
+			feeds[tenant] = feed
+		}
+		mu.Unlock()
+
+		lastID, _ := strconv.ParseUint(c.Get("Last-Event-ID"), 10, 64)
+		heartbeat := config.Get().Stats.EventsHeartbeat
+		// closed when the server shuts down, which would otherwise wait for
+		// the stream to fail on a write
+		shutdown := c.Context().Done()
+
+		c.Set(fiber.HeaderContentType, "text/event-stream")
+		c.Set(fiber.HeaderCacheControl, "no-cache")
+		c.Set(fiber.HeaderConnection, "keep-alive")
+		c.Set("X-Accel-Buffering", "no")
+
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			replay, events, unsubscribe := feed.subscribe(lastID)
+			defer unsubscribe()
+
+			fmt.Fprintf(w, "retry: %d\n\n", statsEventsRetry.Milliseconds())
+			for _, event := range replay {
+				writeStatsEvent(w, event)
+			}
+			if w.Flush() != nil {
+				return
+			}
+
+			ticker := time.NewTicker(heartbeat)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-shutdown:
+					return
+				case event, ok := <-events:
+					if !ok {
+						return
+					}
+					writeStatsEvent(w, event)
+				case <-ticker.C:
+					fmt.Fprint(w, ": heartbeat\n\n")
+				}
+				if w.Flush() != nil {
+					return
+				}
+			}
+		})
+		return nil
+	}
+}
+
+func writeStatsEvent(w *bufio.Writer, event statsEvent) {
+	fmt.Fprintf(w, "id: %d\nevent: top\ndata: %s\n\n", event.id, event.data)
+}
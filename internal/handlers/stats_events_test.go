@@ -0,0 +1,83 @@
+package handlers_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fizzbuzz-server/internal/apps"
+	"fizzbuzz-server/internal/entities"
+	"fizzbuzz-server/internal/handlers"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sseEvent is an event read from a text/event-stream body
+type sseEvent struct {
+	id   string
+	name string
+	data string
+}
+
+func openStatsEvents(t *testing.T, addr, lastEventID string) *bufio.Reader {
+	req, err := http.NewRequest(http.MethodGet, "http://"+addr+"/stats/events", nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	return bufio.NewReader(resp.Body)
+}
+
+// readEvent returns the next event, skipping comments and retry fields
+func readEvent(t *testing.T, r *bufio.Reader) sseEvent {
+	var event sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && event.data != "":
+			return event
+		case strings.HasPrefix(line, "id: "):
+			event.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			event.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func decodeTop(t *testing.T, event sseEvent) []entities.StatsEntry {
+	var response handlers.TopStatsResponse
+	require.NoError(t, json.Unmarshal([]byte(event.data), &response))
+	return response.Top
+}
+
+func TestStatsEvents(t *testing.T) {
+	apps.App().StatsService.Reset()
+	addr := startServer(t)
+
+	events := openStatsEvents(t, addr, "")
+	first := readEvent(t, events)
+	assert.Equal(t, "top", first.name)
+	assert.Empty(t, decodeTop(t, first))
+
+	apps.App().StatsService.Record(entities.FizzBuzzRequest{Int1: 3, Int2: 5, Limit: 15, Str1: "fizz", Str2: "buzz"})
+	second := readEvent(t, events)
+	assert.NotEqual(t, first.id, second.id)
+	top := decodeTop(t, second)
+	require.Len(t, top, 1)
+	assert.Equal(t, 15, top[0].Limit)
+
+	// a client resuming after the first event gets the second one replayed
+	resumed := openStatsEvents(t, addr, first.id)
+	assert.Equal(t, second, readEvent(t, resumed))
+}
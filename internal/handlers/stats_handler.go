@@ -2,45 +2,220 @@ package handlers
 
 import (
 	"fizzbuzz-server/internal/apps"
+	"fizzbuzz-server/internal/apps/contracts"
+	"fizzbuzz-server/internal/config"
+	"fizzbuzz-server/internal/entities"
+	"fizzbuzz-server/internal/services"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// maxTopEntries bounds the n parameter of /stats/top
+const maxTopEntries = 100
+
 func Stats(c *fiber.Ctx) error {
-	entriesCount := len(stats.Counts)
+	window, err := parseWindow(c)
+	if err != nil {
+		return invalidWindow(c)
+	}
+
+	// Find most frequent request
+	top, err := tenantStats(Tenant(c)).Top(1, window)
+	if err != nil {
+		return problem(c, fiber.StatusInternalServerError, "internal_stats_error")
+	}
 
-	if entriesCount == 0 {
+	if len(top) == 0 {
 		return c.JSON(fiber.Map{
 			"message": "No requests have been made yet",
 		})
 	}
 
-	// Find most frequent request with tracing
-	var maxKey string
-	maxCount := 0
-	for key, count := range stats.Counts {
-		if count > maxCount {
-			maxCount = count
-			maxKey = key
+	return c.JSON(StatsResponse{
+		MostFrequentRequest: top[0],
+	})
+}
+
+// StatsTop returns the n most frequent requests, optionally within a window
+func StatsTop(c *fiber.Ctx) error {
+	n := c.QueryInt("n", 10)
+	if n <= 0 || n > maxTopEntries {
+		return problem(c, fiber.StatusBadRequest, "invalid_top_n", strconv.Itoa(maxTopEntries))
+	}
+	window, err := parseWindow(c)
+	if err != nil {
+		return invalidWindow(c)
+	}
+
+	top, err := tenantStats(Tenant(c)).Top(n, window)
+	if err != nil {
+		return problem(c, fiber.StatusInternalServerError, "internal_stats_error")
+	}
+
+	resp := TopStatsResponse{Top: top}
+	if window > 0 {
+		resp.Window = window.String()
+	}
+	return c.JSON(resp)
+}
+
+// The admin stats endpoints act on the counters of every tenant, or on
+// those of one with tenant=<name>
+
+// ResetStats clears every counter
+func ResetStats(c *fiber.Ctx) error {
+	stats, ok := adminStats(c)
+	if !ok {
+		return unknownTenant(c)
+	}
+	stats.Reset()
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ExportStats returns the all-time counters as a snapshot
+func ExportStats(c *fiber.Ctx) error {
+	stats, ok := adminStats(c)
+	if !ok {
+		return unknownTenant(c)
+	}
+	snapshot, err := stats.Export()
+	if err != nil {
+		return problem(c, fiber.StatusInternalServerError, "internal_stats_error")
+	}
+	return c.JSON(snapshot)
+}
+
+// ClientStats returns the requests of every client identified by its TLS
+// certificate, most active first. Without tenant, only the requests made
+// without tenant are counted.
+func ClientStats(c *fiber.Ctx) error {
+	stats, ok := adminStats(c)
+	if !ok {
+		return unknownTenant(c)
+	}
+	return c.JSON(ClientStatsResponse{Clients: stats.Clients()})
+}
+
+// ImportStats loads a snapshot, replacing the counters unless merge=true
+func ImportStats(c *fiber.Ctx) error {
+	stats, ok := adminStats(c)
+	if !ok {
+		return unknownTenant(c)
+	}
+	snapshot := entities.StatsSnapshot{}
+	if err := c.BodyParser(&snapshot); err != nil {
+		return problem(c, fiber.StatusBadRequest, "invalid_stats_snapshot")
+	}
+	if err := stats.Import(snapshot, c.QueryBool("merge")); err != nil {
+		return problem(c, fiber.StatusBadRequest, "rejected_stats_snapshot", err.Error())
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// TenantStats returns the most frequent request of every tenant, configured
+// or having counters
+func TenantStats(c *fiber.Ctx) error {
+	stats := apps.App().StatsService
+	configured := config.Get().Tenancy.Tenants
+	names := stats.Tenants()
+	for name := range configured {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	resp := TenantStatsResponse{Tenants: make([]entities.TenantStats, 0, len(names))}
+	for _, name := range names {
+		top, err := stats.Tenant(name).Top(1, 0)
+		if err != nil {
+			return problem(c, fiber.StatusInternalServerError, "internal_stats_error")
+		}
+		tenant := entities.TenantStats{Tenant: name, Configured: configured[name] != nil}
+		if len(top) > 0 {
+			tenant.MostFrequentRequest = &top[0]
 		}
+		resp.Tenants = append(resp.Tenants, tenant)
 	}
+	return c.JSON(resp)
+}
 
-	// Parse the key back into components with tracing
-	parts, err := apps.App().StatsService.ParseStatsKey(maxKey)
+// AdminStatsTop returns the n most frequent requests like /stats/top, added
+// up across the default namespace and every tenant unless tenant is given
+func AdminStatsTop(c *fiber.Ctx) error {
+	n := c.QueryInt("n", 10)
+	if n <= 0 || n > maxTopEntries {
+		return problem(c, fiber.StatusBadRequest, "invalid_top_n", strconv.Itoa(maxTopEntries))
+	}
+	window, err := parseWindow(c)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
-			Error: "Internal stats error",
-		})
+		return invalidWindow(c)
 	}
 
-	// Build response with tracing
-	resp := StatsResponse{}
-	resp.MostFrequentRequest.Int1 = parts.Int1
-	resp.MostFrequentRequest.Int2 = parts.Int2
-	resp.MostFrequentRequest.Limit = parts.Limit
-	resp.MostFrequentRequest.Str1 = parts.Str1
-	resp.MostFrequentRequest.Str2 = parts.Str2
-	resp.MostFrequentRequest.Hits = maxCount
+	var top []entities.StatsEntry
+	if c.Query("tenant") == "" {
+		top, err = services.TopAcrossTenants(apps.App().StatsService, n, window)
+	} else {
+		stats, ok := adminStats(c)
+		if !ok {
+			return unknownTenant(c)
+		}
+		top, err = stats.Top(n, window)
+	}
+	if err != nil {
+		return problem(c, fiber.StatusInternalServerError, "internal_stats_error")
+	}
 
+	resp := TopStatsResponse{Top: top}
+	if window > 0 {
+		resp.Window = window.String()
+	}
 	return c.JSON(resp)
 }
+
+// adminStats returns the stats named by the tenant query parameter, all of
+// them when it is empty. A tenant must be configured or have counters, false
+// is returned otherwise.
+func adminStats(c *fiber.Ctx) (contracts.StatsServiceIface, bool) {
+	stats := apps.App().StatsService
+	tenant := c.Query("tenant")
+	if tenant == "" {
+		return stats, true
+	}
+	if config.Get().Tenancy.Tenant(tenant) == nil && !slices.Contains(stats.Tenants(), tenant) {
+		return nil, false
+	}
+	// the name may key a new namespace, it must not share fiber's memory
+	return stats.Tenant(strings.Clone(tenant)), true
+}
+
+// unknownTenant answers requests naming a tenant adminStats rejected
+func unknownTenant(c *fiber.Ctx) error {
+	return problem(c, fiber.StatusNotFound, "unknown_tenant", c.Query("tenant"))
+}
+
+// invalidWindow answers requests with a window parseWindow rejected
+func invalidWindow(c *fiber.Ctx) error {
+	return problem(c, fiber.StatusBadRequest, "invalid_window", config.Get().Stats.WindowRetention.String())
+}
+
+// parseWindow reads the optional window query parameter, e.g. window=15m
+func parseWindow(c *fiber.Ctx) (time.Duration, error) {
+	raw := c.Query("window")
+	if raw == "" {
+		return 0, nil
+	}
+	window, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, err
+	}
+	if retention := config.Get().Stats.WindowRetention; window <= 0 || window > retention {
+		return 0, fmt.Errorf("must be between 1m and %s", retention)
+	}
+	return window, nil
+}
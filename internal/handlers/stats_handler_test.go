@@ -3,16 +3,20 @@ package handlers_test
 import (
 	"encoding/json"
 	"fizzbuzz-server/internal/apps"
+	"fizzbuzz-server/internal/entities"
 	"fizzbuzz-server/internal/handlers"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func TestStatsHandler_NoRequests(t *testing.T) {
+	apps.App().StatsService.Reset()
+
 	// Create a test request
 	req := httptest.NewRequest(http.MethodGet, "/stats", nil)
 	req.Header.Set("Content-Type", "application/json")
@@ -35,6 +39,8 @@ func TestStatsHandler_NoRequests(t *testing.T) {
 }
 
 func TestStatsHandler_WithRequests(t *testing.T) {
+	apps.App().StatsService.Reset()
+
 	// Make several FizzBuzz requests with the same parameters
 	for i := 0; i < 3; i++ {
 		req := httptest.NewRequest(http.MethodGet, "/fizzbuzz?int1=3&int2=5&limit=15&str1=fizz&str2=buzz", nil)
@@ -74,6 +80,8 @@ func TestStatsHandler_WithRequests(t *testing.T) {
 }
 
 func TestStatsHandler_MultipleTopRequests(t *testing.T) {
+	apps.App().StatsService.Reset()
+
 	// Make several FizzBuzz requests with different parameters, same number of times
 	for i := 0; i < 2; i++ {
 		// First set of parameters
@@ -123,3 +131,70 @@ func TestStatsHandler_MultipleTopRequests(t *testing.T) {
 
 	assert.True(t, isFirstSet || isSecondSet, "The most frequent request should match one of our test sets")
 }
+
+func TestStatsTopHandler(t *testing.T) {
+	apps.App().StatsService.Reset()
+
+	for _, query := range []string{
+		"int1=3&int2=5&limit=15", "int1=3&int2=5&limit=15", "int1=2&int2=7&limit=10",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/fizzbuzz?"+query, nil)
+		resp, _ := apps.App().FiberApp.Test(req)
+		resp.Body.Close()
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/stats/top?n=5&window=5m", nil)
+	resp, err := apps.App().FiberApp.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	var response handlers.TopStatsResponse
+	err = json.Unmarshal(body, &response)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "5m0s", response.Window)
+	assert.Len(t, response.Top, 2)
+	assert.Equal(t, 15, response.Top[0].Limit)
+	assert.Equal(t, 2, response.Top[0].Hits)
+	assert.Equal(t, 1, response.Top[1].Hits)
+}
+
+func TestStatsTopHandler_InvalidWindow(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/stats/top?window=48h", nil)
+	resp, err := apps.App().FiberApp.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestStatsExportImport(t *testing.T) {
+	apps.App().StatsService.Reset()
+
+	snapshot := `{"entries":[{"int1":3,"int2":5,"limit":15,"str1":"fizz","str2":"buzz","hits":7}]}`
+	req := asAdmin(httptest.NewRequest(http.MethodPost, "/admin/stats/import", strings.NewReader(snapshot)))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := apps.App().FiberApp.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	req = asAdmin(httptest.NewRequest(http.MethodGet, "/admin/stats/export", nil))
+	resp, err = apps.App().FiberApp.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	var exported entities.StatsSnapshot
+	err = json.Unmarshal(body, &exported)
+	assert.NoError(t, err)
+	assert.Len(t, exported.Entries, 1)
+	assert.Equal(t, 7, exported.Entries[0].Hits)
+
+	req = asAdmin(httptest.NewRequest(http.MethodPost, "/admin/stats/reset", nil))
+	resp, err = apps.App().FiberApp.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+}
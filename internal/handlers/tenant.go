@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"fizzbuzz-server/internal/apps"
+	"fizzbuzz-server/internal/apps/contracts"
+	"fizzbuzz-server/internal/binding"
+	"fizzbuzz-server/internal/config"
+	"fizzbuzz-server/internal/validation"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// tenantLocal is the key of the tenant in the request locals
+const tenantLocal = "tenant"
+
+// TenantMiddleware resolves the tenant of the request: the tenant of
+// /tenants/<name> routes, else the one named by the tenant header, else the
+// owner of the X-API-Key. A tenant that is not configured is rejected with
+// 404, and requests of a tenant with API keys must present one of them.
+// Requests without tenant use the default namespace.
+func TenantMiddleware(c *fiber.Ctx) error {
+	tenancy := config.Get().Tenancy
+	provided := c.Get("X-API-Key")
+
+	name := c.Params("tenant")
+	if name == "" && tenancy.Header != "" {
+		name = c.Get(tenancy.Header)
+	}
+	if name == "" && provided != "" {
+		name = tenancy.Owner(provided)
+	}
+	if name == "" {
+		return c.Next()
+	}
+
+	tenant := tenancy.Tenant(name)
+	if tenant == nil {
+		return problem(c, fiber.StatusNotFound, "unknown_tenant", name)
+	}
+	if len(tenant.APIKeys) > 0 && !slices.ContainsFunc(tenant.APIKeys, func(key string) bool {
+		return secureCompare(provided, key)
+	}) {
+		return problem(c, fiber.StatusUnauthorized, "invalid_api_key")
+	}
+	// fiber reuses the memory of request values, the name outlives it in
+	// the stats and in WebSocket sessions
+	c.Locals(tenantLocal, strings.Clone(name))
+	return c.Next()
+}
+
+// Tenant returns the tenant of the request, empty for the default namespace
+func Tenant(c *fiber.Ctx) string {
+	tenant, _ := c.Locals(tenantLocal).(string)
+	return tenant
+}
+
+// tenantStats returns the stats of tenant, the default ones when empty
+func tenantStats(tenant string) contracts.StatsServiceIface {
+	stats := apps.App().StatsService
+	if tenant == "" {
+		return stats
+	}
+	return stats.Tenant(tenant)
+}
+
+// tenantContext returns ctx with the limit cap and the default words of
+// tenant, for binding requests
+func tenantContext(ctx context.Context, tenant string) context.Context {
+	t := config.Get().Tenancy.Tenant(tenant)
+	if t == nil {
+		return ctx
+	}
+	if t.MaxLimit > 0 {
+		ctx = validation.WithMaxLimit(ctx, t.MaxLimit)
+	}
+	return binding.WithDefaults(ctx, map[string]string{"str1": t.Str1, "str2": t.Str2})
+}
@@ -0,0 +1,179 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fizzbuzz-server/internal/apps"
+	"fizzbuzz-server/internal/entities"
+	"fizzbuzz-server/internal/handlers"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const tenantsConfig = `
+auth:
+  api_keys: [global-key]
+tenancy:
+  tenants:
+    acme:
+      api_keys: [acme-key]
+      max_limit: 20
+      str1: foo
+      str2: bar
+    globex: {}
+`
+
+// setTenants configures the acme and globex tenants for the test
+func setTenants(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(tenantsConfig), 0o600))
+	setConfigEnv(t, map[string]string{"CONFIG_FILE": path})
+	apps.App().StatsService.Reset()
+	t.Cleanup(apps.App().StatsService.Reset)
+}
+
+// tenantRequest performs a GET with the given headers and returns the status
+// and body
+func tenantRequest(t *testing.T, target string, headers map[string]string) (int, string) {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := apps.App().FiberApp.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(body)
+}
+
+func TestTenant_Identification(t *testing.T) {
+	setTenants(t)
+	acme := map[string]string{"X-API-Key": "acme-key"}
+
+	tests := []struct {
+		name    string
+		target  string
+		headers map[string]string
+		status  int
+	}{
+		{"path prefix", "/tenants/acme/fizzbuzz?int1=3&int2=5&limit=15", acme, http.StatusOK},
+		{"header", "/fizzbuzz?int1=3&int2=5&limit=15", map[string]string{"X-Tenant": "acme", "X-API-Key": "acme-key"}, http.StatusOK},
+		{"api key", "/fizzbuzz?int1=3&int2=5&limit=15", acme, http.StatusOK},
+		{"unknown tenant", "/tenants/initech/fizzbuzz?int1=3&int2=5&limit=15", acme, http.StatusNotFound},
+		{"key of another tenant", "/tenants/acme/fizzbuzz?int1=3&int2=5&limit=15", map[string]string{"X-API-Key": "global-key"}, http.StatusUnauthorized},
+		{"tenant without keys", "/tenants/globex/fizzbuzz?int1=3&int2=5&limit=15", map[string]string{"X-API-Key": "global-key"}, http.StatusOK},
+		{"tenant without keys and no key", "/tenants/globex/fizzbuzz?int1=3&int2=5&limit=15", nil, http.StatusUnauthorized},
+		{"no key", "/fizzbuzz?int1=3&int2=5&limit=15", nil, http.StatusUnauthorized},
+		{"global key", "/fizzbuzz?int1=3&int2=5&limit=15", map[string]string{"X-API-Key": "global-key"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := tenantRequest(t, tt.target, tt.headers)
+			assert.Equal(t, tt.status, status, body)
+		})
+	}
+}
+
+func TestTenant_DefaultsAndCap(t *testing.T) {
+	setTenants(t)
+	acme := map[string]string{"X-API-Key": "acme-key"}
+
+	status, body := tenantRequest(t, "/tenants/acme/fizzbuzz?int1=3&int2=5&limit=15", acme)
+	require.Equal(t, http.StatusOK, status, body)
+	var resp entities.FizzBuzzResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &resp))
+	assert.Equal(t, "foobar", resp.Result[14])
+
+	// the cap of the tenant applies, the global one elsewhere
+	status, body = tenantRequest(t, "/tenants/acme/fizzbuzz?int1=3&int2=5&limit=21", acme)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, body, "maxlimit")
+	status, _ = tenantRequest(t, "/tenants/globex/fizzbuzz?int1=3&int2=5&limit=21", map[string]string{"X-API-Key": "global-key"})
+	assert.Equal(t, http.StatusOK, status)
+
+	// and to the other APIs
+	query := url.Values{"query": {"{ fizzbuzz(int1: 3, int2: 5, limit: 15, offset: 14) }"}}
+	status, body = tenantRequest(t, "/tenants/acme/graphql?"+query.Encode(), acme)
+	require.Equal(t, http.StatusOK, status, body)
+	assert.Contains(t, body, `"fizzbuzz":["foobar"]`)
+	query.Set("query", "{ fizzbuzz(int1: 3, int2: 5, limit: 21) }")
+	_, body = tenantRequest(t, "/tenants/acme/graphql?"+query.Encode(), acme)
+	assert.Contains(t, body, `"code":"maxlimit"`)
+}
+
+func TestTenant_IsolatedStats(t *testing.T) {
+	setTenants(t)
+	acme := map[string]string{"X-API-Key": "acme-key"}
+	global := map[string]string{"X-API-Key": "global-key"}
+
+	for range 3 {
+		tenantRequest(t, "/tenants/acme/fizzbuzz?int1=3&int2=5&limit=15", acme)
+	}
+	for range 2 {
+		tenantRequest(t, "/tenants/globex/fizzbuzz?int1=2&int2=7&limit=10", global)
+	}
+	tenantRequest(t, "/fizzbuzz?int1=2&int2=7&limit=10", global)
+
+	mostFrequent := func(target string, headers map[string]string) entities.StatsEntry {
+		status, body := tenantRequest(t, target, headers)
+		require.Equal(t, http.StatusOK, status, body)
+		var resp handlers.StatsResponse
+		require.NoError(t, json.Unmarshal([]byte(body), &resp))
+		return resp.MostFrequentRequest
+	}
+	assert.Equal(t, entities.StatsEntry{Int1: 3, Int2: 5, Limit: 15, Str1: "foo", Str2: "bar", Hits: 3}, mostFrequent("/tenants/acme/stats", acme))
+	assert.Equal(t, 3, mostFrequent("/stats", acme).Hits)
+	assert.Equal(t, 2, mostFrequent("/tenants/globex/stats", global).Hits)
+	assert.Equal(t, 1, mostFrequent("/stats", global).Hits)
+
+	// the admin view adds up every tenant
+	status, body := tenantRequest(t, "/admin/stats/top", adminHeaders)
+	require.Equal(t, http.StatusOK, status, body)
+	var top handlers.TopStatsResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &top))
+	assert.Equal(t, []entities.StatsEntry{
+		{Int1: 2, Int2: 7, Limit: 10, Str1: "fizz", Str2: "buzz", Hits: 3},
+		{Int1: 3, Int2: 5, Limit: 15, Str1: "foo", Str2: "bar", Hits: 3},
+	}, top.Top)
+
+	status, body = tenantRequest(t, "/admin/stats/top?tenant=globex", adminHeaders)
+	require.Equal(t, http.StatusOK, status, body)
+	require.NoError(t, json.Unmarshal([]byte(body), &top))
+	assert.Equal(t, 2, top.Top[0].Hits)
+	status, _ = tenantRequest(t, "/admin/stats/top?tenant=initech", adminHeaders)
+	assert.Equal(t, http.StatusNotFound, status)
+
+	status, body = tenantRequest(t, "/admin/stats/tenants", adminHeaders)
+	require.Equal(t, http.StatusOK, status, body)
+	var tenants handlers.TenantStatsResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &tenants))
+	require.Len(t, tenants.Tenants, 2)
+	assert.Equal(t, "acme", tenants.Tenants[0].Tenant)
+	assert.True(t, tenants.Tenants[0].Configured)
+	assert.Equal(t, 3, tenants.Tenants[0].MostFrequentRequest.Hits)
+
+	// a snapshot carries every tenant, reset clears one or all of them
+	status, body = tenantRequest(t, "/admin/stats/export", adminHeaders)
+	require.Equal(t, http.StatusOK, status, body)
+	var snapshot entities.StatsSnapshot
+	require.NoError(t, json.Unmarshal([]byte(body), &snapshot))
+	assert.Len(t, snapshot.Entries, 1)
+	assert.Equal(t, 3, snapshot.Tenants["acme"].Entries[0].Hits)
+
+	req := asAdmin(httptest.NewRequest(http.MethodPost, "/admin/stats/reset?tenant=acme", nil))
+	resp, err := apps.App().FiberApp.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	status, body = tenantRequest(t, "/tenants/acme/stats", acme)
+	assert.True(t, strings.Contains(body, "No requests"), "status %d: %s", status, body)
+	assert.Equal(t, 2, mostFrequent("/tenants/globex/stats", global).Hits)
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fizzbuzz-server/internal/config"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// errClientGone cancels the requests whose client closed the connection
+var errClientGone = errors.New("client closed the connection")
+
+// TimeoutMiddleware gives the requests of a route the deadline selected by
+// timeout from the current configuration. The user context of the request
+// is cancelled once the deadline is exceeded or the client disconnects, so
+// that generation stops; see cancelledProblem for the responses.
+func TimeoutMiddleware(timeout func(config.TimeoutConfig) time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithCancelCause(c.UserContext())
+		defer cancel(nil)
+		if d := timeout(config.Get().Timeout); d > 0 {
+			var cancelTimeout context.CancelFunc
+			ctx, cancelTimeout = context.WithTimeout(ctx, d)
+			defer cancelTimeout()
+		}
+
+		stop := watchDisconnect(c.Context().Conn(), func() { cancel(errClientGone) })
+		defer stop()
+
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
+
+// cancelledProblem answers a request interrupted by its context: 504 when
+// its deadline was exceeded, 503 when it was cancelled otherwise, e.g. by
+// the client disconnecting
+func cancelledProblem(c *fiber.Ctx, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return problem(c, fiber.StatusGatewayTimeout, "deadline_exceeded")
+	}
+	return problem(c, fiber.StatusServiceUnavailable, "request_cancelled")
+}
+
+// disconnectPollInterval is how often a watched connection is peeked
+const disconnectPollInterval = 50 * time.Millisecond
+
+// watchDisconnect calls cancel if the client closes conn while the request
+// is handled. The connection is peeked without consuming the next pipelined
+// request, nor touching its deadlines which belong to fasthttp; watching ends
+// at the first byte received. stop ends the watch and must be called before
+// the handler returns. Connections that cannot be peeked, e.g. TLS ones, are
+// not watched.
+func watchDisconnect(conn net.Conn, cancel func()) (stop func()) {
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return func() {}
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return func() {}
+	}
+
+	stopped := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(disconnectPollInterval)
+		defer ticker.Stop()
+		buf := make([]byte, 1)
+		for {
+			select {
+			case <-stopped:
+				return
+			case <-ticker.C:
+			}
+
+			var n int
+			var peekErr error
+			if err := raw.Control(func(fd uintptr) {
+				n, _, peekErr = syscall.Recvfrom(int(fd), buf, syscall.MSG_PEEK|syscall.MSG_DONTWAIT)
+			}); err != nil {
+				return
+			}
+			if peekErr == syscall.EAGAIN || peekErr == syscall.EINTR {
+				continue // nothing received yet
+			}
+			if n == 0 || peekErr != nil {
+				cancel()
+			}
+			return
+		}
+	}()
+
+	return func() {
+		close(stopped)
+		<-done
+	}
+}
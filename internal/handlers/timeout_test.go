@@ -0,0 +1,124 @@
+package handlers_test
+
+import (
+	"context"
+	"fizzbuzz-server/internal/apps"
+	"fizzbuzz-server/internal/config"
+	"fizzbuzz-server/internal/handlers"
+	"fizzbuzz-server/mocks"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingFizzBuzz replaces the FizzBuzz service with one generating until
+// its context is done, the error of the context being sent on the returned
+// channel
+func blockingFizzBuzz(t *testing.T) <-chan error {
+	cancelled := make(chan error, 1)
+	service := mocks.NewFizzBuzzServiceIface(t)
+	service.On("GenerateFizzBuzzContext", mock.Anything, 3, 5, 15, "fizz", "buzz").
+		Return(func(ctx context.Context, _, _, _ int, _, _ string) ([]string, error) {
+			<-ctx.Done()
+			cancelled <- ctx.Err()
+			return nil, ctx.Err()
+		})
+	previous := apps.App().FizzBuzzService
+	apps.App().FizzBuzzService = service
+	t.Cleanup(func() { apps.App().FizzBuzzService = previous })
+	return cancelled
+}
+
+func TestTimeout_DeadlineExceeded(t *testing.T) {
+	setConfigEnv(t, map[string]string{"TIMEOUT_FIZZBUZZ": "20ms"})
+	cancelled := blockingFizzBuzz(t)
+
+	problem := problemRequest(t, "/fizzbuzz?int1=3&int2=5&limit=15")
+	assert.Equal(t, handlers.ErrorResponse{
+		Type:   "urn:fizzbuzz:problem:timeout",
+		Title:  "Gateway Timeout",
+		Status: http.StatusGatewayTimeout,
+		Detail: "The request did not complete in time",
+	}, problem)
+	assert.ErrorIs(t, <-cancelled, context.DeadlineExceeded)
+}
+
+func TestTimeout_ClientDisconnects(t *testing.T) {
+	setConfigEnv(t, map[string]string{"TIMEOUT_FIZZBUZZ": "0"})
+	cancelled := blockingFizzBuzz(t)
+
+	conn, err := net.Dial("tcp", startServer(t))
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("GET /fizzbuzz?int1=3&int2=5&limit=15 HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	require.NoError(t, err)
+	// let the request reach generation before going away
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, conn.Close())
+
+	select {
+	case err := <-cancelled:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(2 * time.Second):
+		t.Fatal("generation was not cancelled when the client disconnected")
+	}
+}
+
+func TestTimeout_KeepAlive(t *testing.T) {
+	// the watch of a request leaves the connection usable for the next one
+	base := "http://" + startServer(t)
+	client := &http.Client{Transport: &http.Transport{MaxIdleConnsPerHost: 1}}
+	t.Cleanup(client.CloseIdleConnections)
+	for range 3 {
+		resp, err := client.Get(base + "/fizzbuzz?int1=3&int2=5&limit=15")
+		require.NoError(t, err)
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+}
+
+func TestTimeout_KeepsReadDeadline(t *testing.T) {
+	// the deadline of the connection, set by fasthttp when read and idle
+	// timeouts are configured, is still in force once the request is handled
+	readErr := make(chan error, 1)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		conn := c.Context().Conn()
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(100*time.Millisecond)))
+		err := c.Next()
+		_, deadlineErr := conn.Read(make([]byte, 1))
+		readErr <- deadlineErr
+		return err
+	})
+	app.Use(handlers.TimeoutMiddleware(func(config.TimeoutConfig) time.Duration { return 0 }))
+	app.Get("/", func(c *fiber.Ctx) error {
+		time.Sleep(200 * time.Millisecond) // outlives a few watch rounds
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = app.Listener(ln) }()
+	t.Cleanup(func() { _ = app.ShutdownWithTimeout(shutdownTimeout) })
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	require.NoError(t, err)
+
+	select {
+	case err := <-readErr:
+		assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+	case <-time.After(2 * time.Second):
+		t.Fatal("the read deadline of the connection was cleared")
+	}
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"fizzbuzz-server/internal/binding"
+	"fizzbuzz-server/internal/config"
+	"fizzbuzz-server/internal/validation"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+// binderLocal is the key of the shared binder in the request locals
+const binderLocal = "binder"
+
+// NewValidator returns the validator shared by every handler, the limits
+// following the current configuration
+func NewValidator() *validator.Validate {
+	return validation.New(func() validation.Limits {
+		cfg := config.Get().FizzBuzz
+		return validation.Limits{
+			MaxLimit:       cfg.MaxLimit,
+			MaxWordLength:  cfg.MaxWordLength,
+			WordCharacters: cfg.WordCharacters,
+		}
+	})
+}
+
+// NewBinder returns the binder shared by every handler, validating with
+// NewValidator
+func NewBinder() *binding.Binder {
+	return binding.New(NewValidator())
+}
+
+// requestBinder returns the binder set by BinderMiddleware
+func requestBinder(c *fiber.Ctx) *binding.Binder {
+	return c.Locals(binderLocal).(*binding.Binder)
+}
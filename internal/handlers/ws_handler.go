@@ -0,0 +1,347 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fizzbuzz-server/internal/apps"
+	"fizzbuzz-server/internal/binding"
+	"fizzbuzz-server/internal/config"
+	"fizzbuzz-server/internal/entities"
+	"fizzbuzz-server/internal/i18n"
+	"fizzbuzz-server/internal/output"
+	"sync"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Message types of the /ws protocol
+const (
+	// client to server
+	WSGenerate    = "generate"    // start a stream: id, params
+	WSAck         = "ack"         // allow one more chunk of stream id
+	WSCancel      = "cancel"      // stop stream id
+	WSSubscribe   = "subscribe"   // start receiving topic updates
+	WSUnsubscribe = "unsubscribe" // stop receiving topic updates
+
+	// server to client
+	WSChunk     = "chunk"     // values of stream id, seq counts from 1
+	WSDone      = "done"      // stream id is complete, count values were sent
+	WSCancelled = "cancelled" // stream id was stopped by a cancel
+	WSError     = "error"     // request id, or the message when id is empty, failed
+	WSStats     = "stats"     // update of the stats topic
+)
+
+// WSTopicStats is the topic of live stats updates
+const WSTopicStats = "stats"
+
+const (
+	wsMaxMessageSize = 64 * 1024
+	wsWriteTimeout   = 10 * time.Second
+)
+
+// WSMessage is a message of the /ws protocol, in either direction
+type WSMessage struct {
+	Type   string                    `json:"type"`
+	ID     string                    `json:"id,omitempty"`
+	Topic  string                    `json:"topic,omitempty"`
+	Params *entities.FizzBuzzRequest `json:"params,omitempty"`
+	Seq    int                       `json:"seq,omitempty"`
+	Values []string                  `json:"values,omitempty"`
+	Count  int                       `json:"count,omitempty"`
+	Error  string                    `json:"error,omitempty"`
+	Stats  *StatsResponse            `json:"stats,omitempty"`
+}
+
+// WebSocketUpgrade rejects requests to /ws that are not WebSocket upgrades
+func WebSocketUpgrade(c *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(c) {
+		return problem(c, fiber.StatusUpgradeRequired, "websocket_upgrade_required")
+	}
+	return c.Next()
+}
+
+// WebSocketHandler serves interactive FizzBuzz sessions. A client runs
+// several streams at once, each identified by the id it chose: values are
+// sent in chunks, at most a window of chunks ahead of the client acks, until
+// the sequence is done or cancelled. Each stream is counted in the stats
+// like a GET /fizzbuzz.
+func WebSocketHandler() fiber.Handler {
+	return websocket.New(func(conn *websocket.Conn) {
+		conn.SetReadLimit(wsMaxMessageSize)
+
+		ctx, cancel := context.WithCancel(wsContext(conn))
+		s := &wsSession{
+			conn:    conn,
+			cfg:     config.Get().WebSocket,
+			binder:  conn.Locals(binderLocal).(*binding.Binder),
+			client:  wsClient(conn),
+			tenant:  wsTenant(conn),
+			ctx:     ctx,
+			streams: map[string]*wsStream{},
+		}
+		defer func() {
+			cancel()
+			s.wg.Wait()
+		}()
+
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var msg WSMessage
+			if err := json.Unmarshal(data, &msg); err != nil {
+				s.send(WSMessage{Type: WSError, Error: i18n.FromContext(s.ctx).T("invalid_message", err.Error())})
+				continue
+			}
+			s.handle(msg)
+		}
+	})
+}
+
+// wsClient returns the identity of the client that opened conn
+func wsClient(conn *websocket.Conn) string {
+	client, _ := conn.Locals(clientLocal).(string)
+	return client
+}
+
+// wsTenant returns the tenant of the request that opened conn
+func wsTenant(conn *websocket.Conn) string {
+	tenant, _ := conn.Locals(tenantLocal).(string)
+	return tenant
+}
+
+// wsContext returns the context of a connection, translating its messages
+// into the language of the request that opened it
+func wsContext(conn *websocket.Conn) context.Context {
+	t, _ := conn.Locals(translatorLocal).(i18n.Translator)
+	return i18n.WithTranslator(context.Background(), t)
+}
+
+// wsSession is the state of one connection
+type wsSession struct {
+	conn   *websocket.Conn
+	cfg    config.WebSocketConfig
+	binder *binding.Binder
+	client string          // identity of the client, empty when anonymous
+	tenant string          // tenant of the session, empty for none
+	ctx    context.Context // cancelled when the connection closes
+	wg     sync.WaitGroup
+
+	writeMu sync.Mutex
+
+	mu        sync.Mutex // guards streams and stopStats
+	streams   map[string]*wsStream
+	stopStats context.CancelFunc
+}
+
+// wsStream is a running generate request
+type wsStream struct {
+	cancel  context.CancelFunc
+	credits chan struct{} // one token per chunk the client is ready to receive
+}
+
+func (s *wsSession) handle(msg WSMessage) {
+	switch msg.Type {
+	case WSGenerate:
+		s.generate(msg)
+	case WSAck, WSCancel:
+		s.mu.Lock()
+		stream, ok := s.streams[msg.ID]
+		s.mu.Unlock()
+		if !ok {
+			s.send(WSMessage{Type: WSError, ID: msg.ID, Error: i18n.FromContext(s.ctx).T("unknown_stream")})
+			return
+		}
+		if msg.Type == WSCancel {
+			stream.cancel()
+			return
+		}
+		select {
+		case stream.credits <- struct{}{}:
+		default: // acks beyond the window are ignored
+		}
+	case WSSubscribe, WSUnsubscribe:
+		if msg.Topic != WSTopicStats {
+			s.send(WSMessage{Type: WSError, Topic: msg.Topic, Error: i18n.FromContext(s.ctx).T("unknown_topic")})
+			return
+		}
+		if msg.Type == WSSubscribe {
+			s.subscribeStats()
+		} else {
+			s.unsubscribeStats()
+		}
+	default:
+		s.send(WSMessage{Type: WSError, ID: msg.ID, Error: i18n.FromContext(s.ctx).T("unknown_message_type", msg.Type)})
+	}
+}
+
+func (s *wsSession) generate(msg WSMessage) {
+	if msg.ID == "" || msg.Params == nil {
+		s.send(WSMessage{Type: WSError, ID: msg.ID, Error: i18n.FromContext(s.ctx).T("generate_params_required")})
+		return
+	}
+	req := *msg.Params
+	if err := s.binder.Complete(tenantContext(s.ctx, s.tenant), &req); err != nil {
+		s.send(WSMessage{Type: WSError, ID: msg.ID, Error: err.Error()})
+		return
+	}
+	if tooLarge := checkResponseSize("ws", output.FormatJSON, req); tooLarge != nil {
+		s.send(WSMessage{Type: WSError, ID: msg.ID, Error: tooLarge.Message(i18n.FromContext(s.ctx))})
+		return
+	}
+
+	s.mu.Lock()
+	if _, ok := s.streams[msg.ID]; ok {
+		s.mu.Unlock()
+		s.send(WSMessage{Type: WSError, ID: msg.ID, Error: i18n.FromContext(s.ctx).T("stream_id_in_use")})
+		return
+	}
+	if len(s.streams) >= s.cfg.MaxStreams {
+		s.mu.Unlock()
+		s.send(WSMessage{Type: WSError, ID: msg.ID, Error: i18n.FromContext(s.ctx).T("too_many_streams")})
+		return
+	}
+	ctx, cancel := context.WithCancel(s.ctx)
+	stream := &wsStream{cancel: cancel, credits: make(chan struct{}, s.cfg.Window)}
+	for range s.cfg.Window {
+		stream.credits <- struct{}{}
+	}
+	s.streams[msg.ID] = stream
+	s.mu.Unlock()
+
+	updateStats(s.tenant, s.client, req)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			cancel()
+			s.mu.Lock()
+			delete(s.streams, msg.ID)
+			s.mu.Unlock()
+		}()
+		s.stream(ctx, msg.ID, stream, req)
+	}()
+}
+
+// stream sends the sequence of req in chunks, waiting for a credit before
+// each of them
+func (s *wsSession) stream(ctx context.Context, id string, stream *wsStream, req entities.FizzBuzzRequest) {
+	seq, count := 0, 0
+	chunk := make([]string, 0, s.cfg.ChunkSize)
+	flush := func() bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-stream.credits:
+		}
+		seq++
+		count += len(chunk)
+		if !s.send(WSMessage{Type: WSChunk, ID: id, Seq: seq, Values: chunk}) {
+			return false
+		}
+		chunk = make([]string, 0, s.cfg.ChunkSize)
+		return true
+	}
+
+	values := apps.App().FizzBuzzService.SequenceContext(ctx, req.Int1, req.Int2, req.Limit, req.Str1, req.Str2)
+	ok := true
+	for value := range values {
+		chunk = append(chunk, value)
+		if len(chunk) == s.cfg.ChunkSize {
+			if ok = flush(); !ok {
+				break
+			}
+		}
+	}
+	if ok && len(chunk) > 0 {
+		flush()
+	}
+
+	switch {
+	case s.ctx.Err() != nil:
+		// connection closed, nobody to tell
+	case ctx.Err() != nil:
+		s.send(WSMessage{Type: WSCancelled, ID: id, Count: count})
+	default:
+		s.send(WSMessage{Type: WSDone, ID: id, Count: count})
+	}
+}
+
+// subscribeStats sends the most frequent request now and after each change
+// of the stats, at most once per stats interval
+func (s *wsSession) subscribeStats() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopStats != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(s.ctx)
+	s.stopStats = cancel
+
+	changed := make(chan struct{}, 1)
+	unsubscribe := tenantStats(s.tenant).Subscribe(func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer unsubscribe()
+
+		ticker := time.NewTicker(s.cfg.StatsInterval)
+		defer ticker.Stop()
+		for {
+			if !s.sendStats() {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-changed:
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *wsSession) unsubscribeStats() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopStats != nil {
+		s.stopStats()
+		s.stopStats = nil
+	}
+}
+
+func (s *wsSession) sendStats() bool {
+	top, err := tenantStats(s.tenant).Top(1, 0)
+	if err != nil {
+		return s.send(WSMessage{Type: WSError, Topic: WSTopicStats, Error: i18n.FromContext(s.ctx).T("internal_stats_error")})
+	}
+	stats := &StatsResponse{}
+	if len(top) > 0 {
+		stats.MostFrequentRequest = top[0]
+	}
+	return s.send(WSMessage{Type: WSStats, Topic: WSTopicStats, Stats: stats})
+}
+
+// send writes msg, reporting false when the connection is no longer usable
+func (s *wsSession) send(msg WSMessage) bool {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if err := s.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout)); err != nil {
+		return false
+	}
+	return s.conn.WriteJSON(msg) == nil
+}
@@ -0,0 +1,158 @@
+package handlers_test
+
+import (
+	"fizzbuzz-server/internal/apps"
+	"fizzbuzz-server/internal/entities"
+	"fizzbuzz-server/internal/handlers"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dialWebSocket starts a server and connects to its /ws
+func dialWebSocket(t *testing.T) *websocket.Conn {
+	conn, _, err := websocket.DefaultDialer.Dial("ws://"+startServer(t)+"/ws", nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	return conn
+}
+
+// setChunking makes streams send size values per chunk with one chunk in flight
+func setChunking(t *testing.T, size string) {
+	setConfigEnv(t, map[string]string{"WS_CHUNK_SIZE": size, "WS_WINDOW": "1"})
+}
+
+func readMessage(t *testing.T, conn *websocket.Conn) handlers.WSMessage {
+	var msg handlers.WSMessage
+	require.NoError(t, conn.ReadJSON(&msg))
+	return msg
+}
+
+func TestWebSocket_Generate(t *testing.T) {
+	setChunking(t, "5")
+	conn := dialWebSocket(t)
+
+	err := conn.WriteJSON(handlers.WSMessage{
+		Type:   handlers.WSGenerate,
+		ID:     "a",
+		Params: &entities.FizzBuzzRequest{Int1: 3, Int2: 5, Limit: 12},
+	})
+	require.NoError(t, err)
+
+	chunk := readMessage(t, conn)
+	assert.Equal(t, handlers.WSChunk, chunk.Type)
+	assert.Equal(t, 1, chunk.Seq)
+	assert.Equal(t, []string{"1", "2", "fizz", "4", "buzz"}, chunk.Values)
+
+	require.NoError(t, conn.WriteJSON(handlers.WSMessage{Type: handlers.WSAck, ID: "a"}))
+	chunk = readMessage(t, conn)
+	assert.Equal(t, 2, chunk.Seq)
+	assert.Equal(t, []string{"fizz", "7", "8", "fizz", "buzz"}, chunk.Values)
+
+	require.NoError(t, conn.WriteJSON(handlers.WSMessage{Type: handlers.WSAck, ID: "a"}))
+	chunk = readMessage(t, conn)
+	assert.Equal(t, 3, chunk.Seq)
+	assert.Equal(t, []string{"11", "fizz"}, chunk.Values)
+
+	done := readMessage(t, conn)
+	assert.Equal(t, handlers.WSDone, done.Type)
+	assert.Equal(t, "a", done.ID)
+	assert.Equal(t, 12, done.Count)
+}
+
+func TestWebSocket_Cancel(t *testing.T) {
+	setChunking(t, "5")
+	conn := dialWebSocket(t)
+
+	err := conn.WriteJSON(handlers.WSMessage{
+		Type:   handlers.WSGenerate,
+		ID:     "a",
+		Params: &entities.FizzBuzzRequest{Int1: 3, Int2: 5, Limit: 1000},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, handlers.WSChunk, readMessage(t, conn).Type)
+
+	require.NoError(t, conn.WriteJSON(handlers.WSMessage{Type: handlers.WSCancel, ID: "a"}))
+	cancelled := readMessage(t, conn)
+	assert.Equal(t, handlers.WSCancelled, cancelled.Type)
+	assert.Equal(t, 5, cancelled.Count)
+}
+
+func TestWebSocket_InvalidParams(t *testing.T) {
+	conn := dialWebSocket(t)
+
+	err := conn.WriteJSON(handlers.WSMessage{
+		Type:   handlers.WSGenerate,
+		ID:     "a",
+		Params: &entities.FizzBuzzRequest{Int1: 3, Int2: 5},
+	})
+	require.NoError(t, err)
+
+	msg := readMessage(t, conn)
+	assert.Equal(t, handlers.WSError, msg.Type)
+	assert.Equal(t, "a", msg.ID)
+	assert.Equal(t, "limit is required", msg.Error)
+}
+
+func TestWebSocket_TranslatedErrors(t *testing.T) {
+	conn, _, err := websocket.DefaultDialer.Dial("ws://"+startServer(t)+"/ws", http.Header{"Accept-Language": {"fr"}})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	tests := []struct {
+		msg  handlers.WSMessage
+		want string
+	}{
+		{handlers.WSMessage{Type: "nope"}, "Type de message inconnu : nope"},
+		{handlers.WSMessage{Type: handlers.WSAck, ID: "a"}, "Flux inconnu"},
+		{handlers.WSMessage{Type: handlers.WSSubscribe, Topic: "news"}, "Sujet inconnu"},
+		{handlers.WSMessage{Type: handlers.WSGenerate}, "generate nécessite un id et des params"},
+	}
+	for _, tt := range tests {
+		require.NoError(t, conn.WriteJSON(tt.msg))
+		msg := readMessage(t, conn)
+		assert.Equal(t, handlers.WSError, msg.Type)
+		assert.Equal(t, tt.want, msg.Error)
+	}
+}
+
+func TestWebSocket_StatsSubscription(t *testing.T) {
+	apps.App().StatsService.Reset()
+	conn := dialWebSocket(t)
+
+	require.NoError(t, conn.WriteJSON(handlers.WSMessage{Type: handlers.WSSubscribe, Topic: handlers.WSTopicStats}))
+	msg := readMessage(t, conn)
+	assert.Equal(t, handlers.WSStats, msg.Type)
+	assert.Equal(t, 0, msg.Stats.MostFrequentRequest.Hits)
+
+	err := conn.WriteJSON(handlers.WSMessage{
+		Type:   handlers.WSGenerate,
+		ID:     "a",
+		Params: &entities.FizzBuzzRequest{Int1: 3, Int2: 5, Limit: 15},
+	})
+	require.NoError(t, err)
+
+	for {
+		msg = readMessage(t, conn)
+		if msg.Type == handlers.WSStats {
+			break
+		}
+	}
+	assert.Equal(t, 1, msg.Stats.MostFrequentRequest.Hits)
+	assert.Equal(t, 15, msg.Stats.MostFrequentRequest.Limit)
+}
+
+func TestWebSocket_RequiresUpgrade(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+
+	resp, err := apps.App().FiberApp.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUpgradeRequired, resp.StatusCode)
+}
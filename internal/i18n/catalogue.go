@@ -0,0 +1,181 @@
+package i18n
+
+// catalogues hold the messages of every locale by key, {0}, {1}... being
+// replaced by the params of the message. Every key must be in English, the
+// fallback of the other locales.
+var catalogues = map[string]map[string]string{
+	"en": {
+		"status.400": "Bad Request",
+		"status.401": "Unauthorized",
+		"status.404": "Not Found",
+		"status.405": "Method Not Allowed",
+		"status.413": "Request Entity Too Large",
+		"status.422": "Unprocessable Entity",
+		"status.426": "Upgrade Required",
+		"status.429": "Too Many Requests",
+		"status.500": "Internal Server Error",
+		"status.503": "Service Unavailable",
+		"status.504": "Gateway Timeout",
+
+		"invalid_parameters":         "Invalid parameters",
+		"invalid_parameter_format":   "Invalid parameter format",
+		"invalid_request_body":       "Invalid request body",
+		"invalid_format":             "Invalid format: {0}",
+		"invalid_window":             "window must be a duration between 1m and {0}",
+		"invalid_top_n":              "n must be between 1 and {0}",
+		"batch_size":                 "Batch must contain between 1 and {0} parameter sets",
+		"missing_query":              "Missing query",
+		"invalid_variables":          "Invalid variables: {0}",
+		"invalid_stats_snapshot":     "Invalid stats snapshot",
+		"rejected_stats_snapshot":    "Invalid stats snapshot: {0}",
+		"internal_stats_error":       "Internal stats error",
+		"internal_error":             "Internal error",
+		"unknown_tenant":             "Unknown tenant: {0}",
+		"invalid_api_key":            "Invalid or missing API key",
+		"invalid_admin_token":        "Invalid or missing admin token",
+		"admin_disabled":             "The admin endpoints are not served on the public port without an admin token",
+		"too_many_requests":          "Too many requests",
+		"websocket_upgrade_required": "WebSocket upgrade required",
+		"level_required":             "level is required",
+		"invalid_log_level":          "Invalid log level: {0}",
+		"rejected_config":            "Configuration rejected: {0}",
+		"route_not_found":            "Cannot {0} {1}",
+		"deadline_exceeded":          "The request did not complete in time",
+		"request_cancelled":          "The request was cancelled",
+		"response_too_large":         "The estimated response size of {0} bytes exceeds the budget of {1} bytes",
+		"invalid_message":            "Invalid message: {0}",
+		"unknown_message_type":       "Unknown message type: {0}",
+		"unknown_stream":             "Unknown stream",
+		"unknown_topic":              "Unknown topic",
+		"generate_params_required":   "generate requires an id and params",
+		"stream_id_in_use":           "Stream id already in use",
+		"too_many_streams":           "Too many concurrent streams",
+
+		"validation.required":  "{0} is required",
+		"validation.gt":        "{0} must be greater than {1}",
+		"validation.gte":       "{0} must be at least {1}",
+		"validation.lt":        "{0} must be less than {1}",
+		"validation.lte":       "{0} must be at most {1}",
+		"validation.oneof":     "{0} must be one of {1}",
+		"validation.maxlimit":  "{0} exceeds the maximum limit",
+		"validation.wordlen":   "{0} exceeds the maximum word length",
+		"validation.wordchars": "{0} contains characters that are not allowed",
+		"validation.default":   "{0} failed the {1} rule",
+	},
+	"fr": {
+		"status.400": "Requête invalide",
+		"status.401": "Non autorisé",
+		"status.404": "Introuvable",
+		"status.405": "Méthode non autorisée",
+		"status.413": "Requête trop volumineuse",
+		"status.422": "Entité non traitable",
+		"status.426": "Mise à niveau requise",
+		"status.429": "Trop de requêtes",
+		"status.500": "Erreur interne du serveur",
+		"status.503": "Service indisponible",
+		"status.504": "Délai d'attente dépassé",
+
+		"invalid_parameters":         "Paramètres invalides",
+		"invalid_parameter_format":   "Format de paramètre invalide",
+		"invalid_request_body":       "Corps de requête invalide",
+		"invalid_format":             "Format invalide : {0}",
+		"invalid_window":             "window doit être une durée entre 1m et {0}",
+		"invalid_top_n":              "n doit être compris entre 1 et {0}",
+		"batch_size":                 "Le lot doit contenir entre 1 et {0} jeux de paramètres",
+		"missing_query":              "Requête GraphQL manquante",
+		"invalid_variables":          "Variables invalides : {0}",
+		"invalid_stats_snapshot":     "Instantané de statistiques invalide",
+		"rejected_stats_snapshot":    "Instantané de statistiques invalide : {0}",
+		"internal_stats_error":       "Erreur interne des statistiques",
+		"internal_error":             "Erreur interne",
+		"unknown_tenant":             "Locataire inconnu : {0}",
+		"invalid_api_key":            "Clé d'API invalide ou manquante",
+		"invalid_admin_token":        "Jeton d'administration invalide ou manquant",
+		"admin_disabled":             "Les points d'administration ne sont pas servis sur le port public sans jeton d'administration",
+		"too_many_requests":          "Trop de requêtes",
+		"websocket_upgrade_required": "Mise à niveau WebSocket requise",
+		"level_required":             "level est obligatoire",
+		"invalid_log_level":          "Niveau de journalisation invalide : {0}",
+		"rejected_config":            "Configuration rejetée : {0}",
+		"route_not_found":            "Impossible de traiter {0} {1}",
+		"deadline_exceeded":          "La requête ne s'est pas terminée à temps",
+		"request_cancelled":          "La requête a été annulée",
+		"response_too_large":         "La taille estimée de la réponse, {0} octets, dépasse le budget de {1} octets",
+		"invalid_message":            "Message invalide : {0}",
+		"unknown_message_type":       "Type de message inconnu : {0}",
+		"unknown_stream":             "Flux inconnu",
+		"unknown_topic":              "Sujet inconnu",
+		"generate_params_required":   "generate nécessite un id et des params",
+		"stream_id_in_use":           "Identifiant de flux déjà utilisé",
+		"too_many_streams":           "Trop de flux simultanés",
+
+		"validation.required":  "{0} est obligatoire",
+		"validation.gt":        "{0} doit être supérieur à {1}",
+		"validation.gte":       "{0} doit être au moins {1}",
+		"validation.lt":        "{0} doit être inférieur à {1}",
+		"validation.lte":       "{0} doit être au plus {1}",
+		"validation.oneof":     "{0} doit être l'une des valeurs {1}",
+		"validation.maxlimit":  "{0} dépasse la limite maximale",
+		"validation.wordlen":   "{0} dépasse la longueur maximale d'un mot",
+		"validation.wordchars": "{0} contient des caractères non autorisés",
+		"validation.default":   "{0} ne respecte pas la règle {1}",
+	},
+	"pt": {
+		"status.400": "Requisição inválida",
+		"status.401": "Não autorizado",
+		"status.404": "Não encontrado",
+		"status.405": "Método não permitido",
+		"status.413": "Requisição muito grande",
+		"status.422": "Entidade não processável",
+		"status.426": "Atualização necessária",
+		"status.429": "Muitas requisições",
+		"status.500": "Erro interno do servidor",
+		"status.503": "Serviço indisponível",
+		"status.504": "Tempo limite esgotado",
+
+		"invalid_parameters":         "Parâmetros inválidos",
+		"invalid_parameter_format":   "Formato de parâmetro inválido",
+		"invalid_request_body":       "Corpo da requisição inválido",
+		"invalid_format":             "Formato inválido: {0}",
+		"invalid_window":             "window deve ser uma duração entre 1m e {0}",
+		"invalid_top_n":              "n deve estar entre 1 e {0}",
+		"batch_size":                 "O lote deve conter entre 1 e {0} conjuntos de parâmetros",
+		"missing_query":              "Consulta GraphQL ausente",
+		"invalid_variables":          "Variáveis inválidas: {0}",
+		"invalid_stats_snapshot":     "Instantâneo de estatísticas inválido",
+		"rejected_stats_snapshot":    "Instantâneo de estatísticas inválido: {0}",
+		"internal_stats_error":       "Erro interno das estatísticas",
+		"internal_error":             "Erro interno",
+		"unknown_tenant":             "Inquilino desconhecido: {0}",
+		"invalid_api_key":            "Chave de API inválida ou ausente",
+		"invalid_admin_token":        "Token de administração inválido ou ausente",
+		"admin_disabled":             "Os endpoints de administração não são servidos na porta pública sem token de administração",
+		"too_many_requests":          "Muitas requisições",
+		"websocket_upgrade_required": "Atualização para WebSocket necessária",
+		"level_required":             "level é obrigatório",
+		"invalid_log_level":          "Nível de log inválido: {0}",
+		"rejected_config":            "Configuração rejeitada: {0}",
+		"route_not_found":            "Não é possível processar {0} {1}",
+		"deadline_exceeded":          "A requisição não foi concluída a tempo",
+		"request_cancelled":          "A requisição foi cancelada",
+		"response_too_large":         "O tamanho estimado da resposta, {0} bytes, excede o orçamento de {1} bytes",
+		"invalid_message":            "Mensagem inválida: {0}",
+		"unknown_message_type":       "Tipo de mensagem desconhecido: {0}",
+		"unknown_stream":             "Fluxo desconhecido",
+		"unknown_topic":              "Tópico desconhecido",
+		"generate_params_required":   "generate requer um id e params",
+		"stream_id_in_use":           "Identificador de fluxo já em uso",
+		"too_many_streams":           "Muitos fluxos simultâneos",
+
+		"validation.required":  "{0} é obrigatório",
+		"validation.gt":        "{0} deve ser maior que {1}",
+		"validation.gte":       "{0} deve ser no mínimo {1}",
+		"validation.lt":        "{0} deve ser menor que {1}",
+		"validation.lte":       "{0} deve ser no máximo {1}",
+		"validation.oneof":     "{0} deve ser um dos valores {1}",
+		"validation.maxlimit":  "{0} excede o limite máximo",
+		"validation.wordlen":   "{0} excede o comprimento máximo de uma palavra",
+		"validation.wordchars": "{0} contém caracteres não permitidos",
+		"validation.default":   "{0} não respeita a regra {1}",
+	},
+}
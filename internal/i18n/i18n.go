@@ -0,0 +1,146 @@
+// Package i18n translates the messages returned to clients. English is the
+// fallback, French and Portuguese are also provided; a locale is selected
+// from the Accept-Language header of the request.
+package i18n
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/fr"
+	"github.com/go-playground/locales/pt"
+	ut "github.com/go-playground/universal-translator"
+)
+
+var (
+	universal = newUniversal()
+	english   = Translator{trans: universal.GetFallback()}
+)
+
+// newUniversal registers every catalogue. The catalogues are static, failing
+// to load one is a programming error.
+func newUniversal() *ut.UniversalTranslator {
+	supported := []locales.Translator{en.New(), fr.New(), pt.New()}
+	universal := ut.New(supported[0], supported...)
+	for _, locale := range supported {
+		trans, _ := universal.GetTranslator(locale.Locale())
+		for key, text := range catalogues[locale.Locale()] {
+			if err := trans.Add(key, text, false); err != nil {
+				panic(err)
+			}
+		}
+	}
+	return universal
+}
+
+// Translator translates messages into one locale. The zero value translates
+// into English.
+type Translator struct {
+	trans ut.Translator
+}
+
+// ForAcceptLanguage returns the translator of the most preferred supported
+// locale of an Accept-Language header, English when none is supported
+func ForAcceptLanguage(header string) Translator {
+	trans, _ := universal.FindTranslator(preferredLocales(header)...)
+	return Translator{trans: trans}
+}
+
+// Locale returns the locale messages are translated into, e.g. fr
+func (t Translator) Locale() string {
+	if t.trans == nil {
+		return english.Locale()
+	}
+	return t.trans.Locale()
+}
+
+// T translates the message key, {0}, {1}... in the text being replaced by
+// params. Keys missing from the locale are translated into English, unknown
+// keys are returned as is.
+func (t Translator) T(key string, params ...string) string {
+	if t.trans != nil {
+		if text, err := t.trans.T(key, params...); err == nil {
+			return text
+		}
+	}
+	if text, err := english.trans.T(key, params...); err == nil {
+		return text
+	}
+	return key
+}
+
+// ruleKeys are the message keys of the validation rules sharing the message
+// of another one
+var ruleKeys = map[string]string{
+	"min": "gte",
+	"max": "lte",
+}
+
+// Field translates the error of a field that failed the validation rule
+// with param, e.g. "limit is required"
+func (t Translator) Field(field, rule, param string) string {
+	if alias, ok := ruleKeys[rule]; ok {
+		rule = alias
+	}
+	key := "validation." + rule
+	if _, ok := catalogues["en"][key]; !ok {
+		return t.T("validation.default", field, rule)
+	}
+	return t.T(key, field, param)
+}
+
+type translatorKey struct{}
+
+// WithTranslator returns ctx carrying t, for messages built away from the
+// request, e.g. by the binder
+func WithTranslator(ctx context.Context, t Translator) context.Context {
+	return context.WithValue(ctx, translatorKey{}, t)
+}
+
+// FromContext returns the translator of ctx, English when it has none
+func FromContext(ctx context.Context) Translator {
+	t, _ := ctx.Value(translatorKey{}).(Translator)
+	return t
+}
+
+// preferredLocales returns the locales of an Accept-Language header by
+// decreasing preference, each followed by its base language: "fr-CA,
+// en;q=0.5" gives fr_CA, fr, en
+func preferredLocales(header string) []string {
+	type weighted struct {
+		locale string
+		q      float64
+	}
+	var ranges []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		if tag == "" || tag == "*" || q <= 0 {
+			continue
+		}
+		ranges = append(ranges, weighted{locale: strings.ReplaceAll(tag, "-", "_"), q: q})
+	}
+	slices.SortStableFunc(ranges, func(a, b weighted) int {
+		return cmp.Compare(b.q, a.q)
+	})
+
+	locales := make([]string, 0, 2*len(ranges))
+	for _, r := range ranges {
+		locales = append(locales, r.locale)
+		if base, _, ok := strings.Cut(r.locale, "_"); ok {
+			locales = append(locales, base)
+		}
+	}
+	return locales
+}
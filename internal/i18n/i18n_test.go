@@ -0,0 +1,64 @@
+package i18n
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		locale string
+	}{
+		{"", "en"},
+		{"fr", "fr"},
+		{"fr-CA,en;q=0.5", "fr"},
+		{"pt-BR", "pt"},
+		{"de, pt;q=0.8, fr;q=0.9", "fr"},
+		{"fr;q=0, pt;q=0.1", "pt"},
+		{"de, ja", "en"},
+		{"*", "en"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			assert.Equal(t, tt.locale, ForAcceptLanguage(tt.header).Locale())
+		})
+	}
+}
+
+func TestTranslator_T(t *testing.T) {
+	fr := ForAcceptLanguage("fr")
+	assert.Equal(t, "Locataire inconnu : acme", fr.T("unknown_tenant", "acme"))
+	assert.Equal(t, "limit est obligatoire", fr.Field("limit", "required", ""))
+	assert.Equal(t, "n deve ser no mínimo 1", ForAcceptLanguage("pt").Field("n", "min", "1"))
+	assert.Equal(t, "str1 failed the alpha rule", Translator{}.Field("str1", "alpha", ""))
+	assert.Equal(t, "Cannot GET /", Translator{}.T("Cannot GET /"))
+
+	assert.Equal(t, "en", FromContext(context.Background()).Locale())
+	assert.Equal(t, "fr", FromContext(WithTranslator(context.Background(), fr)).Locale())
+}
+
+func TestCatalogues_Complete(t *testing.T) {
+	placeholders := regexp.MustCompile(`\{\d+\}`)
+	for locale, catalogue := range catalogues {
+		for key, text := range catalogues["en"] {
+			translated, ok := catalogue[key]
+			if !assert.True(t, ok, "%s misses %s", locale, key) {
+				continue
+			}
+			want := placeholders.FindAllString(text, -1)
+			got := placeholders.FindAllString(translated, -1)
+			slices.Sort(want)
+			slices.Sort(got)
+			assert.Equal(t, want, got, "placeholders of %s in %s", key, locale)
+		}
+		for key := range catalogue {
+			_, ok := catalogues["en"][key]
+			assert.True(t, ok, "%s has %s, missing in English", locale, key)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+// Package listener opens the sockets the server accepts connections on: TCP
+// addresses, Unix sockets and sockets passed by systemd socket activation.
+package listener
+
+import (
+	"errors"
+	"fizzbuzz-server/internal/config"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// sdListenFDsStart is the first file descriptor passed by systemd, see
+// sd_listen_fds(3)
+const sdListenFDsStart = 3
+
+// Address listens on a TCP address such as ":8080", or on a Unix socket for
+// "unix:/path" addresses, created with mode
+func Address(address string, mode os.FileMode) (net.Listener, error) {
+	if path, ok := config.UnixSocket(address); ok {
+		return Unix(path, mode)
+	}
+	return net.Listen("tcp", address)
+}
+
+// Unix listens on a Unix socket at path and sets its mode. A socket left
+// behind by a previous run is replaced, one still accepting connections is
+// not. The socket file is removed when the listener is closed.
+func Unix(path string, mode os.FileMode) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if conn, err := net.Dial("unix", path); err == nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("listener: %s is in use", path)
+		}
+		_ = os.Remove(path)
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		_ = ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+// Systemd returns the sockets passed by systemd socket activation. The
+// activation variables are unset so that child processes do not see them.
+func Systemd() ([]net.Listener, error) {
+	return listenFDs(sdListenFDsStart)
+}
+
+func listenFDs(first int) ([]net.Listener, error) {
+	pid, fds, names := os.Getenv("LISTEN_PID"), os.Getenv("LISTEN_FDS"), os.Getenv("LISTEN_FDNAMES")
+	for _, env := range []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES"} {
+		_ = os.Unsetenv(env)
+	}
+
+	if pid != strconv.Itoa(os.Getpid()) {
+		return nil, errors.New("listener: no sockets passed by systemd, LISTEN_PID does not match")
+	}
+	n, err := strconv.Atoi(fds)
+	if err != nil || n <= 0 {
+		return nil, errors.New("listener: no sockets passed by systemd, LISTEN_FDS is not set")
+	}
+
+	fdNames := strings.Split(names, ":")
+	lns := make([]net.Listener, 0, n)
+	for i := range n {
+		fd := first + i
+		syscall.CloseOnExec(fd)
+		name := "LISTEN_FD_" + strconv.Itoa(fd)
+		if i < len(fdNames) && fdNames[i] != "" {
+			name = fdNames[i]
+		}
+
+		// FileListener works on a copy of the descriptor
+		file := os.NewFile(uintptr(fd), name)
+		ln, err := net.FileListener(file)
+		_ = file.Close()
+		if err != nil {
+			for _, ln := range lns {
+				_ = ln.Close()
+			}
+			return nil, fmt.Errorf("listener: socket %s: %w", name, err)
+		}
+		lns = append(lns, ln)
+	}
+	return lns, nil
+}
+
+// Merge returns a listener accepting the connections of every listener of
+// lns. Closing it closes them all.
+func Merge(lns ...net.Listener) net.Listener {
+	if len(lns) == 1 {
+		return lns[0]
+	}
+	m := &merged{lns: lns, accepted: make(chan accepted), done: make(chan struct{})}
+	for _, ln := range lns {
+		go m.accept(ln)
+	}
+	return m
+}
+
+type accepted struct {
+	conn net.Conn
+	err  error
+}
+
+type merged struct {
+	lns      []net.Listener
+	accepted chan accepted
+	done     chan struct{}
+	once     sync.Once
+}
+
+// accept forwards the connections of ln until it fails or m is closed
+func (m *merged) accept(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		select {
+		case m.accepted <- accepted{conn: conn, err: err}:
+		case <-m.done:
+			if conn != nil {
+				_ = conn.Close()
+			}
+			return
+		}
+		// a listener that failed for good is dropped, the error stops the server
+		var netErr net.Error
+		if err != nil && (!errors.As(err, &netErr) || !netErr.Timeout()) {
+			return
+		}
+	}
+}
+
+func (m *merged) Accept() (net.Conn, error) {
+	select {
+	case a := <-m.accepted:
+		return a.conn, a.err
+	case <-m.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (m *merged) Close() error {
+	var err error
+	m.once.Do(func() {
+		close(m.done)
+		for _, ln := range m.lns {
+			err = errors.Join(err, ln.Close())
+		}
+	})
+	return err
+}
+
+// Addr returns the address of the first listener
+func (m *merged) Addr() net.Addr {
+	return m.lns[0].Addr()
+}
+
+// Named is a listener passed between processes, with its role
+type Named struct {
+	Name     string // role of the socket, e.g. "server" or "admin"
+	Listener net.Listener
+	// Owned is set when the process created the socket, and removes the
+	// file of a Unix socket on close. Sockets passed by systemd are not.
+	Owned bool
+}
+
+type filer interface {
+	File() (*os.File, error)
+}
+
+// Files duplicates the descriptors of lns for a child process, in order, and
+// describes them for Inherit. The caller closes the files once the child
+// started.
+func Files(lns []Named) ([]*os.File, string, error) {
+	files := make([]*os.File, 0, len(lns))
+	entries := make([]string, 0, len(lns))
+	for _, l := range lns {
+		file, err := listenerFile(l)
+		if err != nil {
+			for _, file := range files {
+				_ = file.Close()
+			}
+			return nil, "", err
+		}
+		files = append(files, file)
+		ownership := "shared"
+		if l.Owned {
+			ownership = "owned"
+		}
+		entries = append(entries, l.Name+":"+ownership)
+	}
+	return files, strings.Join(entries, ","), nil
+}
+
+func listenerFile(l Named) (*os.File, error) {
+	f, ok := l.Listener.(filer)
+	if !ok {
+		return nil, fmt.Errorf("listener: %s listener %s cannot be passed", l.Name, l.Listener.Addr())
+	}
+	return f.File()
+}
+
+// Inherit adopts the listeners described by entries, as returned by Files,
+// passed from descriptor first on
+func Inherit(entries string, first int) ([]Named, error) {
+	if entries == "" {
+		return nil, nil
+	}
+	var lns []Named
+	for i, entry := range strings.Split(entries, ",") {
+		name, ownership, _ := strings.Cut(entry, ":")
+		fd := first + i
+		syscall.CloseOnExec(fd)
+
+		// FileListener works on a copy of the descriptor
+		file := os.NewFile(uintptr(fd), name)
+		ln, err := net.FileListener(file)
+		_ = file.Close()
+		if err != nil {
+			for _, l := range lns {
+				_ = l.Listener.Close()
+			}
+			return nil, fmt.Errorf("listener: %s listener: %w", name, err)
+		}
+		owned := ownership == "owned"
+		if unix, ok := ln.(*net.UnixListener); ok {
+			unix.SetUnlinkOnClose(owned)
+		}
+		lns = append(lns, Named{Name: name, Listener: ln, Owned: owned})
+	}
+	return lns, nil
+}
+
+// Release stops the Unix sockets of lns from being removed on close, once
+// they were passed to a process taking them over
+func Release(lns []Named) {
+	for _, l := range lns {
+		if unix, ok := l.Listener.(*net.UnixListener); ok {
+			unix.SetUnlinkOnClose(false)
+		}
+	}
+}
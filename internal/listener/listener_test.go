@@ -0,0 +1,103 @@
+package listener
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// roundTrip dials addr and checks that ln accepts the connection
+func roundTrip(t *testing.T, ln net.Listener, network, addr string) {
+	t.Helper()
+	conn, err := net.Dial(network, addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	accepted, err := ln.Accept()
+	require.NoError(t, err)
+	_ = accepted.Close()
+}
+
+func TestUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.sock")
+
+	ln, err := Unix(path, 0o600)
+	require.NoError(t, err)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	roundTrip(t, ln, "unix", path)
+
+	_, err = Unix(path, 0o600)
+	assert.ErrorContains(t, err, "in use")
+
+	require.NoError(t, ln.Close())
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestUnix_ReplacesStaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.sock")
+	stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	require.NoError(t, err)
+	stale.SetUnlinkOnClose(false)
+	require.NoError(t, stale.Close())
+
+	ln, err := Unix(path, 0o660)
+	require.NoError(t, err)
+	defer ln.Close()
+	roundTrip(t, ln, "unix", path)
+}
+
+func TestSystemd(t *testing.T) {
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer tcp.Close()
+	// the copy of the descriptor is handed over, listenFDs closes it
+	file, err := tcp.(*net.TCPListener).File()
+	require.NoError(t, err)
+
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDS", "1")
+	t.Setenv("LISTEN_FDNAMES", "http")
+	lns, err := listenFDs(int(file.Fd()))
+	require.NoError(t, err)
+	require.Len(t, lns, 1)
+	defer lns[0].Close()
+	roundTrip(t, lns[0], "tcp", tcp.Addr().String())
+
+	_, ok := os.LookupEnv("LISTEN_FDS")
+	assert.False(t, ok)
+	_, err = listenFDs(int(file.Fd()))
+	assert.Error(t, err)
+}
+
+func TestSystemd_OtherProcess(t *testing.T) {
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	t.Setenv("LISTEN_FDS", "1")
+	_, err := Systemd()
+	assert.ErrorContains(t, err, "LISTEN_PID")
+}
+
+func TestMerge(t *testing.T) {
+	first, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	second, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ln := Merge(first, second)
+	assert.Equal(t, first.Addr(), ln.Addr())
+	roundTrip(t, ln, "tcp", first.Addr().String())
+	roundTrip(t, ln, "tcp", second.Addr().String())
+
+	require.NoError(t, ln.Close())
+	_, err = ln.Accept()
+	assert.ErrorIs(t, err, net.ErrClosed)
+	_, err = net.Dial("tcp", second.Addr().String())
+	assert.Error(t, err)
+}
@@ -0,0 +1,17 @@
+// Package metrics holds the application metrics exposed on the Prometheus
+// endpoint next to the Go and process ones.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// ResponseBudgetRejections counts the requests rejected because their
+// estimated response exceeded fizzbuzz.max_response_bytes, by endpoint:
+// fizzbuzz, batch, rpc, ws or graphql
+var ResponseBudgetRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "fizzbuzz",
+	Name:      "response_budget_rejections_total",
+	Help:      "Requests rejected because their estimated response size exceeded the budget.",
+}, []string{"endpoint"})
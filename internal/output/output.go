@@ -0,0 +1,125 @@
+package output
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"strconv"
+)
+
+// Supported output formats
+const (
+	FormatJSON   = "json"   // {"result":[...]}, the /fizzbuzz response body
+	FormatNDJSON = "ndjson" // one JSON string per line
+	FormatCSV    = "csv"    // "n,value" rows after a header
+	FormatText   = "text"   // one raw value per line
+)
+
+// Formats lists every supported format
+var Formats = []string{FormatJSON, FormatNDJSON, FormatCSV, FormatText}
+
+// Valid reports whether format is supported
+func Valid(format string) bool {
+	for _, f := range Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// Write encodes the sequence to w in the given format. Values are written as
+// they are produced, so memory use does not depend on the sequence length.
+func Write(w io.Writer, format string, seq iter.Seq[string]) error {
+	bw := bufio.NewWriter(w)
+
+	var err error
+	switch format {
+	case FormatJSON:
+		err = writeJSON(bw, seq)
+	case FormatNDJSON:
+		err = writeNDJSON(bw, seq)
+	case FormatCSV:
+		err = writeCSV(bw, seq)
+	case FormatText:
+		err = writeText(bw, seq)
+	default:
+		return fmt.Errorf("unsupported output format %q", format)
+	}
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func writeJSON(w *bufio.Writer, seq iter.Seq[string]) error {
+	if _, err := w.WriteString(`{"result":[`); err != nil {
+		return err
+	}
+	first := true
+	for v := range seq {
+		if !first {
+			if err := w.WriteByte(','); err != nil {
+				return err
+			}
+		}
+		first = false
+		if err := writeJSONString(w, v); err != nil {
+			return err
+		}
+	}
+	_, err := w.WriteString("]}\n")
+	return err
+}
+
+func writeNDJSON(w *bufio.Writer, seq iter.Seq[string]) error {
+	for v := range seq {
+		if err := writeJSONString(w, v); err != nil {
+			return err
+		}
+		if err := w.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeCSV(w *bufio.Writer, seq iter.Seq[string]) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"n", "value"}); err != nil {
+		return err
+	}
+	n := 0
+	for v := range seq {
+		n++
+		if err := cw.Write([]string{strconv.Itoa(n), v}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeText(w *bufio.Writer, seq iter.Seq[string]) error {
+	for v := range seq {
+		if _, err := w.WriteString(v); err != nil {
+			return err
+		}
+		if err := w.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeJSONString(w *bufio.Writer, v string) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
@@ -0,0 +1,37 @@
+package output
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrite_Formats(t *testing.T) {
+	values := []string{"1", "fizz", `say "hi"`}
+	expected := map[string]string{
+		FormatJSON:   `{"result":["1","fizz","say \"hi\""]}` + "\n",
+		FormatNDJSON: "\"1\"\n\"fizz\"\n\"say \\\"hi\\\"\"\n",
+		FormatCSV:    "n,value\n1,1\n2,fizz\n3,\"say \"\"hi\"\"\"\n",
+		FormatText:   "1\nfizz\nsay \"hi\"\n",
+	}
+
+	for _, format := range Formats {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			assert.NoError(t, Write(&buf, format, slices.Values(values)))
+			assert.Equal(t, expected[format], buf.String())
+		})
+	}
+}
+
+func TestWrite_EmptySequence(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, Write(&buf, FormatJSON, slices.Values([]string{})))
+	assert.Equal(t, "{\"result\":[]}\n", buf.String())
+}
+
+func TestWrite_UnsupportedFormat(t *testing.T) {
+	assert.Error(t, Write(&bytes.Buffer{}, "xml", slices.Values([]string{"1"})))
+}
@@ -0,0 +1,306 @@
+// Package prefork runs the server as several worker processes sharing the
+// listening sockets of a master process, which the kernel balances
+// connections over. The master only supervises the workers: it restarts the
+// ones that exit and forwards signals to them.
+package prefork
+
+import (
+	"errors"
+	"fizzbuzz-server/internal/listener"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Variables describing a worker process
+const (
+	envWorker    = "FIZZBUZZ_PREFORK_WORKER"    // number of the worker
+	envStats     = "FIZZBUZZ_PREFORK_STATS"     // socket of the stats aggregator
+	envListeners = "FIZZBUZZ_PREFORK_LISTENERS" // inherited listeners, see listener.Files
+)
+
+// Descriptors passed to a worker
+const (
+	readyFD         = 3 // written by the worker once it is serving
+	firstListenerFD = 4
+)
+
+// Delays of the master
+const (
+	restartDelay       = time.Second            // before restarting a worker that exited
+	maxRestartDelay    = 30 * time.Second       // between retries of a failed restart
+	masterPollInterval = 500 * time.Millisecond // of a worker checking its master
+)
+
+// Worker is the side of a worker process
+type Worker struct {
+	ID           int
+	StatsAddress string // Unix socket of the stats aggregator
+	listeners    []listener.Named
+	ready        *os.File
+	master       int
+}
+
+// Inherited returns the side of the worker when this process was started by
+// a Master, and nil otherwise
+func Inherited() (*Worker, error) {
+	id, ok := os.LookupEnv(envWorker)
+	if !ok {
+		return nil, nil
+	}
+	w := &Worker{StatsAddress: os.Getenv(envStats), master: os.Getppid()}
+	entries := os.Getenv(envListeners)
+	for _, env := range []string{envWorker, envStats, envListeners} {
+		_ = os.Unsetenv(env)
+	}
+
+	var err error
+	if w.ID, err = strconv.Atoi(id); err != nil {
+		return nil, fmt.Errorf("prefork: invalid worker number %q", id)
+	}
+	syscall.CloseOnExec(readyFD)
+	w.ready = os.NewFile(readyFD, "prefork-ready")
+	if w.listeners, err = listener.Inherit(entries, firstListenerFD); err != nil {
+		_ = w.ready.Close()
+		return nil, fmt.Errorf("prefork: %w", err)
+	}
+	return w, nil
+}
+
+// Listeners returns the inherited listeners
+func (w *Worker) Listeners() []listener.Named {
+	return w.listeners
+}
+
+// Ready tells the master that the worker is serving
+func (w *Worker) Ready() error {
+	defer w.ready.Close()
+	_, err := w.ready.Write([]byte{1})
+	return err
+}
+
+// WatchMaster calls stop once the master process is gone, so that workers
+// do not outlive it
+func (w *Worker) WatchMaster(stop func()) {
+	go func() {
+		ticker := time.NewTicker(masterPollInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if os.Getppid() != w.master {
+				stop()
+				return
+			}
+		}
+	}()
+}
+
+// Master starts and supervises the worker processes
+type Master struct {
+	listeners    []listener.Named
+	statsAddress string
+	onExit       func(id int, err error)
+
+	mu       sync.Mutex
+	workers  map[int]*os.Process
+	stopping bool
+	stopped  chan struct{} // closed when stopping, ends the restart delays
+	wg       sync.WaitGroup
+}
+
+// Start starts n workers running the binary with the same arguments and
+// environment, sharing listeners and counting their stats on the aggregator
+// at statsAddress. It returns once every worker is serving, or fails when
+// one is not within timeout. onExit is told of workers exiting while not
+// stopped, they are restarted, and of the restarts failing, they are retried
+// with an increasing delay.
+func Start(n int, listeners []listener.Named, statsAddress string, timeout time.Duration, onExit func(id int, err error)) (*Master, error) {
+	// the master owns the sockets, workers closing them must not remove them
+	shared := make([]listener.Named, len(listeners))
+	for i, l := range listeners {
+		l.Owned = false
+		shared[i] = l
+	}
+	m := &Master{
+		listeners:    shared,
+		statsAddress: statsAddress,
+		onExit:       onExit,
+		workers:      make(map[int]*os.Process, n),
+		stopped:      make(chan struct{}),
+	}
+	ready := make([]<-chan error, 0, n)
+	for id := 1; id <= n; id++ {
+		r, err := m.start(id)
+		if err != nil {
+			_ = m.Stop(timeout)
+			return nil, err
+		}
+		ready = append(ready, r)
+	}
+
+	deadline := time.After(timeout)
+	for id, r := range ready {
+		select {
+		case err := <-r:
+			if err != nil {
+				_ = m.Stop(timeout)
+				return nil, fmt.Errorf("prefork: worker %d: %w", id+1, err)
+			}
+		case <-deadline:
+			_ = m.Stop(timeout)
+			return nil, fmt.Errorf("prefork: workers not ready after %s", timeout)
+		}
+	}
+	return m, nil
+}
+
+// start starts worker id; the returned channel receives nil once it is
+// serving, or an error when it exits before
+func (m *Master) start(id int) (<-chan error, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("prefork: %w", err)
+	}
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("prefork: %w", err)
+	}
+	lnFiles, entries, err := listener.Files(m.listeners)
+	if err != nil {
+		_ = readyR.Close()
+		_ = readyW.Close()
+		return nil, fmt.Errorf("prefork: %w", err)
+	}
+	// the descriptors of the worker, closed here once it started
+	files := append([]*os.File{readyW}, lnFiles...)
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	cmd.Env = append(os.Environ(),
+		envWorker+"="+strconv.Itoa(id),
+		envStats+"="+m.statsAddress,
+		envListeners+"="+entries,
+	)
+	cmd.ExtraFiles = files
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stopping {
+		_ = readyR.Close()
+		return nil, errors.New("prefork: stopping")
+	}
+	if err := cmd.Start(); err != nil {
+		_ = readyR.Close()
+		return nil, fmt.Errorf("prefork: %w", err)
+	}
+	m.workers[id] = cmd.Process
+	m.wg.Add(1)
+	go m.wait(id, cmd)
+
+	ready := make(chan error, 1)
+	go func() {
+		defer readyR.Close()
+		// the worker writes a byte once serving, the pipe is closed without
+		// one when it exits before
+		if _, err := readyR.Read(make([]byte, 1)); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = errors.New("exited before it was ready")
+			}
+			ready <- err
+			return
+		}
+		ready <- nil
+	}()
+	return ready, nil
+}
+
+// wait restarts worker id when it exits, unless the master is stopping. A
+// failed restart is retried, the delay doubling up to maxRestartDelay, so
+// that the slot of the worker is not left empty.
+func (m *Master) wait(id int, cmd *exec.Cmd) {
+	defer m.wg.Done()
+	err := cmd.Wait()
+
+	m.mu.Lock()
+	delete(m.workers, id)
+	stopping := m.stopping
+	m.mu.Unlock()
+	if stopping {
+		return
+	}
+
+	m.onExit(id, err)
+	for delay := restartDelay; ; delay = min(2*delay, maxRestartDelay) {
+		select {
+		case <-m.stopped:
+			return
+		case <-time.After(delay):
+		}
+		_, err := m.start(id)
+		if err == nil || m.isStopping() {
+			return
+		}
+		m.onExit(id, err)
+	}
+}
+
+func (m *Master) isStopping() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stopping
+}
+
+// PIDs returns the process IDs of the running workers
+func (m *Master) PIDs() []int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pids := make([]int, 0, len(m.workers))
+	for _, p := range m.workers {
+		pids = append(pids, p.Pid)
+	}
+	return pids
+}
+
+// Signal sends sig to every worker
+func (m *Master) Signal(sig os.Signal) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, p := range m.workers {
+		_ = p.Signal(sig)
+	}
+}
+
+// Stop shuts the workers down gracefully with SIGTERM and kills the ones
+// still running after timeout
+func (m *Master) Stop(timeout time.Duration) error {
+	m.mu.Lock()
+	if !m.stopping {
+		m.stopping = true
+		close(m.stopped)
+	}
+	m.mu.Unlock()
+	m.Signal(syscall.SIGTERM)
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		m.Signal(syscall.SIGKILL)
+		<-done
+		return fmt.Errorf("prefork: workers killed after %s", timeout)
+	}
+}
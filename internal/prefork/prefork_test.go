@@ -0,0 +1,218 @@
+package prefork
+
+import (
+	"bufio"
+	"fizzbuzz-server/internal/entities"
+	"fizzbuzz-server/internal/listener"
+	"fizzbuzz-server/internal/services"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// envTestWorker selects the behaviour of the test binary started as a worker
+const envTestWorker = "FIZZBUZZ_PREFORK_TEST_WORKER"
+
+// perWorker is the number of requests each worker counts on start
+const perWorker = 25
+
+var testRequest = entities.FizzBuzzRequest{Int1: 3, Int2: 5, Limit: 15, Str1: "fizz", Str2: "buzz"}
+
+// TestMain runs the test binary as a worker when started by a Master
+func TestMain(m *testing.M) {
+	worker, err := Inherited()
+	if err != nil {
+		os.Exit(2)
+	}
+	if worker != nil {
+		os.Exit(runWorker(worker))
+	}
+	os.Exit(m.Run())
+}
+
+// runWorker counts perWorker requests, then answers every connection with
+// its PID and the hits it reads, until SIGTERM
+func runWorker(worker *Worker) int {
+	if os.Getenv(envTestWorker) == "fail" {
+		return 1
+	}
+	stats, err := services.DialStatsService(worker.StatsAddress, func(error) {})
+	if err != nil {
+		return 1
+	}
+	defer stats.Close()
+	for range perWorker {
+		stats.Record(testRequest)
+	}
+
+	lns := worker.Listeners()
+	if len(lns) != 1 || lns[0].Owned {
+		return 1
+	}
+	ln := lns[0].Listener
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		_ = ln.Close()
+	}()
+	if err := worker.Ready(); err != nil {
+		return 1
+	}
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return 0
+		}
+		hits := 0
+		if top, err := stats.Top(1, 0); err == nil && len(top) == 1 {
+			hits = top[0].Hits
+		}
+		_, _ = fmt.Fprintf(conn, "%d %d\n", os.Getpid(), hits)
+		_ = conn.Close()
+	}
+}
+
+// startAggregator serves stats to the workers and returns its socket
+func startAggregator(t *testing.T, stats *services.StatsService) string {
+	path := filepath.Join(t.TempDir(), "stats.sock")
+	ln, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	aggregator := services.NewStatsAggregator(stats)
+	go func() { _ = aggregator.Serve(ln) }()
+	t.Cleanup(func() {
+		aggregator.Close()
+		_ = ln.Close()
+	})
+	return path
+}
+
+// ask returns the PID and hits answered by the worker accepting a connection
+func ask(t *testing.T, address string) (int, int) {
+	conn, err := net.DialTimeout("tcp", address, 5*time.Second)
+	require.NoError(t, err)
+	defer conn.Close()
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	require.NoError(t, err)
+	fields := strings.Fields(line)
+	require.Len(t, fields, 2)
+	pid, err := strconv.Atoi(fields[0])
+	require.NoError(t, err)
+	hits, err := strconv.Atoi(fields[1])
+	require.NoError(t, err)
+	return pid, hits
+}
+
+func totalHits(t *testing.T, stats *services.StatsService) int {
+	top, err := stats.Top(1, 0)
+	require.NoError(t, err)
+	if len(top) == 0 {
+		return 0
+	}
+	return top[0].Hits
+}
+
+func TestMaster_AggregatesWorkerStats(t *testing.T) {
+	stats := services.NewStatsService(time.Hour)
+	path := startAggregator(t, stats)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	const workers = 3
+	exited := make(chan int, workers)
+	master, err := Start(workers, []listener.Named{{Name: "server", Listener: ln, Owned: true}}, path, 10*time.Second, func(id int, err error) {
+		exited <- id
+	})
+	require.NoError(t, err)
+	pids := master.PIDs()
+	require.Len(t, pids, workers)
+
+	// every worker counted into the master and reads the merged counters
+	assert.Equal(t, workers*perWorker, totalHits(t, stats))
+	for range 20 {
+		pid, hits := ask(t, ln.Addr().String())
+		assert.Contains(t, pids, pid)
+		assert.Equal(t, workers*perWorker, hits)
+	}
+
+	// a worker that dies is restarted, the counts of the old one are kept
+	require.NoError(t, syscall.Kill(pids[0], syscall.SIGKILL))
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker exit not reported")
+	}
+	require.Eventually(t, func() bool {
+		return totalHits(t, stats) == (workers+1)*perWorker && len(master.PIDs()) == workers
+	}, 10*time.Second, 10*time.Millisecond)
+	assert.NotContains(t, master.PIDs(), pids[0])
+
+	require.NoError(t, master.Stop(10*time.Second))
+	assert.Empty(t, master.PIDs())
+	select {
+	case id := <-exited:
+		t.Fatalf("worker %d reported as exited while stopping", id)
+	default:
+	}
+}
+
+func TestStart_WorkerFails(t *testing.T) {
+	t.Setenv(envTestWorker, "fail")
+	stats := services.NewStatsService(time.Hour)
+	path := startAggregator(t, stats)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	_, err = Start(2, []listener.Named{{Name: "server", Listener: ln}}, path, 10*time.Second, func(int, error) {})
+	assert.ErrorContains(t, err, "exited before it was ready")
+}
+
+func TestMaster_RetriesFailedRestarts(t *testing.T) {
+	stats := services.NewStatsService(time.Hour)
+	path := startAggregator(t, stats)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	errs := make(chan error, 10)
+	master, err := Start(1, []listener.Named{{Name: "server", Listener: ln}}, path, 10*time.Second, func(_ int, err error) {
+		errs <- err
+	})
+	require.NoError(t, err)
+
+	// without its listener the worker cannot be started again
+	require.NoError(t, ln.Close())
+	require.NoError(t, syscall.Kill(master.PIDs()[0], syscall.SIGKILL))
+	next := func() error {
+		select {
+		case err := <-errs:
+			return err
+		case <-time.After(10 * time.Second):
+			t.Fatal("worker exit not reported")
+			return nil
+		}
+	}
+	assert.ErrorContains(t, next(), "signal: killed")
+	for range 2 {
+		assert.ErrorContains(t, next(), "prefork: ")
+	}
+	assert.Empty(t, master.PIDs())
+
+	// stopping ends the retries without waiting for the next one
+	start := time.Now()
+	require.NoError(t, master.Stop(10*time.Second))
+	assert.Less(t, time.Since(start), time.Second)
+}
@@ -1,6 +1,15 @@
 package services
 
-import "strconv"
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fizzbuzz-server/internal/output"
+	"iter"
+	"math"
+	"strconv"
+	"strings"
+)
 
 type FizzBuzzService struct {
 }
@@ -12,16 +21,140 @@ func NewFizzBuzzService() *FizzBuzzService {
 func (f *FizzBuzzService) GenerateFizzBuzz(int1, int2, limit int, str1, str2 string) []string {
 	result := make([]string, limit)
 	for i := 1; i <= limit; i++ {
-		switch {
-		case i%int1 == 0 && i%int2 == 0:
-			result[i-1] = str1 + str2
-		case i%int1 == 0:
-			result[i-1] = str1
-		case i%int2 == 0:
-			result[i-1] = str2
-		default:
-			result[i-1] = strconv.Itoa(i)
-		}
+		result[i-1] = fizzBuzzValue(i, int1, int2, str1, str2)
 	}
 	return result
 }
+
+// Sequence yields the same values as GenerateFizzBuzz one at a time, so very
+// large limits can be streamed without holding the whole result in memory
+func (f *FizzBuzzService) Sequence(int1, int2, limit int, str1, str2 string) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for i := 1; i <= limit; i++ {
+			if !yield(fizzBuzzValue(i, int1, int2, str1, str2)) {
+				return
+			}
+		}
+	}
+}
+
+// cancelCheckInterval is the number of values generated between two checks
+// of the context, frequent enough to stop within milliseconds and rare
+// enough not to slow generation down
+const cancelCheckInterval = 1024
+
+// GenerateFizzBuzzContext is GenerateFizzBuzz interrupted once ctx is done,
+// returning the error of ctx, so that abandoned requests stop consuming CPU
+func (f *FizzBuzzService) GenerateFizzBuzzContext(ctx context.Context, int1, int2, limit int, str1, str2 string) ([]string, error) {
+	result := make([]string, 0, limit)
+	for value := range f.SequenceContext(ctx, int1, int2, limit, str1, str2) {
+		result = append(result, value)
+	}
+	if len(result) < limit {
+		return nil, ctx.Err()
+	}
+	return result, nil
+}
+
+// SequenceContext is Sequence stopping once ctx is done, checked every
+// cancelCheckInterval values
+func (f *FizzBuzzService) SequenceContext(ctx context.Context, int1, int2, limit int, str1, str2 string) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for i := 1; i <= limit; i++ {
+			if i%cancelCheckInterval == 1 && ctx.Err() != nil {
+				return
+			}
+			if !yield(fizzBuzzValue(i, int1, int2, str1, str2)) {
+				return
+			}
+		}
+	}
+}
+
+func fizzBuzzValue(i, int1, int2 int, str1, str2 string) string {
+	switch {
+	case i%int1 == 0 && i%int2 == 0:
+		return str1 + str2
+	case i%int1 == 0:
+		return str1
+	case i%int2 == 0:
+		return str2
+	default:
+		return strconv.Itoa(i)
+	}
+}
+
+// ResponseSize estimates the bytes taken by the values first to last of a
+// sequence written in format, without generating them: the /fizzbuzz body
+// for output.FormatJSON, also used for the other JSON responses, and the
+// encodings of output.Write otherwise. The estimate never falls below the
+// actual size, so it can bound a response before it is built.
+func ResponseSize(format string, int1, int2, first, last int, str1, str2 string) int {
+	if first < 1 || last < first || int1 < 1 || int2 < 1 {
+		return 0
+	}
+	multiples := func(k int) int {
+		if k <= 0 {
+			return 0
+		}
+		return last/k - (first-1)/k
+	}
+	both := multiples(lcm(int1, int2))
+	only1 := multiples(int1) - both
+	only2 := multiples(int2) - both
+	numbers := last - first + 1 - only1 - only2 - both
+
+	// numbers and row numbers are counted at the width of the last one
+	width := len(strconv.Itoa(last))
+	var value func(string) int // length of an encoded word
+	var number, overhead int   // length of an encoded number, bytes around the values
+	switch format {
+	case output.FormatNDJSON:
+		// a JSON string per line
+		value = func(s string) int { return jsonLength(s) + 1 }
+		number = width + 3
+	case output.FormatCSV:
+		// a "n,value" row per value after the header
+		value = func(s string) int { return width + csvLength(s) + 2 }
+		number, overhead = 2*width+2, len("n,value\n")
+	case output.FormatText:
+		value = func(s string) int { return len(s) + 1 }
+		number = width + 1
+	default:
+		// {"result":[...]}, values being quoted, escaped and followed by a
+		// comma
+		value = func(s string) int { return jsonLength(s) + 1 }
+		number, overhead = width+3, len(`{"result":[]}`+"\n")
+	}
+	return overhead + only1*value(str1) + only2*value(str2) + both*value(str1+str2) +
+		numbers*number
+}
+
+// lcm returns the least common multiple of a and b, 0 when it overflows
+func lcm(a, b int) int {
+	x, y := a, b
+	for y != 0 {
+		x, y = y, x%y
+	}
+	n := a / x
+	if n > math.MaxInt/b {
+		return 0
+	}
+	return n * b
+}
+
+// jsonLength returns the length of s encoded as a JSON string
+func jsonLength(s string) int {
+	encoded, _ := json.Marshal(s)
+	return len(encoded)
+}
+
+// csvLength returns the length of s encoded as a CSV field
+func csvLength(s string) int {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	_ = w.Write([]string{"", s})
+	w.Flush()
+	// the empty field is followed by a comma, the row by a newline
+	return b.Len() - 2
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fizzbuzz-server/internal/output"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseSize(t *testing.T) {
+	s := NewFizzBuzzService()
+	tests := []struct {
+		name        string
+		int1, int2  int
+		first, last int
+		str1, str2  string
+	}{
+		{"classic", 3, 5, 1, 100, "fizz", "buzz"},
+		{"same divisors", 4, 4, 1, 1000, "a", "b"},
+		{"escaped words", 2, 3, 1, 50, `"<q>"`, "é\n"},
+		{"commas", 3, 5, 1, 30, "a,b", "c"},
+		{"range", 3, 5, 91, 120, "fizz", "buzz"},
+		{"huge divisors", math.MaxInt, math.MaxInt - 1, 1, 10, "fizz", "buzz"},
+	}
+	for _, tt := range tests {
+		for _, format := range output.Formats {
+			t.Run(tt.name+"/"+format, func(t *testing.T) {
+				values := slices.Collect(s.Sequence(tt.int1, tt.int2, tt.last, tt.str1, tt.str2))
+				var encoded bytes.Buffer
+				require.NoError(t, output.Write(&encoded, format, slices.Values(values[tt.first-1:])))
+
+				size := ResponseSize(format, tt.int1, tt.int2, tt.first, tt.last, tt.str1, tt.str2)
+				assert.GreaterOrEqual(t, size, encoded.Len())
+				// numbers are counted at the width of the last one
+				assert.LessOrEqual(t, size, 2*encoded.Len())
+			})
+		}
+	}
+
+	// csv rows are larger than the JSON values of numbers
+	assert.Greater(t, ResponseSize(output.FormatCSV, 3, 5, 1, 10000, "fizz", "buzz"),
+		ResponseSize(output.FormatJSON, 3, 5, 1, 10000, "fizz", "buzz"))
+	assert.Zero(t, ResponseSize(output.FormatJSON, 3, 5, 10, 9, "fizz", "buzz"))
+}
+
+func TestGenerateFizzBuzzContext(t *testing.T) {
+	s := NewFizzBuzzService()
+	result, err := s.GenerateFizzBuzzContext(context.Background(), 3, 5, 15, "fizz", "buzz")
+	require.NoError(t, err)
+	assert.Equal(t, s.GenerateFizzBuzz(3, 5, 15, "fizz", "buzz"), result)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err = s.GenerateFizzBuzzContext(ctx, 3, 5, 1_000_000, "fizz", "buzz")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, result)
+
+	// a sequence stops within one check interval of the cancellation
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	count := 0
+	for range s.SequenceContext(ctx, 3, 5, 1_000_000, "fizz", "buzz") {
+		if count++; count == 10 {
+			cancel()
+		}
+	}
+	assert.Equal(t, cancelCheckInterval, count)
+}
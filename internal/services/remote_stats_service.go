@@ -0,0 +1,174 @@
+package services
+
+import (
+	"fizzbuzz-server/internal/apps/contracts"
+	"fizzbuzz-server/internal/entities"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RemoteStatsService is the stats service of a prefork worker. Every call is
+// made on the StatsAggregator of the master process, so that requests are
+// counted once across workers and every worker reads the merged counters.
+// Subscribers are notified of the changes of every tenant.
+type RemoteStatsService struct {
+	client  *rpc.Client
+	onError func(error)
+	closed  *atomic.Bool
+	tenant  string              // empty for the default namespace
+	root    *RemoteStatsService // the default namespace
+
+	viewsMu sync.Mutex
+	views   map[string]*RemoteStatsService // tenant namespaces, on the root
+
+	subscriptions
+}
+
+// DialStatsService connects to the aggregator listening on the Unix socket
+// at path. onError receives the failures of calls that do not return an
+// error, the counts and reads they carried are lost.
+func DialStatsService(path string, onError func(error)) (*RemoteStatsService, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	r := &RemoteStatsService{
+		client:  rpc.NewClientWithCodec(jsonrpc.NewClientCodec(conn)),
+		onError: onError,
+		closed:  &atomic.Bool{},
+		views:   map[string]*RemoteStatsService{},
+	}
+	r.root = r
+	go r.watch()
+	return r, nil
+}
+
+// watch notifies the subscribers of every change made through the
+// aggregator, by any worker
+func (r *RemoteStatsService) watch() {
+	var version uint64
+	for {
+		var next uint64
+		if err := r.client.Call(statsRPCName+".Changes", version, &next); err != nil {
+			if !r.closed.Load() {
+				r.onError(err)
+			}
+			return
+		}
+		if next != version {
+			version = next
+			r.notify()
+			r.viewsMu.Lock()
+			views := make([]*RemoteStatsService, 0, len(r.views))
+			for _, view := range r.views {
+				views = append(views, view)
+			}
+			r.viewsMu.Unlock()
+			for _, view := range views {
+				view.notify()
+			}
+		}
+	}
+}
+
+// Tenant returns the counters of the tenant name, an empty name returns the
+// default namespace. Namespaces share the connection of the default one.
+func (r *RemoteStatsService) Tenant(name string) contracts.StatsServiceIface {
+	root := r.root
+	if name == "" {
+		return root
+	}
+	root.viewsMu.Lock()
+	defer root.viewsMu.Unlock()
+	view, ok := root.views[name]
+	if !ok {
+		view = &RemoteStatsService{
+			client:  root.client,
+			onError: root.onError,
+			closed:  root.closed,
+			tenant:  name,
+			root:    root,
+		}
+		root.views[name] = view
+	}
+	return view
+}
+
+// Tenants returns the names of the tenants having counters, sorted
+func (r *RemoteStatsService) Tenants() []string {
+	var names []string
+	if err := r.call("Tenants", struct{}{}, &names); err != nil {
+		r.onError(err)
+	}
+	return names
+}
+
+// Close disconnects from the aggregator, for every namespace
+func (r *RemoteStatsService) Close() error {
+	r.closed.Store(true)
+	return r.client.Close()
+}
+
+func (r *RemoteStatsService) call(method string, args, reply any) error {
+	return r.client.Call(statsRPCName+"."+method, args, reply)
+}
+
+func (r *RemoteStatsService) ParseStatsKey(key string) (entities.StatsKeys, error) {
+	return parseStatsKey(key)
+}
+
+// Record counts one request
+func (r *RemoteStatsService) Record(req entities.FizzBuzzRequest) {
+	if err := r.call("Record", StatsRecordArgs{Tenant: r.tenant, Request: req}, &struct{}{}); err != nil {
+		r.onError(err)
+	}
+}
+
+// RecordClient counts one request of an identified client
+func (r *RemoteStatsService) RecordClient(client string) {
+	if err := r.call("RecordClient", StatsClientArgs{Tenant: r.tenant, Client: client}, &struct{}{}); err != nil {
+		r.onError(err)
+	}
+}
+
+// Clients returns the requests of every identified client, most active first
+func (r *RemoteStatsService) Clients() []entities.ClientStats {
+	var clients []entities.ClientStats
+	if err := r.call("Clients", r.tenant, &clients); err != nil {
+		r.onError(err)
+	}
+	return clients
+}
+
+// Top returns the n most frequent requests, within window when it is not zero
+func (r *RemoteStatsService) Top(n int, window time.Duration) ([]entities.StatsEntry, error) {
+	var top []entities.StatsEntry
+	if err := r.call("Top", StatsTopArgs{Tenant: r.tenant, N: n, Window: window}, &top); err != nil {
+		return nil, err
+	}
+	return top, nil
+}
+
+// Reset clears every counter, see StatsService.Reset
+func (r *RemoteStatsService) Reset() {
+	if err := r.call("Reset", r.tenant, &struct{}{}); err != nil {
+		r.onError(err)
+	}
+}
+
+// Export returns the all-time counters, see StatsService.Export
+func (r *RemoteStatsService) Export() (entities.StatsSnapshot, error) {
+	var snapshot entities.StatsSnapshot
+	err := r.call("Export", r.tenant, &snapshot)
+	return snapshot, err
+}
+
+// Import loads the counters of a snapshot, adding them to the current ones
+// when merge is set and replacing them otherwise
+func (r *RemoteStatsService) Import(snapshot entities.StatsSnapshot, merge bool) error {
+	return r.call("Import", StatsImportArgs{Tenant: r.tenant, Snapshot: snapshot, Merge: merge}, &struct{}{})
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"errors"
+	"fizzbuzz-server/internal/entities"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"sync"
+	"time"
+)
+
+// statsRPCName is the name of the aggregator calls, e.g. "Stats.Record"
+const statsRPCName = "Stats"
+
+// Every call names the tenant whose counters it uses, empty for the default
+// namespace
+
+// StatsRecordArgs are the arguments of Stats.Record
+type StatsRecordArgs struct {
+	Tenant  string
+	Request entities.FizzBuzzRequest
+}
+
+// StatsClientArgs are the arguments of Stats.RecordClient
+type StatsClientArgs struct {
+	Tenant string
+	Client string
+}
+
+// StatsTopArgs are the arguments of Stats.Top
+type StatsTopArgs struct {
+	Tenant string
+	N      int
+	Window time.Duration
+}
+
+// StatsImportArgs are the arguments of Stats.Import
+type StatsImportArgs struct {
+	Tenant   string
+	Snapshot entities.StatsSnapshot
+	Merge    bool
+}
+
+// StatsAggregator serves a stats service to the worker processes of prefork
+// mode over JSON-RPC on a local socket, so that every worker counts into and
+// reads from the same counters, see RemoteStatsService
+type StatsAggregator struct {
+	server      *rpc.Server
+	unsubscribe func()
+
+	mu      sync.Mutex
+	version uint64        // number of changes of the counters, of any tenant
+	changed chan struct{} // closed on the next change
+	done    chan struct{}
+	once    sync.Once
+}
+
+// NewStatsAggregator returns an aggregator serving stats and the counters of
+// its tenants
+func NewStatsAggregator(stats *StatsService) *StatsAggregator {
+	a := &StatsAggregator{
+		server:  rpc.NewServer(),
+		changed: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	_ = a.server.RegisterName(statsRPCName, &statsRPC{stats: stats, aggregator: a})
+	a.unsubscribe = stats.SubscribeAll(a.change)
+	return a
+}
+
+func (a *StatsAggregator) change() {
+	a.mu.Lock()
+	a.version++
+	close(a.changed)
+	a.changed = make(chan struct{})
+	a.mu.Unlock()
+}
+
+// wait blocks until the counters changed after version seen, or the
+// aggregator is closed, and returns the current version
+func (a *StatsAggregator) wait(seen uint64) uint64 {
+	a.mu.Lock()
+	version, changed := a.version, a.changed
+	a.mu.Unlock()
+	if version != seen {
+		return version
+	}
+	select {
+	case <-changed:
+	case <-a.done:
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.version
+}
+
+// Serve accepts worker connections on ln until ln is closed
+func (a *StatsAggregator) Serve(ln net.Listener) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go a.server.ServeCodec(jsonrpc.NewServerCodec(conn))
+	}
+}
+
+// Close releases the workers waiting for changes
+func (a *StatsAggregator) Close() {
+	a.once.Do(func() {
+		a.unsubscribe()
+		close(a.done)
+	})
+}
+
+// statsRPC exposes the stats service as net/rpc calls
+type statsRPC struct {
+	stats      *StatsService
+	aggregator *StatsAggregator
+}
+
+func (r *statsRPC) Record(args StatsRecordArgs, _ *struct{}) error {
+	r.stats.Tenant(args.Tenant).Record(args.Request)
+	return nil
+}
+
+func (r *statsRPC) RecordClient(args StatsClientArgs, _ *struct{}) error {
+	r.stats.Tenant(args.Tenant).RecordClient(args.Client)
+	return nil
+}
+
+func (r *statsRPC) Clients(tenant string, clients *[]entities.ClientStats) error {
+	*clients = r.stats.Tenant(tenant).Clients()
+	return nil
+}
+
+func (r *statsRPC) Top(args StatsTopArgs, top *[]entities.StatsEntry) error {
+	entries, err := r.stats.Tenant(args.Tenant).Top(args.N, args.Window)
+	*top = entries
+	return err
+}
+
+func (r *statsRPC) Reset(tenant string, _ *struct{}) error {
+	r.stats.Tenant(tenant).Reset()
+	return nil
+}
+
+func (r *statsRPC) Export(tenant string, snapshot *entities.StatsSnapshot) error {
+	var err error
+	*snapshot, err = r.stats.Tenant(tenant).Export()
+	return err
+}
+
+func (r *statsRPC) Import(args StatsImportArgs, _ *struct{}) error {
+	return r.stats.Tenant(args.Tenant).Import(args.Snapshot, args.Merge)
+}
+
+func (r *statsRPC) Tenants(_ struct{}, names *[]string) error {
+	*names = r.stats.Tenants()
+	return nil
+}
+
+// Changes waits for the counters to change after version seen
+func (r *statsRPC) Changes(seen uint64, version *uint64) error {
+	*version = r.aggregator.wait(seen)
+	return nil
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"fizzbuzz-server/internal/entities"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startAggregator serves stats on a Unix socket and returns its path
+func startAggregator(t *testing.T, stats *StatsService) string {
+	path := filepath.Join(t.TempDir(), "stats.sock")
+	ln, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	aggregator := NewStatsAggregator(stats)
+	go func() { _ = aggregator.Serve(ln) }()
+	t.Cleanup(func() {
+		aggregator.Close()
+		_ = ln.Close()
+	})
+	return path
+}
+
+func dialRemote(t *testing.T, path string) *RemoteStatsService {
+	remote, err := DialStatsService(path, func(err error) { t.Errorf("aggregator call failed: %v", err) })
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = remote.Close() })
+	return remote
+}
+
+func TestStatsAggregator_MergesWorkers(t *testing.T) {
+	stats := NewStatsService(time.Hour)
+	path := startAggregator(t, stats)
+	req := entities.FizzBuzzRequest{Int1: 3, Int2: 5, Limit: 15, Str1: "fizz", Str2: "buzz"}
+
+	const workers, perWorker = 4, 50
+	remotes := make([]*RemoteStatsService, workers)
+	var wg sync.WaitGroup
+	for i := range remotes {
+		remotes[i] = dialRemote(t, path)
+		wg.Add(1)
+		go func(remote *RemoteStatsService) {
+			defer wg.Done()
+			for range perWorker {
+				remote.Record(req)
+			}
+			remote.RecordClient("alice")
+		}(remotes[i])
+	}
+	wg.Wait()
+
+	// every worker reads the merged counters
+	for _, remote := range remotes {
+		top, err := remote.Top(1, 0)
+		require.NoError(t, err)
+		require.Len(t, top, 1)
+		assert.Equal(t, workers*perWorker, top[0].Hits)
+
+		top, err = remote.Top(1, 10*time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, workers*perWorker, top[0].Hits)
+
+		assert.Equal(t, []entities.ClientStats{{Client: "alice", Requests: workers}}, remote.Clients())
+	}
+
+	_, err := remotes[0].Top(1, 2*time.Hour)
+	assert.ErrorContains(t, err, "window must be between")
+
+	snapshot, err := remotes[1].Export()
+	require.NoError(t, err)
+	remotes[2].Reset()
+	top, err := stats.Top(0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, top)
+	require.NoError(t, remotes[3].Import(snapshot, true))
+	top, err = stats.Top(0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, workers*perWorker, top[0].Hits)
+
+	snapshot.Entries[0].Hits = -1
+	assert.ErrorContains(t, remotes[0].Import(snapshot, false), "negative hits")
+
+	// tenants count apart, in the namespaces of the master
+	remotes[0].Tenant("acme").Record(req)
+	remotes[1].Tenant("acme").RecordClient("bob")
+	assert.Equal(t, []string{"acme"}, remotes[2].Tenants())
+	top, err = stats.Tenant("acme").Top(0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, top[0].Hits)
+	assert.Equal(t, []entities.ClientStats{{Client: "bob", Requests: 1}}, remotes[3].Tenant("acme").Clients())
+	top, err = remotes[3].Top(0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, workers*perWorker, top[0].Hits)
+}
+
+func TestStatsAggregator_Subscribe(t *testing.T) {
+	stats := NewStatsService(time.Hour)
+	path := startAggregator(t, stats)
+	watching, recording := dialRemote(t, path), dialRemote(t, path)
+
+	changed := make(chan struct{}, 1)
+	unsubscribe := watching.Subscribe(func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+	defer unsubscribe()
+
+	// changes made by another worker, or by the master, are notified
+	recording.Record(entities.FizzBuzzRequest{Int1: 3, Int2: 5, Limit: 15, Str1: "fizz", Str2: "buzz"})
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("change of another worker not notified")
+	}
+	stats.Reset()
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("reset not notified")
+	}
+
+	// the changes of tenants reach the workers too
+	unsubscribe = watching.Tenant("acme").Subscribe(func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+	defer unsubscribe()
+	stats.Tenant("acme").Reset()
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("change of a tenant not notified")
+	}
+}
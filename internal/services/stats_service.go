@@ -1,44 +1,462 @@
 package services
 
 import (
+	"encoding/json"
+	"errors"
+	"fizzbuzz-server/internal/apps/contracts"
 	"fizzbuzz-server/internal/entities"
+	"fizzbuzz-server/pkg/ulog"
 	"fmt"
-	"strconv"
-	"strings"
+	"sort"
+	"sync"
+	"time"
 )
 
+// statsBucketWidth is the granularity of windowed statistics
+const statsBucketWidth = time.Minute
+
+// statsBucket counts the requests received during one bucket width
+type statsBucket struct {
+	start  time.Time
+	counts map[string]int
+}
+
 type StatsService struct {
+	stats     entities.RequestStats
+	mu        sync.Mutex // guards buckets
+	buckets   []statsBucket
+	retention time.Duration
+	now       func() time.Time
+
+	clientsMu sync.Mutex
+	clients   map[string]int // requests per client identity
+
+	tenantsMu sync.Mutex
+	tenants   map[string]*StatsService // namespaces of the tenants, created on first use
+	parent    *StatsService            // the default namespace, set on tenant ones
+
+	subscriptions
+	all subscriptions // changes of every namespace, on the default one
+}
+
+// NewStatsService returns an empty stats store keeping per-minute counts for
+// retention, the longest window that can be queried
+func NewStatsService(retention time.Duration) *StatsService {
+	return &StatsService{
+		stats:     entities.RequestStats{Counts: make(map[string]int)},
+		clients:   make(map[string]int),
+		retention: retention,
+		now:       time.Now,
+	}
 }
 
-func NewStatsService() *StatsService {
-	return &StatsService{}
+// Tenant returns the counters of the tenant name, kept apart from those of
+// the requests without tenant. An empty name returns the default namespace.
+func (s *StatsService) Tenant(name string) contracts.StatsServiceIface {
+	if s.parent != nil {
+		return s.parent.Tenant(name)
+	}
+	if name == "" {
+		return s
+	}
+	return s.tenant(name)
+}
+
+func (s *StatsService) tenant(name string) *StatsService {
+	s.tenantsMu.Lock()
+	defer s.tenantsMu.Unlock()
+	tenant, ok := s.tenants[name]
+	if !ok {
+		tenant = NewStatsService(s.retention)
+		tenant.now = s.now
+		tenant.parent = s
+		if s.tenants == nil {
+			s.tenants = map[string]*StatsService{}
+		}
+		s.tenants[name] = tenant
+	}
+	return tenant
+}
+
+// Tenants returns the names of the tenants having counters, sorted
+func (s *StatsService) Tenants() []string {
+	if s.parent != nil {
+		return s.parent.Tenants()
+	}
+	s.tenantsMu.Lock()
+	names := make([]string, 0, len(s.tenants))
+	for name := range s.tenants {
+		names = append(names, name)
+	}
+	s.tenantsMu.Unlock()
+	sort.Strings(names)
+	return names
+}
+
+// SubscribeAll registers fn to be called after every change of the counters
+// of any namespace, see Subscribe
+func (s *StatsService) SubscribeAll(fn func()) (unsubscribe func()) {
+	if s.parent != nil {
+		return s.parent.SubscribeAll(fn)
+	}
+	return s.all.Subscribe(fn)
+}
+
+// changed notifies the subscribers of the namespace and those of every one
+func (s *StatsService) changed() {
+	s.notify()
+	if s.parent != nil {
+		s.parent.all.notify()
+	} else {
+		s.all.notify()
+	}
+}
+
+// StatsKey builds the key under which a request is counted: the request
+// fields as a JSON array, so that words may contain any character
+func StatsKey(int1, int2, limit int, str1, str2 string) string {
+	key, _ := json.Marshal([]any{int1, int2, limit, str1, str2})
+	return string(key)
 }
 
 func (s *StatsService) ParseStatsKey(key string) (entities.StatsKeys, error) {
+	return parseStatsKey(key)
+}
+
+func parseStatsKey(key string) (entities.StatsKeys, error) {
 	var statsKeys entities.StatsKeys
-	parts := strings.Split(key, ",")
-	if len(parts) != 5 {
-		return statsKeys, fmt.Errorf("invalid stats key format")
+	var parts []json.RawMessage
+	if err := json.Unmarshal([]byte(key), &parts); err != nil || len(parts) != 5 {
+		return statsKeys, fmt.Errorf("invalid stats key format: %q", key)
+	}
+	for i, dst := range []any{&statsKeys.Int1, &statsKeys.Int2, &statsKeys.Limit, &statsKeys.Str1, &statsKeys.Str2} {
+		if err := json.Unmarshal(parts[i], dst); err != nil {
+			return statsKeys, fmt.Errorf("invalid stats key %q: %w", key, err)
+		}
 	}
+	return statsKeys, nil
+}
 
-	int1, err := strconv.Atoi(parts[0])
-	if err != nil {
-		return statsKeys, err
+// Record counts one request
+func (s *StatsService) Record(req entities.FizzBuzzRequest) {
+	key := StatsKey(req.Int1, req.Int2, req.Limit, req.Str1, req.Str2)
+
+	s.stats.Mutex.Lock()
+	s.stats.Counts[key]++
+	s.stats.Mutex.Unlock()
+
+	s.mu.Lock()
+	bucket := s.currentBucket()
+	bucket.counts[key]++
+	s.mu.Unlock()
+
+	s.changed()
+}
+
+// RecordClient counts one request of an identified client
+func (s *StatsService) RecordClient(client string) {
+	s.clientsMu.Lock()
+	s.clients[client]++
+	s.clientsMu.Unlock()
+}
+
+// Clients returns the requests of every identified client, most active
+// first and ties by identity
+func (s *StatsService) Clients() []entities.ClientStats {
+	s.clientsMu.Lock()
+	clients := make([]entities.ClientStats, 0, len(s.clients))
+	for client, requests := range s.clients {
+		clients = append(clients, entities.ClientStats{Client: client, Requests: requests})
+	}
+	s.clientsMu.Unlock()
+
+	sort.Slice(clients, func(i, j int) bool {
+		if clients[i].Requests != clients[j].Requests {
+			return clients[i].Requests > clients[j].Requests
+		}
+		return clients[i].Client < clients[j].Client
+	})
+	return clients
+}
+
+// currentBucket returns the bucket for now, dropping expired ones.
+// It must be called with s.mu held.
+func (s *StatsService) currentBucket() *statsBucket {
+	start := s.now().Truncate(statsBucketWidth)
+	if n := len(s.buckets); n == 0 || s.buckets[n-1].start.Before(start) {
+		s.buckets = append(s.buckets, statsBucket{start: start, counts: map[string]int{}})
 	}
-	int2, err := strconv.Atoi(parts[1])
+
+	cutoff := start.Add(-s.retention)
+	expired := 0
+	for expired < len(s.buckets) && !s.buckets[expired].start.After(cutoff) {
+		expired++
+	}
+	s.buckets = s.buckets[expired:]
+
+	return &s.buckets[len(s.buckets)-1]
+}
+
+// Top returns the n most frequent requests, most frequent first and ties in
+// key order. A zero window covers all requests since start (or the last
+// reset), otherwise only requests received within the window are counted,
+// to the minute. n <= 0 returns every entry.
+func (s *StatsService) Top(n int, window time.Duration) ([]entities.StatsEntry, error) {
+	if window < 0 || window > s.retention {
+		return nil, fmt.Errorf("window must be between 0 and %s", s.retention)
+	}
+
+	var counts map[string]int
+	if window == 0 {
+		s.stats.Mutex.RLock()
+		counts = make(map[string]int, len(s.stats.Counts))
+		for key, count := range s.stats.Counts {
+			counts[key] = count
+		}
+		s.stats.Mutex.RUnlock()
+	} else {
+		counts = s.windowCounts(window)
+	}
+
+	entries, err := topEntries(counts, n)
 	if err != nil {
-		return statsKeys, err
+		// the entries that could be parsed are still served
+		ulog.Errorf("stats: %v", err)
+	}
+	return entries, nil
+}
+
+// topEntries returns the n largest counts, largest first and ties in key
+// order, every count when n <= 0. Keys that cannot be parsed are skipped and
+// reported in the error, along with the entries of the others.
+func topEntries(counts map[string]int, n int) ([]entities.StatsEntry, error) {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if n > 0 && len(keys) > n {
+		keys = keys[:n]
+	}
+
+	entries := make([]entities.StatsEntry, 0, len(keys))
+	var errs []error
+	for _, key := range keys {
+		parts, err := parseStatsKey(key)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		entries = append(entries, entities.StatsEntry{
+			Int1:  parts.Int1,
+			Int2:  parts.Int2,
+			Limit: parts.Limit,
+			Str1:  parts.Str1,
+			Str2:  parts.Str2,
+			Hits:  counts[key],
+		})
+	}
+	return entries, errors.Join(errs...)
+}
+
+// TopAcrossTenants returns the n most frequent requests like Top, adding up
+// the counters of the default namespace and of every tenant
+func TopAcrossTenants(stats contracts.StatsServiceIface, n int, window time.Duration) ([]entities.StatsEntry, error) {
+	counts := map[string]int{}
+	for _, name := range append([]string{""}, stats.Tenants()...) {
+		entries, err := stats.Tenant(name).Top(0, window)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			counts[StatsKey(e.Int1, e.Int2, e.Limit, e.Str1, e.Str2)] += e.Hits
+		}
 	}
-	limit, err := strconv.Atoi(parts[2])
+	// the keys are built above, they all parse
+	return topEntries(counts, n)
+}
+
+func (s *StatsService) windowCounts(window time.Duration) map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	from := s.now().Add(-window).Truncate(statsBucketWidth)
+	counts := map[string]int{}
+	for _, bucket := range s.buckets {
+		if bucket.start.Before(from) {
+			continue
+		}
+		for key, count := range bucket.counts {
+			counts[key] += count
+		}
+	}
+	return counts
+}
+
+// Reset clears every counter, those of the tenants too on the default
+// namespace
+func (s *StatsService) Reset() {
+	if s.parent == nil {
+		for _, name := range s.Tenants() {
+			s.tenant(name).Reset()
+		}
+	}
+
+	s.stats.Mutex.Lock()
+	s.stats.Counts = make(map[string]int)
+	s.stats.Mutex.Unlock()
+
+	s.mu.Lock()
+	s.buckets = nil
+	s.mu.Unlock()
+
+	s.clientsMu.Lock()
+	s.clients = make(map[string]int)
+	s.clientsMu.Unlock()
+
+	s.changed()
+}
+
+// Export returns the all-time counters, with those of every tenant on the
+// default namespace. Windowed and client counts are not exported. Counters
+// that cannot be exported are reported in the error, the snapshot holding
+// the others.
+func (s *StatsService) Export() (entities.StatsSnapshot, error) {
+	s.stats.Mutex.RLock()
+	counts := make(map[string]int, len(s.stats.Counts))
+	for key, count := range s.stats.Counts {
+		counts[key] = count
+	}
+	s.stats.Mutex.RUnlock()
+
+	entries, err := topEntries(counts, 0)
+	snapshot := entities.StatsSnapshot{
+		ExportedAt: s.now().UTC(),
+		Entries:    entries,
+	}
+	if s.parent != nil {
+		return snapshot, err
+	}
+	errs := []error{err}
+	for _, name := range s.Tenants() {
+		if snapshot.Tenants == nil {
+			snapshot.Tenants = map[string]entities.StatsSnapshot{}
+		}
+		tenant, err := s.tenant(name).Export()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", name, err))
+		}
+		snapshot.Tenants[name] = tenant
+	}
+	return snapshot, errors.Join(errs...)
+}
+
+// Import loads the counters of a snapshot, adding them to the current ones
+// when merge is set and replacing them otherwise. On the default namespace,
+// the tenants of the snapshot are loaded into their namespaces and, unless
+// merge is set, the counters of the other tenants are cleared.
+func (s *StatsService) Import(snapshot entities.StatsSnapshot, merge bool) error {
+	if len(snapshot.Tenants) > 0 && s.parent != nil {
+		return fmt.Errorf("tenant counters can only be imported without tenant")
+	}
+	counts, err := snapshotCounts(snapshot)
 	if err != nil {
-		return statsKeys, err
+		return err
+	}
+	tenantCounts := make(map[string]map[string]int, len(snapshot.Tenants))
+	for name, tenant := range snapshot.Tenants {
+		if len(tenant.Tenants) > 0 {
+			return fmt.Errorf("tenant %s: nested tenants", name)
+		}
+		if tenantCounts[name], err = snapshotCounts(tenant); err != nil {
+			return fmt.Errorf("tenant %s: %w", name, err)
+		}
 	}
 
-	statsKeys.Int1 = int1
-	statsKeys.Int2 = int2
-	statsKeys.Limit = limit
-	statsKeys.Str1 = parts[3]
-	statsKeys.Str2 = parts[4]
+	s.load(counts, merge)
+	if s.parent != nil {
+		return nil
+	}
+	for name, counts := range tenantCounts {
+		s.tenant(name).load(counts, merge)
+	}
+	if !merge {
+		for _, name := range s.Tenants() {
+			if _, ok := tenantCounts[name]; !ok {
+				s.tenant(name).load(nil, false)
+			}
+		}
+	}
+	return nil
+}
 
-	return statsKeys, nil
+// snapshotCounts returns the all-time counters of a snapshot by stats key
+func snapshotCounts(snapshot entities.StatsSnapshot) (map[string]int, error) {
+	counts := make(map[string]int, len(snapshot.Entries))
+	for _, entry := range snapshot.Entries {
+		if entry.Hits < 0 {
+			return nil, fmt.Errorf("negative hits for %d,%d,%d,%s,%s", entry.Int1, entry.Int2, entry.Limit, entry.Str1, entry.Str2)
+		}
+		counts[StatsKey(entry.Int1, entry.Int2, entry.Limit, entry.Str1, entry.Str2)] += entry.Hits
+	}
+	return counts, nil
+}
+
+func (s *StatsService) load(counts map[string]int, merge bool) {
+	s.stats.Mutex.Lock()
+	if !merge {
+		s.stats.Counts = make(map[string]int, len(counts))
+	}
+	for key, count := range counts {
+		s.stats.Counts[key] += count
+	}
+	s.stats.Mutex.Unlock()
+
+	s.changed()
+}
+
+// subscriptions calls the functions registered with Subscribe after every
+// change of the counters
+type subscriptions struct {
+	subsMu    sync.Mutex
+	subs      map[int]func()
+	nextSubID int
+}
+
+// Subscribe registers fn to be called after every change of the counters.
+// fn runs on the goroutine making the change and must not block.
+// It returns a function removing the subscription.
+func (s *subscriptions) Subscribe(fn func()) (unsubscribe func()) {
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+	if s.subs == nil {
+		s.subs = map[int]func(){}
+	}
+	id := s.nextSubID
+	s.nextSubID++
+	s.subs[id] = fn
+
+	return func() {
+		s.subsMu.Lock()
+		defer s.subsMu.Unlock()
+		delete(s.subs, id)
+	}
+}
+
+func (s *subscriptions) notify() {
+	s.subsMu.Lock()
+	subs := make([]func(), 0, len(s.subs))
+	for _, sub := range s.subs {
+		subs = append(subs, sub)
+	}
+	s.subsMu.Unlock()
+
+	for _, sub := range subs {
+		sub()
+	}
 }
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"fizzbuzz-server/internal/entities"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatsService_TopWithWindow(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := NewStatsService(time.Hour)
+	s.now = func() time.Time { return now }
+
+	old := entities.FizzBuzzRequest{Int1: 3, Int2: 5, Limit: 15, Str1: "fizz", Str2: "buzz"}
+	recent := entities.FizzBuzzRequest{Int1: 2, Int2: 7, Limit: 10, Str1: "hello", Str2: "world"}
+	for i := 0; i < 3; i++ {
+		s.Record(old)
+	}
+	now = now.Add(30 * time.Minute)
+	s.Record(recent)
+
+	top, err := s.Top(1, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, top[0].Hits)
+	assert.Equal(t, "fizz", top[0].Str1)
+
+	top, err = s.Top(1, 10*time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, top[0].Hits)
+	assert.Equal(t, "hello", top[0].Str1)
+
+	_, err = s.Top(1, 2*time.Hour)
+	assert.Error(t, err)
+}
+
+func TestStatsService_ExportImport(t *testing.T) {
+	s := NewStatsService(time.Hour)
+	req := entities.FizzBuzzRequest{Int1: 3, Int2: 5, Limit: 15, Str1: "fizz", Str2: "buzz"}
+	s.Record(req)
+	s.Record(req)
+	snapshot, err := s.Export()
+	assert.NoError(t, err)
+
+	other := NewStatsService(time.Hour)
+	other.Record(req)
+	assert.NoError(t, other.Import(snapshot, true))
+	top, err := other.Top(0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, top[0].Hits)
+
+	assert.NoError(t, other.Import(snapshot, false))
+	top, err = other.Top(0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, top[0].Hits)
+
+	other.Reset()
+	top, err = other.Top(0, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, top)
+}
+
+func TestStatsService_WordsWithCommas(t *testing.T) {
+	s := NewStatsService(time.Hour)
+	req := entities.FizzBuzzRequest{Int1: 3, Int2: 5, Limit: 15, Str1: "a,b", Str2: `"c",d`}
+	s.Record(req)
+	s.Record(entities.FizzBuzzRequest{Int1: 3, Int2: 5, Limit: 15, Str1: "a", Str2: "b"})
+	s.Record(req)
+
+	top, err := s.Top(0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []entities.StatsEntry{
+		{Int1: 3, Int2: 5, Limit: 15, Str1: "a,b", Str2: `"c",d`, Hits: 2},
+		{Int1: 3, Int2: 5, Limit: 15, Str1: "a", Str2: "b", Hits: 1},
+	}, top)
+
+	snapshot, err := s.Export()
+	assert.NoError(t, err)
+	other := NewStatsService(time.Hour)
+	assert.NoError(t, other.Import(snapshot, false))
+	imported, err := other.Top(0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, top, imported)
+}
+
+func TestStatsService_InvalidKeys(t *testing.T) {
+	// counters that cannot be parsed do not hide the others
+	s := NewStatsService(time.Hour)
+	s.Record(entities.FizzBuzzRequest{Int1: 3, Int2: 5, Limit: 15, Str1: "fizz", Str2: "buzz"})
+	s.stats.Counts["3,5,15,fizz"] = 4
+
+	top, err := s.Top(0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []entities.StatsEntry{{Int1: 3, Int2: 5, Limit: 15, Str1: "fizz", Str2: "buzz", Hits: 1}}, top)
+
+	snapshot, err := s.Export()
+	assert.ErrorContains(t, err, `invalid stats key format: "3,5,15,fizz"`)
+	assert.Equal(t, top, snapshot.Entries)
+}
+
+func TestStatsService_Subscribe(t *testing.T) {
+	s := NewStatsService(time.Hour)
+	changes := 0
+	unsubscribe := s.Subscribe(func() { changes++ })
+
+	s.Record(entities.FizzBuzzRequest{Int1: 3, Int2: 5, Limit: 15, Str1: "fizz", Str2: "buzz"})
+	assert.NoError(t, s.Import(entities.StatsSnapshot{}, true))
+	s.Reset()
+	assert.Equal(t, 3, changes)
+
+	unsubscribe()
+	s.Reset()
+	assert.Equal(t, 3, changes)
+}
+
+func TestStatsService_Clients(t *testing.T) {
+	s := NewStatsService(time.Hour)
+	s.RecordClient("bob")
+	s.RecordClient("alice")
+	s.RecordClient("carol")
+	s.RecordClient("carol")
+
+	assert.Equal(t, []entities.ClientStats{
+		{Client: "carol", Requests: 2},
+		{Client: "alice", Requests: 1},
+		{Client: "bob", Requests: 1},
+	}, s.Clients())
+
+	s.Reset()
+	assert.Empty(t, s.Clients())
+}
+
+func TestStatsService_Tenants(t *testing.T) {
+	s := NewStatsService(time.Hour)
+	fizz := entities.FizzBuzzRequest{Int1: 3, Int2: 5, Limit: 15, Str1: "fizz", Str2: "buzz"}
+	foo := entities.FizzBuzzRequest{Int1: 3, Int2: 5, Limit: 15, Str1: "foo", Str2: "bar"}
+
+	notified := 0
+	unsubscribe := s.SubscribeAll(func() { notified++ })
+	defer unsubscribe()
+	defaultNotified := 0
+	defer s.Subscribe(func() { defaultNotified++ })()
+
+	s.Record(fizz)
+	s.Tenant("acme").Record(foo)
+	s.Tenant("acme").Record(foo)
+	s.Tenant("globex").Record(fizz)
+	assert.Equal(t, 4, notified)
+	assert.Equal(t, 1, defaultNotified)
+	assert.Same(t, s, s.Tenant(""))
+	assert.Equal(t, []string{"acme", "globex"}, s.Tenant("acme").Tenants())
+
+	top, err := s.Tenant("acme").Top(0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []entities.StatsEntry{{Int1: 3, Int2: 5, Limit: 15, Str1: "foo", Str2: "bar", Hits: 2}}, top)
+	top, err = TopAcrossTenants(s, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []entities.StatsEntry{
+		{Int1: 3, Int2: 5, Limit: 15, Str1: "fizz", Str2: "buzz", Hits: 2},
+		{Int1: 3, Int2: 5, Limit: 15, Str1: "foo", Str2: "bar", Hits: 2},
+	}, top)
+
+	// a snapshot of the default namespace carries the tenants
+	snapshot, err := s.Export()
+	assert.NoError(t, err)
+	assert.Len(t, snapshot.Entries, 1)
+	assert.Equal(t, 2, snapshot.Tenants["acme"].Entries[0].Hits)
+	assert.Error(t, s.Tenant("acme").Import(snapshot, true))
+
+	other := NewStatsService(time.Hour)
+	other.Tenant("initech").Record(fizz)
+	assert.NoError(t, other.Import(snapshot, false))
+	imported, err := other.Export()
+	assert.NoError(t, err)
+	assert.Equal(t, snapshot.Entries, imported.Entries)
+	assert.Equal(t, snapshot.Tenants["acme"].Entries, imported.Tenants["acme"].Entries)
+	top, err = other.Tenant("initech").Top(0, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, top)
+
+	s.Reset()
+	top, err = TopAcrossTenants(s, 0, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, top)
+}
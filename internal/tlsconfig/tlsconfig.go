@@ -0,0 +1,200 @@
+// Package tlsconfig builds the TLS configuration of the server from the tls
+// settings and keeps it current as certificate files and settings change,
+// without restarting the listener.
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fizzbuzz-server/internal/config"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce groups the events of a certificate rotation, which usually
+// rewrites several files
+const reloadDebounce = 100 * time.Millisecond
+
+// Reloader serves the certificate, client CAs and settings last loaded
+type Reloader struct {
+	current atomic.Pointer[tls.Config]
+
+	mu  sync.Mutex // serializes reloads and guards cfg
+	cfg config.TLSConfig
+}
+
+// New loads the files named by cfg
+func New(cfg config.TLSConfig) (*Reloader, error) {
+	r := &Reloader{}
+	if err := r.Reload(cfg); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Config returns the configuration to listen with. Every handshake uses the
+// settings of the last successful reload.
+func (r *Reloader) Config() *tls.Config {
+	return &tls.Config{
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.current.Load(), nil
+		},
+	}
+}
+
+// Reload reads the files of cfg and applies it to new connections.
+// The current configuration is kept when cfg cannot be loaded.
+func (r *Reloader) Reload(cfg config.TLSConfig) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tlsConfig, err := build(cfg)
+	if err != nil {
+		return err
+	}
+	r.cfg = cfg
+	r.current.Store(tlsConfig)
+	return nil
+}
+
+func build(cfg config.TLSConfig) (*tls.Config, error) {
+	if !cfg.Enabled() {
+		return nil, errors.New("tls: cert_file and key_file are required")
+	}
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("tls: loading certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:   cfg.Version(),
+		Certificates: []tls.Certificate{cert},
+	}
+	for _, name := range cfg.CipherSuites {
+		id, ok := config.CipherSuite(name)
+		if !ok {
+			return nil, fmt.Errorf("tls: unknown cipher suite %s", name)
+		}
+		tlsConfig.CipherSuites = append(tlsConfig.CipherSuites, id)
+	}
+
+	if cfg.ClientCAFile != "" {
+		data, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("tls: loading client CAs: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("tls: no certificate found in %s", cfg.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		if cfg.ClientAuth == "optional" {
+			tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+	}
+	return tlsConfig, nil
+}
+
+// Watch reloads the files whenever they change, and the settings whenever
+// the configuration is reloaded, until ctx is done. The directories holding
+// the files are watched and any change in them triggers a reload, which also
+// covers secrets mounted through symlinks. onError receives rejected reloads.
+func (r *Reloader) Watch(ctx context.Context, onError func(error)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	cfg := r.cfg
+	r.mu.Unlock()
+	if err := watchDirs(watcher, cfg); err != nil {
+		_ = watcher.Close()
+		return err
+	}
+
+	changed := make(chan config.TLSConfig, 1)
+	unsubscribe := config.Subscribe(func(old, new *config.Config) {
+		if reflect.DeepEqual(old.TLS, new.TLS) {
+			return
+		}
+		// keep the latest settings only
+		select {
+		case <-changed:
+		default:
+		}
+		select {
+		case changed <- new.TLS:
+		default:
+		}
+	})
+
+	go func() {
+		defer watcher.Close()
+		defer unsubscribe()
+
+		timer := time.NewTimer(reloadDebounce)
+		timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op != fsnotify.Chmod {
+					timer.Reset(reloadDebounce)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				onError(err)
+			case cfg := <-changed:
+				if err := r.Reload(cfg); err != nil {
+					onError(err)
+					continue
+				}
+				if err := watchDirs(watcher, cfg); err != nil {
+					onError(err)
+				}
+			case <-timer.C:
+				r.mu.Lock()
+				cfg := r.cfg
+				r.mu.Unlock()
+				if err := r.Reload(cfg); err != nil {
+					onError(err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// watchDirs adds the directories of the files of cfg to watcher
+func watchDirs(watcher *fsnotify.Watcher, cfg config.TLSConfig) error {
+	for _, path := range []string{cfg.CertFile, cfg.KeyFile, cfg.ClientCAFile} {
+		if path == "" {
+			continue
+		}
+		dir, err := filepath.Abs(filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		if err := watcher.Add(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package tlsconfig_test
+
+import (
+	"context"
+	"crypto/tls"
+	"fizzbuzz-server/internal/config"
+	"fizzbuzz-server/internal/tlsconfig"
+	"fizzbuzz-server/internal/tlsconfig/tlstest"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serve accepts TLS connections configured by r, writing "ok" once the
+// handshake succeeded, and returns the listener address
+func serve(t *testing.T, r *tlsconfig.Reloader) string {
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", r.Config())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			if conn.(*tls.Conn).Handshake() == nil {
+				_, _ = conn.Write([]byte("ok"))
+			}
+			_ = conn.Close()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// dial connects to addr and returns the common name of the server
+// certificate once the server accepted the handshake
+func dial(ca *tlstest.CA, addr string, certs ...tls.Certificate) (string, error) {
+	conn, err := tls.Dial("tcp", addr, &tls.Config{RootCAs: ca.Pool(), ServerName: "localhost", Certificates: certs})
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	if _, err := io.ReadFull(conn, make([]byte, 2)); err != nil {
+		return "", err
+	}
+	return conn.ConnectionState().PeerCertificates[0].Subject.CommonName, nil
+}
+
+func TestReloader_ReloadsChangedFiles(t *testing.T) {
+	ca := tlstest.NewCA(t)
+	dir := t.TempDir()
+	certFile, keyFile := ca.WriteCert(t, dir, "server-1")
+	cfg := config.TLSConfig{CertFile: certFile, KeyFile: keyFile, MinVersion: "1.2"}
+
+	r, err := tlsconfig.New(cfg)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, r.Watch(ctx, func(err error) { t.Log(err) }))
+	addr := serve(t, r)
+
+	cn, err := dial(ca, addr)
+	require.NoError(t, err)
+	assert.Equal(t, "server-1", cn)
+
+	ca.WriteCert(t, dir, "server-2")
+	assert.Eventually(t, func() bool {
+		cn, err := dial(ca, addr)
+		return err == nil && cn == "server-2"
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestReloader_ClientAuth(t *testing.T) {
+	ca := tlstest.NewCA(t)
+	dir := t.TempDir()
+	certFile, keyFile := ca.WriteCert(t, dir, "server")
+	cfg := config.TLSConfig{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		MinVersion:   "1.2",
+		ClientCAFile: ca.WriteCA(t, dir),
+		ClientAuth:   "require",
+	}
+	r, err := tlsconfig.New(cfg)
+	require.NoError(t, err)
+	addr := serve(t, r)
+
+	_, err = dial(ca, addr)
+	assert.Error(t, err)
+	_, err = dial(ca, addr, tlstest.NewCA(t).Issue(t, "stranger"))
+	assert.Error(t, err)
+	_, err = dial(ca, addr, ca.Issue(t, "client"))
+	assert.NoError(t, err)
+
+	cfg.ClientAuth = "optional"
+	require.NoError(t, r.Reload(cfg))
+	_, err = dial(ca, addr)
+	assert.NoError(t, err)
+}
+
+func TestReloader_KeepsConfigOnError(t *testing.T) {
+	ca := tlstest.NewCA(t)
+	certFile, keyFile := ca.WriteCert(t, t.TempDir(), "server")
+	cfg := config.TLSConfig{CertFile: certFile, KeyFile: keyFile, MinVersion: "1.2"}
+
+	_, err := tlsconfig.New(config.TLSConfig{CertFile: certFile, KeyFile: certFile, MinVersion: "1.2"})
+	assert.Error(t, err)
+
+	r, err := tlsconfig.New(cfg)
+	require.NoError(t, err)
+	addr := serve(t, r)
+
+	cfg.CipherSuites = []string{"TLS_UNKNOWN"}
+	assert.Error(t, r.Reload(cfg))
+	cn, err := dial(ca, addr)
+	require.NoError(t, err)
+	assert.Equal(t, "server", cn)
+}
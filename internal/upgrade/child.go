@@ -0,0 +1,68 @@
+package upgrade
+
+import (
+	"encoding/json"
+	"fizzbuzz-server/internal/listener"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// Child is the side of a process started by Upgrade
+type Child struct {
+	listeners []listener.Named
+	ready     *os.File
+	state     *os.File
+}
+
+// Inherited returns the side of the new process when this process was
+// started by Upgrade, and nil otherwise. The upgrade variable is unset so
+// that a later upgrade starts afresh.
+func Inherited() (*Child, error) {
+	entries, ok := os.LookupEnv(envListeners)
+	if !ok {
+		return nil, nil
+	}
+	_ = os.Unsetenv(envListeners)
+
+	for _, fd := range []int{readyFD, stateFD} {
+		syscall.CloseOnExec(fd)
+	}
+	c := &Child{
+		ready: os.NewFile(readyFD, "upgrade-ready"),
+		state: os.NewFile(stateFD, "upgrade-state"),
+	}
+	lns, err := listener.Inherit(entries, firstListenerFD)
+	if err != nil {
+		c.release()
+		return nil, fmt.Errorf("upgrade: %w", err)
+	}
+	c.listeners = lns
+	return c, nil
+}
+
+// Listeners returns the inherited listeners
+func (c *Child) Listeners() []listener.Named {
+	return c.listeners
+}
+
+// Ready tells the old process that this one is serving, upon which it stops
+// serving and drains
+func (c *Child) Ready() error {
+	defer c.ready.Close()
+	_, err := c.ready.Write([]byte{1})
+	return err
+}
+
+// ReceiveState waits for the old process to drain and decodes the state it
+// sent into v
+func (c *Child) ReceiveState(v any) error {
+	defer c.state.Close()
+	return json.NewDecoder(c.state).Decode(v)
+}
+
+// release closes the upgrade descriptors
+func (c *Child) release() {
+	_ = c.ready.Close()
+	_ = c.state.Close()
+}
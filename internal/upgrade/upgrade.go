@@ -0,0 +1,135 @@
+// Package upgrade replaces the running server with a new process of its
+// binary without closing the listening sockets. The new process inherits the
+// sockets and reports when it is serving; the old one then drains and hands
+// over its in-memory state before exiting.
+package upgrade
+
+import (
+	"encoding/json"
+	"errors"
+	"fizzbuzz-server/internal/listener"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// envListeners lists the inherited listeners of an upgraded process, in
+// descriptor order, as name:owned or name:shared entries
+const envListeners = "FIZZBUZZ_UPGRADE_LISTENERS"
+
+// Descriptors passed to the new process
+const (
+	readyFD         = 3 // written by the new process once it is serving
+	stateFD         = 4 // state sent by the old process once it has drained
+	firstListenerFD = 5
+)
+
+// stateTimeout bounds the wait of the old process for the new one to read
+// the state
+const stateTimeout = 10 * time.Second
+
+// Handoff is an upgrade whose new process is serving
+type Handoff struct {
+	state *os.File
+	pid   int
+}
+
+// PID returns the process ID of the new process
+func (h *Handoff) PID() int {
+	return h.pid
+}
+
+// SendState sends v, JSON encoded, to the new process and completes the
+// upgrade. It is called once the old process stopped serving.
+func (h *Handoff) SendState(v any) error {
+	defer h.state.Close()
+	_ = h.state.SetWriteDeadline(time.Now().Add(stateTimeout))
+	return json.NewEncoder(h.state).Encode(v)
+}
+
+// Upgrade starts the running binary again, with the same arguments and
+// environment, handing it listeners. It returns once the new process is
+// serving, or an error when it exits or is not serving within timeout, in
+// which case the new process is killed and the listeners are unaffected.
+func Upgrade(listeners []listener.Named, timeout time.Duration) (*Handoff, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("upgrade: %w", err)
+	}
+
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("upgrade: %w", err)
+	}
+	defer readyR.Close()
+	stateR, stateW, err := os.Pipe()
+	if err != nil {
+		_ = readyW.Close()
+		return nil, fmt.Errorf("upgrade: %w", err)
+	}
+
+	lnFiles, entries, err := listener.Files(listeners)
+	if err != nil {
+		_ = readyW.Close()
+		_ = stateR.Close()
+		_ = stateW.Close()
+		return nil, fmt.Errorf("upgrade: %w", err)
+	}
+	// the descriptors of the new process, closed here once it started
+	files := append([]*os.File{readyW, stateR}, lnFiles...)
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	cmd.Env = append(os.Environ(), envListeners+"="+entries)
+	cmd.ExtraFiles = files
+	if err := cmd.Start(); err != nil {
+		_ = stateW.Close()
+		return nil, fmt.Errorf("upgrade: %w", err)
+	}
+
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	ready := make(chan error, 1)
+	go func() {
+		// the new process writes a byte once serving, the pipe is closed
+		// without one when it exits before
+		if _, err := readyR.Read(make([]byte, 1)); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = errors.New("new process exited before it was ready")
+			}
+			ready <- err
+			return
+		}
+		ready <- nil
+	}()
+
+	fail := func(err error) (*Handoff, error) {
+		_ = cmd.Process.Kill()
+		_ = stateW.Close()
+		return nil, fmt.Errorf("upgrade: %w", err)
+	}
+	// closing the parent copy lets the read end see the new process exit
+	_ = readyW.Close()
+	select {
+	case err := <-ready:
+		if err != nil {
+			return fail(err)
+		}
+	case err := <-exited:
+		return fail(fmt.Errorf("new process exited: %v", err))
+	case <-time.After(timeout):
+		return fail(fmt.Errorf("new process not ready after %s", timeout))
+	}
+
+	// the new process serves the sockets from now on, closing them here
+	// must not remove them
+	listener.Release(listeners)
+	return &Handoff{state: stateW, pid: cmd.Process.Pid}, nil
+}
@@ -0,0 +1,120 @@
+package upgrade
+
+import (
+	"bufio"
+	"fizzbuzz-server/internal/listener"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// envTestChild selects the behaviour of the test binary started by Upgrade
+const envTestChild = "FIZZBUZZ_UPGRADE_TEST_CHILD"
+
+// TestMain runs the test binary as the new process when started by Upgrade
+func TestMain(m *testing.M) {
+	child, err := Inherited()
+	if err != nil {
+		os.Exit(2)
+	}
+	if child != nil {
+		os.Exit(runChild(child))
+	}
+	os.Exit(m.Run())
+}
+
+// runChild serves the received state on each inherited listener, one
+// connection each
+func runChild(child *Child) int {
+	if os.Getenv(envTestChild) == "fail" {
+		return 1
+	}
+	if err := child.Ready(); err != nil {
+		return 1
+	}
+	var state string
+	if err := child.ReceiveState(&state); err != nil {
+		return 1
+	}
+	for _, l := range child.Listeners() {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return 1
+		}
+		_, _ = conn.Write([]byte(l.Name + " " + state + "\n"))
+		_ = conn.Close()
+		_ = l.Listener.Close()
+	}
+	return 0
+}
+
+func TestUpgrade(t *testing.T) {
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer tcp.Close()
+	path := filepath.Join(t.TempDir(), "admin.sock")
+	unix, err := net.Listen("unix", path)
+	require.NoError(t, err)
+
+	handoff, err := Upgrade([]listener.Named{
+		{Name: "server", Listener: tcp, Owned: true},
+		{Name: "admin", Listener: unix, Owned: true},
+	}, 10*time.Second)
+	require.NoError(t, err)
+	assert.NotEqual(t, os.Getpid(), handoff.PID())
+
+	// closing the old listeners leaves the sockets to the new process
+	require.NoError(t, tcp.Close())
+	require.NoError(t, unix.Close())
+	assert.FileExists(t, path)
+	require.NoError(t, handoff.SendState("handed-over"))
+
+	for _, dial := range []struct{ network, address, want string }{
+		{"tcp", tcp.Addr().String(), "server handed-over\n"},
+		{"unix", path, "admin handed-over\n"},
+	} {
+		conn, err := net.DialTimeout(dial.network, dial.address, 5*time.Second)
+		require.NoError(t, err)
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		_ = conn.Close()
+		require.NoError(t, err)
+		assert.Equal(t, dial.want, line)
+	}
+
+	// the owned socket is removed once the new process closes it
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return os.IsNotExist(err)
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestUpgrade_NewProcessFails(t *testing.T) {
+	t.Setenv(envTestChild, "fail")
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	_, err = Upgrade([]listener.Named{{Name: "server", Listener: ln, Owned: true}}, 10*time.Second)
+	assert.ErrorContains(t, err, "exited")
+
+	// the old process keeps serving
+	go func() {
+		if conn, err := ln.Accept(); err == nil {
+			_ = conn.Close()
+		}
+	}()
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	require.NoError(t, err)
+	_ = conn.Close()
+}
+
+func TestInherited_NotUpgraded(t *testing.T) {
+	child, err := Inherited()
+	require.NoError(t, err)
+	assert.Nil(t, child)
+}
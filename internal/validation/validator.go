@@ -0,0 +1,84 @@
+package validation
+
+import (
+	"context"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// CharacterClasses are the Unicode classes the "wordchars" rule can accept,
+// by name. Control and format characters are never accepted.
+var CharacterClasses = map[string]*unicode.RangeTable{
+	"letters":     unicode.L,
+	"marks":       unicode.M,
+	"numbers":     unicode.N,
+	"punctuation": unicode.P,
+	"symbols":     unicode.S,
+	"space":       unicode.Zs,
+}
+
+// Limits are the bounds checked by the application specific rules
+type Limits struct {
+	MaxLimit       int      // cap of the "maxlimit" rule
+	MaxWordLength  int      // longest string accepted by the "wordlen" rule in characters, 0 for no bound
+	WordCharacters []string // CharacterClasses accepted by the "wordchars" rule, every class when empty
+}
+
+type maxLimitKey struct{}
+
+// WithMaxLimit lowers the cap checked by the "maxlimit" rule to n for the
+// validations run with ctx, e.g. the cap of a tenant. A cap above the one of
+// the validator does not raise it.
+func WithMaxLimit(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, maxLimitKey{}, n)
+}
+
+// New returns a validator with the application specific rules registered.
+// limits provides the bounds of the rules; it is called on every validation
+// so changed bounds apply to the next request.
+func New(limits func() Limits) *validator.Validate {
+	validate := validator.New()
+
+	_ = validate.RegisterValidationCtx("maxlimit", func(ctx context.Context, fl validator.FieldLevel) bool {
+		limit := limits().MaxLimit
+		if n, ok := ctx.Value(maxLimitKey{}).(int); ok {
+			limit = min(limit, n)
+		}
+		return fl.Field().Int() <= int64(limit)
+	})
+
+	_ = validate.RegisterValidation("wordlen", func(fl validator.FieldLevel) bool {
+		maxLength := limits().MaxWordLength
+		return maxLength <= 0 || utf8.RuneCountInString(fl.Field().String()) <= maxLength
+	})
+
+	_ = validate.RegisterValidation("wordchars", func(fl validator.FieldLevel) bool {
+		return wordCharacters(fl.Field().String(), limits().WordCharacters)
+	})
+
+	return validate
+}
+
+// wordCharacters reports whether every character of s belongs to one of the
+// named classes
+func wordCharacters(s string, classes []string) bool {
+	tables := make([]*unicode.RangeTable, 0, len(CharacterClasses))
+	for _, name := range classes {
+		if table, ok := CharacterClasses[name]; ok {
+			tables = append(tables, table)
+		}
+	}
+	if len(classes) == 0 {
+		for _, table := range CharacterClasses {
+			tables = append(tables, table)
+		}
+	}
+	for _, r := range s {
+		if r == utf8.RuneError || !unicode.IsOneOf(tables, r) {
+			return false
+		}
+	}
+	return true
+}
@@ -2,7 +2,12 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	context "context"
+	iter "iter"
+
+	mock "github.com/stretchr/testify/mock"
+)
 
 // FizzBuzzServiceIface is an autogenerated mock type for the FizzBuzzServiceIface type
 type FizzBuzzServiceIface struct {
@@ -29,6 +34,76 @@ func (_m *FizzBuzzServiceIface) GenerateFizzBuzz(int1 int, int2 int, limit int,
 	return r0
 }
 
+// GenerateFizzBuzzContext provides a mock function with given fields: ctx, int1, int2, limit, str1, str2
+func (_m *FizzBuzzServiceIface) GenerateFizzBuzzContext(ctx context.Context, int1 int, int2 int, limit int, str1 string, str2 string) ([]string, error) {
+	ret := _m.Called(ctx, int1, int2, limit, str1, str2)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GenerateFizzBuzzContext")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int, string, string) ([]string, error)); ok {
+		return rf(ctx, int1, int2, limit, str1, str2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int, string, string) []string); ok {
+		r0 = rf(ctx, int1, int2, limit, str1, str2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, int, string, string) error); ok {
+		r1 = rf(ctx, int1, int2, limit, str1, str2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Sequence provides a mock function with given fields: int1, int2, limit, str1, str2
+func (_m *FizzBuzzServiceIface) Sequence(int1 int, int2 int, limit int, str1 string, str2 string) iter.Seq[string] {
+	ret := _m.Called(int1, int2, limit, str1, str2)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Sequence")
+	}
+
+	var r0 iter.Seq[string]
+	if rf, ok := ret.Get(0).(func(int, int, int, string, string) iter.Seq[string]); ok {
+		r0 = rf(int1, int2, limit, str1, str2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(iter.Seq[string])
+		}
+	}
+
+	return r0
+}
+
+// SequenceContext provides a mock function with given fields: ctx, int1, int2, limit, str1, str2
+func (_m *FizzBuzzServiceIface) SequenceContext(ctx context.Context, int1 int, int2 int, limit int, str1 string, str2 string) iter.Seq[string] {
+	ret := _m.Called(ctx, int1, int2, limit, str1, str2)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SequenceContext")
+	}
+
+	var r0 iter.Seq[string]
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int, string, string) iter.Seq[string]); ok {
+		r0 = rf(ctx, int1, int2, limit, str1, str2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(iter.Seq[string])
+		}
+	}
+
+	return r0
+}
+
 // NewFizzBuzzServiceIface creates a new instance of FizzBuzzServiceIface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewFizzBuzzServiceIface(t interface {
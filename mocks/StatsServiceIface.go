@@ -3,9 +3,13 @@
 package mocks
 
 import (
+	contracts "fizzbuzz-server/internal/apps/contracts"
+
 	entities "fizzbuzz-server/internal/entities"
 
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // StatsServiceIface is an autogenerated mock type for the StatsServiceIface type
@@ -13,6 +17,72 @@ type StatsServiceIface struct {
 	mock.Mock
 }
 
+// Clients provides a mock function with no fields
+func (_m *StatsServiceIface) Clients() []entities.ClientStats {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Clients")
+	}
+
+	var r0 []entities.ClientStats
+	if rf, ok := ret.Get(0).(func() []entities.ClientStats); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entities.ClientStats)
+		}
+	}
+
+	return r0
+}
+
+// Export provides a mock function with no fields
+func (_m *StatsServiceIface) Export() (entities.StatsSnapshot, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Export")
+	}
+
+	var r0 entities.StatsSnapshot
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (entities.StatsSnapshot, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() entities.StatsSnapshot); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(entities.StatsSnapshot)
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Import provides a mock function with given fields: snapshot, merge
+func (_m *StatsServiceIface) Import(snapshot entities.StatsSnapshot, merge bool) error {
+	ret := _m.Called(snapshot, merge)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Import")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(entities.StatsSnapshot, bool) error); ok {
+		r0 = rf(snapshot, merge)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ParseStatsKey provides a mock function with given fields: key
 func (_m *StatsServiceIface) ParseStatsKey(key string) (entities.StatsKeys, error) {
 	ret := _m.Called(key)
@@ -41,6 +111,111 @@ func (_m *StatsServiceIface) ParseStatsKey(key string) (entities.StatsKeys, erro
 	return r0, r1
 }
 
+// Record provides a mock function with given fields: req
+func (_m *StatsServiceIface) Record(req entities.FizzBuzzRequest) {
+	_m.Called(req)
+}
+
+// RecordClient provides a mock function with given fields: client
+func (_m *StatsServiceIface) RecordClient(client string) {
+	_m.Called(client)
+}
+
+// Reset provides a mock function with no fields
+func (_m *StatsServiceIface) Reset() {
+	_m.Called()
+}
+
+// Subscribe provides a mock function with given fields: fn
+func (_m *StatsServiceIface) Subscribe(fn func()) func() {
+	ret := _m.Called(fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Subscribe")
+	}
+
+	var r0 func()
+	if rf, ok := ret.Get(0).(func(func()) func()); ok {
+		r0 = rf(fn)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(func())
+		}
+	}
+
+	return r0
+}
+
+// Tenant provides a mock function with given fields: name
+func (_m *StatsServiceIface) Tenant(name string) contracts.StatsServiceIface {
+	ret := _m.Called(name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Tenant")
+	}
+
+	var r0 contracts.StatsServiceIface
+	if rf, ok := ret.Get(0).(func(string) contracts.StatsServiceIface); ok {
+		r0 = rf(name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(contracts.StatsServiceIface)
+		}
+	}
+
+	return r0
+}
+
+// Tenants provides a mock function with no fields
+func (_m *StatsServiceIface) Tenants() []string {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Tenants")
+	}
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func() []string); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	return r0
+}
+
+// Top provides a mock function with given fields: n, window
+func (_m *StatsServiceIface) Top(n int, window time.Duration) ([]entities.StatsEntry, error) {
+	ret := _m.Called(n, window)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Top")
+	}
+
+	var r0 []entities.StatsEntry
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int, time.Duration) ([]entities.StatsEntry, error)); ok {
+		return rf(n, window)
+	}
+	if rf, ok := ret.Get(0).(func(int, time.Duration) []entities.StatsEntry); ok {
+		r0 = rf(n, window)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entities.StatsEntry)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int, time.Duration) error); ok {
+		r1 = rf(n, window)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewStatsServiceIface creates a new instance of StatsServiceIface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewStatsServiceIface(t interface {
@@ -0,0 +1,191 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Generate returns the FizzBuzz sequence for p. Empty words use the server
+// defaults.
+func (c *Client) Generate(ctx context.Context, p Params, opts ...RequestOption) ([]string, error) {
+	var resp FizzBuzzResponse
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/fizzbuzz", query: paramsQuery(p)}, &resp, opts); err != nil {
+		return nil, err
+	}
+	return resp.Result, nil
+}
+
+// Stream yields the FizzBuzz sequence for p as the server sends it, without
+// holding the whole sequence in memory. Iteration stops at the first error,
+// which is yielded with an empty value.
+func (c *Client) Stream(ctx context.Context, p Params, opts ...RequestOption) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		query := paramsQuery(p)
+		query.Set("format", "ndjson")
+		resp, err := c.send(ctx, request{method: http.MethodGet, path: "/fizzbuzz", query: query}, opts)
+		if err != nil {
+			yield("", err)
+			return
+		}
+		defer resp.Body.Close()
+
+		dec := json.NewDecoder(resp.Body)
+		for {
+			var value string
+			if err := dec.Decode(&value); err != nil {
+				if err != io.EOF {
+					yield("", err)
+				}
+				return
+			}
+			if !yield(value, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Batch generates the sequences of several parameter sets in one request.
+// Results are in the order of params; invalid sets carry an error message
+// instead of failing the whole batch.
+func (c *Client) Batch(ctx context.Context, params []Params, opts ...RequestOption) ([]BatchResult, error) {
+	var resp []BatchResult
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/fizzbuzz/batch", body: params}, &resp, opts); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Stats returns the most frequent request, within window when it is not
+// zero. MostFrequentRequest has zero hits when no request has been made.
+func (c *Client) Stats(ctx context.Context, window time.Duration, opts ...RequestOption) (*StatsResponse, error) {
+	var resp StatsResponse
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/stats", query: windowQuery(window)}, &resp, opts); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Top returns the n most frequent requests, within window when it is not zero
+func (c *Client) Top(ctx context.Context, n int, window time.Duration, opts ...RequestOption) ([]StatsEntry, error) {
+	query := windowQuery(window)
+	query.Set("n", strconv.Itoa(n))
+
+	var resp TopStatsResponse
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/stats/top", query: query}, &resp, opts); err != nil {
+		return nil, err
+	}
+	return resp.Top, nil
+}
+
+// Health checks that the server is up
+func (c *Client) Health(ctx context.Context, opts ...RequestOption) (Health, error) {
+	var resp Health
+	err := c.do(ctx, request{method: http.MethodGet, path: "/health"}, &resp, opts)
+	return resp, err
+}
+
+// ResetStats clears the request statistics, those of every tenant included
+func (c *Client) ResetStats(ctx context.Context, opts ...RequestOption) error {
+	return c.do(ctx, request{method: http.MethodPost, path: "/admin/stats/reset"}, nil, opts)
+}
+
+// ExportStats returns the all-time request statistics, with those of each
+// tenant in Tenants
+func (c *Client) ExportStats(ctx context.Context, opts ...RequestOption) (StatsSnapshot, error) {
+	var resp StatsSnapshot
+	err := c.do(ctx, request{method: http.MethodGet, path: "/admin/stats/export"}, &resp, opts)
+	return resp, err
+}
+
+// ClientStats returns the requests of every client identified by its TLS
+// certificate, most active first
+func (c *Client) ClientStats(ctx context.Context, opts ...RequestOption) ([]ClientStats, error) {
+	var resp ClientStatsResponse
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/admin/stats/clients"}, &resp, opts); err != nil {
+		return nil, err
+	}
+	return resp.Clients, nil
+}
+
+// TenantStats returns the most frequent request of every tenant
+func (c *Client) TenantStats(ctx context.Context, opts ...RequestOption) ([]TenantStats, error) {
+	var resp TenantStatsResponse
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/admin/stats/tenants"}, &resp, opts); err != nil {
+		return nil, err
+	}
+	return resp.Tenants, nil
+}
+
+// ImportStats loads a snapshot, adding to the current counters when merge is
+// set and replacing them otherwise
+func (c *Client) ImportStats(ctx context.Context, snapshot StatsSnapshot, merge bool, opts ...RequestOption) error {
+	query := url.Values{}
+	query.Set("merge", strconv.FormatBool(merge))
+	return c.do(ctx, request{method: http.MethodPost, path: "/admin/stats/import", query: query, body: snapshot}, nil, opts)
+}
+
+// LogLevels returns the server log levels
+func (c *Client) LogLevels(ctx context.Context, opts ...RequestOption) (LogLevels, error) {
+	var resp LogLevels
+	err := c.do(ctx, request{method: http.MethodGet, path: "/admin/log-level"}, &resp, opts)
+	return resp, err
+}
+
+// SetLogLevel changes the default log level, or a component's level when
+// component is set; an empty level removes the component override
+func (c *Client) SetLogLevel(ctx context.Context, level, component string, opts ...RequestOption) (LogLevels, error) {
+	body := map[string]string{"level": level, "component": component}
+	var resp LogLevels
+	err := c.do(ctx, request{method: http.MethodPut, path: "/admin/log-level", body: body}, &resp, opts)
+	return resp, err
+}
+
+// Config returns the effective server configuration, secrets redacted
+func (c *Client) Config(ctx context.Context, opts ...RequestOption) (map[string]any, error) {
+	var resp map[string]any
+	err := c.do(ctx, request{method: http.MethodGet, path: "/admin/config"}, &resp, opts)
+	return resp, err
+}
+
+// ConfigVersion returns the version of the active server configuration
+func (c *Client) ConfigVersion(ctx context.Context, opts ...RequestOption) (ConfigVersion, error) {
+	var resp ConfigVersion
+	err := c.do(ctx, request{method: http.MethodGet, path: "/admin/config/version"}, &resp, opts)
+	return resp, err
+}
+
+// ReloadConfig asks the server to reload its configuration
+func (c *Client) ReloadConfig(ctx context.Context, opts ...RequestOption) (ConfigVersion, error) {
+	var resp ConfigVersion
+	err := c.do(ctx, request{method: http.MethodPost, path: "/admin/config/reload"}, &resp, opts)
+	return resp, err
+}
+
+func windowQuery(window time.Duration) url.Values {
+	query := url.Values{}
+	if window > 0 {
+		query.Set("window", window.String())
+	}
+	return query
+}
+
+func paramsQuery(p Params) url.Values {
+	query := url.Values{}
+	query.Set("int1", strconv.Itoa(p.Int1))
+	query.Set("int2", strconv.Itoa(p.Int2))
+	query.Set("limit", strconv.Itoa(p.Limit))
+	if p.Str1 != "" {
+		query.Set("str1", p.Str1)
+	}
+	if p.Str2 != "" {
+		query.Set("str2", p.Str2)
+	}
+	return query
+}
@@ -0,0 +1,305 @@
+// Package client is a typed Go client for the fizzbuzz-server HTTP API.
+//
+// Idempotent requests answered with 429 Too Many Requests or 503 Service
+// Unavailable are retried with exponential backoff, waiting for the
+// Retry-After delay when the server sends one; other requests are only retried
+// when the call opts in with WithRetryNonIdempotent. Every call honours the
+// cancellation of its context.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client calls a fizzbuzz-server instance
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	apiKey     string
+	tenant     string
+	adminToken string
+	userAgent  string
+	retry      RetryPolicy
+}
+
+// RetryPolicy controls how throttled or unavailable responses are retried
+type RetryPolicy struct {
+	MaxRetries int           // retries after the first attempt, 0 disables retrying
+	BaseDelay  time.Duration // delay before the first retry, doubled for each next one
+	MaxDelay   time.Duration // upper bound of the backoff and of Retry-After
+}
+
+// DefaultRetryPolicy is used unless WithRetryPolicy is given
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	BaseDelay:  100 * time.Millisecond,
+	MaxDelay:   10 * time.Second,
+}
+
+// Option customises a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the http.Client used for requests
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithAPIKey sends the key in the X-API-Key header of API requests
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// WithTenant makes the API requests under /tenants/<name>, counting them in
+// the stats of the tenant and reading those. Admin requests are not affected.
+func WithTenant(name string) Option {
+	return func(c *Client) {
+		c.tenant = name
+	}
+}
+
+// WithAdminToken sends the token as a bearer token on /admin requests
+func WithAdminToken(token string) Option {
+	return func(c *Client) {
+		c.adminToken = token
+	}
+}
+
+// WithUserAgent sets the User-Agent header
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithRetryPolicy replaces DefaultRetryPolicy
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// New returns a client for the server at baseURL, e.g. http://localhost:8080
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		userAgent:  "fizzbuzz-server-client",
+		retry:      DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// RequestOption customises a single call
+type RequestOption func(*requestOptions)
+
+type requestOptions struct {
+	header         http.Header
+	retry          *RetryPolicy
+	retryAnyMethod bool
+}
+
+// WithHeader adds a header to the request
+func WithHeader(key, value string) RequestOption {
+	return func(o *requestOptions) {
+		o.header.Add(key, value)
+	}
+}
+
+// WithRequestRetryPolicy overrides the client retry policy for one call
+func WithRequestRetryPolicy(policy RetryPolicy) RequestOption {
+	return func(o *requestOptions) {
+		o.retry = &policy
+	}
+}
+
+// WithRetryNonIdempotent lets the retry policy apply to a POST call, which is
+// otherwise never retried since the server may have applied it already
+func WithRetryNonIdempotent() RequestOption {
+	return func(o *requestOptions) {
+		o.retryAnyMethod = true
+	}
+}
+
+// Error is returned when the server answers with a non-2xx status
+type Error struct {
+	StatusCode int
+	Message    string
+	Type       string       // stable type of the problem, empty if the body is not one
+	Fields     []FieldError // parameters rejected by validation
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("fizzbuzz-server: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// request describes one API call
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	body   any
+}
+
+// do sends a request and decodes a JSON response into out, if not nil
+func (c *Client) do(ctx context.Context, r request, out any, opts []RequestOption) error {
+	resp, err := c.send(ctx, r, opts)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// send performs the request, retrying according to the retry policy, and
+// returns a 2xx response whose body the caller must close
+func (c *Client) send(ctx context.Context, r request, opts []RequestOption) (*http.Response, error) {
+	o := requestOptions{header: http.Header{}, retry: &c.retry}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var body []byte
+	if r.body != nil {
+		var err error
+		if body, err = json.Marshal(r.body); err != nil {
+			return nil, err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := c.newRequest(ctx, r, body, o.header)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+			return resp, nil
+		}
+
+		apiErr := decodeError(resp)
+		resp.Body.Close()
+		if !retryable(resp.StatusCode) || !(o.retryAnyMethod || idempotent(r.method)) || attempt >= o.retry.MaxRetries {
+			return nil, apiErr
+		}
+
+		timer := time.NewTimer(o.retry.delay(attempt, resp.Header.Get("Retry-After")))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) newRequest(ctx context.Context, r request, body []byte, header http.Header) (*http.Request, error) {
+	u := c.baseURL + r.path
+	if c.tenant != "" && !strings.HasPrefix(r.path, "/admin/") && r.path != "/health" {
+		u = c.baseURL + "/tenants/" + url.PathEscape(c.tenant) + r.path
+	}
+	if len(r.query) > 0 {
+		u += "?" + r.query.Encode()
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, r.method, u, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+	if c.adminToken != "" && strings.HasPrefix(r.path, "/admin/") {
+		req.Header.Set("Authorization", "Bearer "+c.adminToken)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	return req, nil
+}
+
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable
+}
+
+// idempotent reports whether repeating a request of the method has the same
+// effect as sending it once
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// delay returns how long to wait before the retry following attempt,
+// Retry-After (seconds or HTTP date) taking precedence over the backoff
+func (p RetryPolicy) delay(attempt int, retryAfter string) time.Duration {
+	if retryAfter != "" {
+		if secs, err := strconv.Atoi(retryAfter); err == nil && secs >= 0 {
+			return min(time.Duration(secs)*time.Second, p.MaxDelay)
+		}
+		if at, err := http.ParseTime(retryAfter); err == nil {
+			return min(max(time.Until(at), 0), p.MaxDelay)
+		}
+	}
+
+	backoff := p.BaseDelay << attempt
+	if backoff <= 0 || backoff > p.MaxDelay {
+		backoff = p.MaxDelay
+	}
+	// full jitter spreads retries of concurrent clients
+	return time.Duration(rand.Int64N(int64(backoff) + 1))
+}
+
+func decodeError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	apiErr := &Error{StatusCode: resp.StatusCode}
+
+	var problem Problem
+	if json.Unmarshal(data, &problem) != nil || problem.Type == "" {
+		apiErr.Message = strings.TrimSpace(string(data))
+		return apiErr
+	}
+	apiErr.Type = problem.Type
+	apiErr.Fields = problem.Errors
+	apiErr.Message = problem.Detail
+	if apiErr.Message == "" {
+		apiErr.Message = problem.Title
+	}
+	return apiErr
+}
@@ -0,0 +1,203 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_Generate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/fizzbuzz", r.URL.Path)
+		assert.Equal(t, "3", r.URL.Query().Get("int1"))
+		assert.False(t, r.URL.Query().Has("str1"))
+		assert.Equal(t, "secret", r.Header.Get("X-API-Key"))
+		_, _ = w.Write([]byte(`{"result":["1","2","fizz"]}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, WithAPIKey("secret"))
+	result, err := c.Generate(context.Background(), Params{Int1: 3, Int2: 5, Limit: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "2", "fizz"}, result)
+}
+
+func TestClient_Top(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/stats/top", r.URL.Path)
+		assert.Equal(t, "15m0s", r.URL.Query().Get("window"))
+		_, _ = w.Write([]byte(`{"top":[{"int1":3,"int2":5,"limit":15,"str1":"fizz","str2":"buzz","hits":4}]}`))
+	}))
+	defer srv.Close()
+
+	top, err := New(srv.URL).Top(context.Background(), 1, 15*time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, []StatsEntry{{Int1: 3, Int2: 5, Limit: 15, Str1: "fizz", Str2: "buzz", Hits: 4}}, top)
+}
+
+func TestClient_Tenant(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path == "/admin/stats/tenants" {
+			_, _ = w.Write([]byte(`{"tenants":[{"tenant":"acme","configured":true}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"top":[]}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, WithTenant("acme"))
+	_, err := c.Top(context.Background(), 1, 0)
+	assert.NoError(t, err)
+	tenants, err := c.TenantStats(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []TenantStats{{Tenant: "acme", Configured: true}}, tenants)
+	assert.Equal(t, []string{"/tenants/acme/stats/top", "/admin/stats/tenants"}, paths)
+}
+
+func TestClient_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer admin", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"type":"urn:fizzbuzz:problem:unauthorized","title":"Unauthorized","status":401,"detail":"Invalid or missing admin token"}`))
+	}))
+	defer srv.Close()
+
+	err := New(srv.URL, WithAdminToken("admin")).ResetStats(context.Background())
+	var apiErr *Error
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+	assert.Equal(t, "Invalid or missing admin token", apiErr.Message)
+	assert.Equal(t, "urn:fizzbuzz:problem:unauthorized", apiErr.Type)
+}
+
+func TestClient_Stats(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/stats", r.URL.Path)
+		assert.False(t, r.URL.Query().Has("window"))
+		_, _ = w.Write([]byte(`{"most_frequent_request":{"int1":3,"int2":5,"limit":15,"str1":"fizz","str2":"buzz","hits":2}}`))
+	}))
+	defer srv.Close()
+
+	stats, err := New(srv.URL).Stats(context.Background(), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stats.MostFrequentRequest.Hits)
+}
+
+func TestClient_Stream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "ndjson", r.URL.Query().Get("format"))
+		_, _ = w.Write([]byte("\"1\"\n\"2\"\n\"fizz\"\n"))
+	}))
+	defer srv.Close()
+
+	var values []string
+	for value, err := range New(srv.URL).Stream(context.Background(), Params{Int1: 3, Int2: 5, Limit: 3}) {
+		assert.NoError(t, err)
+		values = append(values, value)
+	}
+	assert.Equal(t, []string{"1", "2", "fizz"}, values)
+}
+
+func TestClient_Batch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/fizzbuzz/batch", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		_, _ = w.Write([]byte(`[{"result":["1","2"]},{"error":{"type":"urn:fizzbuzz:problem:validation-error","title":"Invalid parameters","status":400,"detail":"int1 must be at least 1","errors":[{"field":"int1","code":"min","message":"int1 must be at least 1","param":"1"}]}}]`))
+	}))
+	defer srv.Close()
+
+	results, err := New(srv.URL).Batch(context.Background(), []Params{{Int1: 3, Int2: 5, Limit: 2}, {}})
+	assert.NoError(t, err)
+	assert.Equal(t, []BatchResult{{Result: []string{"1", "2"}}, {Error: &Problem{
+		Type:   "urn:fizzbuzz:problem:validation-error",
+		Title:  "Invalid parameters",
+		Status: 400,
+		Detail: "int1 must be at least 1",
+		Errors: []FieldError{{Field: "int1", Code: "min", Message: "int1 must be at least 1", Param: "1"}},
+	}}}, results)
+}
+
+func TestClient_RetriesThrottledRequests(t *testing.T) {
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, `[{"int1":3,"int2":5,"limit":1,"str1":"","str2":""}]`, string(body))
+		if attempts < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"result":["1"]}]`))
+	}))
+	defer srv.Close()
+
+	_, err := New(srv.URL).Batch(context.Background(), []Params{{Int1: 3, Int2: 5, Limit: 1}}, WithRetryNonIdempotent())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestClient_DoesNotRetryNonIdempotentRequests(t *testing.T) {
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	err := New(srv.URL).ImportStats(context.Background(), StatsSnapshot{}, true)
+	var apiErr *Error
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestClient_RetryGivesUp(t *testing.T) {
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	policy := RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+	_, err := New(srv.URL, WithRetryPolicy(policy)).Health(context.Background())
+	var apiErr *Error
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestClient_RetryHonoursContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "5")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := New(srv.URL).Health(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	p := RetryPolicy{MaxRetries: 3, BaseDelay: 100 * time.Millisecond, MaxDelay: 2 * time.Second}
+	assert.Equal(t, time.Second, p.delay(0, "1"))
+	assert.Equal(t, 2*time.Second, p.delay(0, "60"))
+	assert.LessOrEqual(t, p.delay(2, ""), 400*time.Millisecond)
+	assert.LessOrEqual(t, p.delay(10, ""), 2*time.Second)
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"fizzbuzz-server/internal/entities"
+	"time"
+)
+
+// Params are the parameters of a FizzBuzz sequence
+type Params = entities.FizzBuzzRequest
+
+// StatsEntry is a parameter set with its number of hits
+type StatsEntry = entities.StatsEntry
+
+// StatsResponse is the response of /stats
+type StatsResponse = entities.StatsResponse
+
+// TopStatsResponse is the response of /stats/top
+type TopStatsResponse = entities.TopStatsResponse
+
+// FizzBuzzResponse is the JSON response of /fizzbuzz
+type FizzBuzzResponse = entities.FizzBuzzResponse
+
+// StatsSnapshot is the export format of the request statistics
+type StatsSnapshot = entities.StatsSnapshot
+
+// ClientStats is the number of requests made by an identified client
+type ClientStats = entities.ClientStats
+
+// ClientStatsResponse is the body of /admin/stats/clients
+type ClientStatsResponse = entities.ClientStatsResponse
+
+// TenantStats sums up the requests of one tenant
+type TenantStats = entities.TenantStats
+
+// TenantStatsResponse is the body of /admin/stats/tenants
+type TenantStatsResponse = entities.TenantStatsResponse
+
+// BatchResult is the outcome of one parameter set of a Batch call
+type BatchResult = entities.BatchResult
+
+// Problem is the body of the error responses
+type Problem = entities.Problem
+
+// FieldError describes a parameter rejected by validation
+type FieldError = entities.ProblemFieldError
+
+// Health is the response of /health
+type Health struct {
+	Status        string `json:"status"`
+	Uptime        string `json:"uptime"`
+	ConfigVersion uint64 `json:"config_version"`
+}
+
+// LogLevels reports the default log level and per-component overrides
+type LogLevels struct {
+	Level      string            `json:"level"`
+	Components map[string]string `json:"components"`
+}
+
+// ConfigVersion describes the configuration active on the server
+type ConfigVersion struct {
+	Version  uint64    `json:"version"`
+	LoadedAt time.Time `json:"loaded_at"`
+	File     string    `json:"file,omitempty"`
+}
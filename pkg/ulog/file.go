@@ -0,0 +1,42 @@
+package ulog
+
+import (
+	"io"
+
+	"github.com/rs/zerolog"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// FileConfig describes a rotating log file sink
+type FileConfig struct {
+	Path       string
+	MaxSizeMB  int  // rotate once the file reaches this size
+	MaxAgeDays int  // remove rotated files older than this, 0 keeps them
+	MaxBackups int  // number of rotated files to keep, 0 keeps all
+	Compress   bool // gzip rotated files
+}
+
+func (fc FileConfig) writer() *lumberjack.Logger {
+	return &lumberjack.Logger{
+		Filename:   fc.Path,
+		MaxSize:    fc.MaxSizeMB,
+		MaxAge:     fc.MaxAgeDays,
+		MaxBackups: fc.MaxBackups,
+		Compress:   fc.Compress,
+		LocalTime:  true,
+	}
+}
+
+// minLevelWriter only forwards events at or above min, used for the error
+// file; events without a level, e.g. from Log or Print, are dropped
+type minLevelWriter struct {
+	io.Writer
+	min zerolog.Level
+}
+
+func (w minLevelWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if level < w.min || level == zerolog.NoLevel {
+		return len(p), nil
+	}
+	return w.Write(p)
+}
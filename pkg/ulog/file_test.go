@@ -0,0 +1,47 @@
+package ulog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogInit_FileAndErrorFile(t *testing.T) {
+	dir := t.TempDir()
+	all := filepath.Join(dir, "server.log")
+	errs := filepath.Join(dir, "error.log")
+
+	LogInit(WithFile(FileConfig{Path: all, MaxSizeMB: 1}), WithErrorFile(FileConfig{Path: errs, MaxSizeMB: 1}))
+	Info("request served")
+	Error("request failed")
+	assert.NoError(t, Close())
+
+	allLog, err := os.ReadFile(all)
+	assert.NoError(t, err)
+	assert.Contains(t, string(allLog), "request served")
+	assert.Contains(t, string(allLog), "request failed")
+
+	errLog, err := os.ReadFile(errs)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(errLog), "request served")
+	assert.Contains(t, string(errLog), "request failed")
+}
+
+func TestMinLevelWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := minLevelWriter{Writer: &buf, min: zerolog.ErrorLevel}
+
+	for _, level := range []zerolog.Level{zerolog.InfoLevel, zerolog.WarnLevel, zerolog.NoLevel} {
+		_, err := w.WriteLevel(level, []byte(level.String()+"\n"))
+		assert.NoError(t, err)
+	}
+	for _, level := range []zerolog.Level{zerolog.ErrorLevel, zerolog.FatalLevel} {
+		_, err := w.WriteLevel(level, []byte(level.String()+"\n"))
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, "error\nfatal\n", buf.String())
+}
@@ -0,0 +1,128 @@
+package ulog
+
+import (
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// Levels are tracked here rather than on the loggers themselves so they can be
+// changed at runtime. zerolog's global level is kept at the most verbose level
+// in use and each logger drops events below its own effective level via a hook.
+var (
+	levelMu         sync.RWMutex
+	baseLevel       = zerolog.DebugLevel
+	componentLevels = map[string]zerolog.Level{}
+)
+
+// levelHook discards events below the effective level of a component,
+// the empty component standing for the root logger
+type levelHook struct {
+	component string
+}
+
+func (h levelHook) Run(e *zerolog.Event, level zerolog.Level, _ string) {
+	if level != zerolog.NoLevel && level < effectiveLevel(h.component) {
+		e.Discard()
+	}
+}
+
+func effectiveLevel(component string) zerolog.Level {
+	levelMu.RLock()
+	defer levelMu.RUnlock()
+	if lvl, ok := componentLevels[component]; ok && component != "" {
+		return lvl
+	}
+	return baseLevel
+}
+
+// Component returns a logger tagged with the component name whose level can
+// be overridden independently with SetComponentLevel
+func Component(name string) zerolog.Logger {
+	return rootLogger.With().Str("component", name).Logger().Hook(levelHook{component: name})
+}
+
+// Level returns the default log level
+func Level() zerolog.Level {
+	return effectiveLevel("")
+}
+
+// SetLevel changes the default log level
+func SetLevel(level zerolog.Level) {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+	baseLevel = level
+	syncGlobalLevel()
+}
+
+// SetComponentLevel overrides the log level of a single component
+func SetComponentLevel(component string, level zerolog.Level) {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+	componentLevels[component] = level
+	syncGlobalLevel()
+}
+
+// ClearComponentLevel makes a component follow the default level again
+func ClearComponentLevel(component string) {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+	delete(componentLevels, component)
+	syncGlobalLevel()
+}
+
+// ComponentLevels returns a copy of the per-component overrides
+func ComponentLevels() map[string]zerolog.Level {
+	levelMu.RLock()
+	defer levelMu.RUnlock()
+	levels := make(map[string]zerolog.Level, len(componentLevels))
+	for k, v := range componentLevels {
+		levels[k] = v
+	}
+	return levels
+}
+
+// IncreaseVerbosity lowers the default level by one step, down to trace
+func IncreaseVerbosity() zerolog.Level {
+	lvl := Level()
+	if lvl > zerolog.TraceLevel {
+		lvl--
+	}
+	SetLevel(lvl)
+	return lvl
+}
+
+// DecreaseVerbosity raises the default level by one step, up to error
+func DecreaseVerbosity() zerolog.Level {
+	lvl := Level()
+	if lvl < zerolog.ErrorLevel {
+		lvl++
+	}
+	SetLevel(lvl)
+	return lvl
+}
+
+// CycleVerbosity lowers the default level by one step like
+// IncreaseVerbosity, going from trace back to error, so that repeating it
+// reaches any level from error to trace
+func CycleVerbosity() zerolog.Level {
+	lvl := Level()
+	if lvl <= zerolog.TraceLevel || lvl > zerolog.ErrorLevel {
+		lvl = zerolog.ErrorLevel
+	} else {
+		lvl--
+	}
+	SetLevel(lvl)
+	return lvl
+}
+
+// syncGlobalLevel must be called with levelMu held
+func syncGlobalLevel() {
+	lowest := baseLevel
+	for _, lvl := range componentLevels {
+		if lvl < lowest {
+			lowest = lvl
+		}
+	}
+	zerolog.SetGlobalLevel(lowest)
+}
@@ -0,0 +1,26 @@
+package ulog
+
+import (
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCycleVerbosity(t *testing.T) {
+	t.Cleanup(func() { SetLevel(zerolog.DebugLevel) })
+
+	SetLevel(zerolog.InfoLevel)
+	var levels []zerolog.Level
+	for range 6 {
+		levels = append(levels, CycleVerbosity())
+	}
+	assert.Equal(t, []zerolog.Level{
+		zerolog.DebugLevel, zerolog.TraceLevel,
+		zerolog.ErrorLevel, zerolog.WarnLevel, zerolog.InfoLevel, zerolog.DebugLevel,
+	}, levels)
+
+	// levels above error cycle from error as well
+	SetLevel(zerolog.Disabled)
+	assert.Equal(t, zerolog.ErrorLevel, CycleVerbosity())
+}
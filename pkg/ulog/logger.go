@@ -2,6 +2,8 @@ package ulog
 
 import (
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"runtime/debug"
 	"strings"
@@ -11,26 +13,59 @@ import (
 )
 
 var (
+	rootLogger  zerolog.Logger
 	logger      zerolog.Logger
+	slogLogger  zerolog.Logger
 	gitRevision string
 	buildInfo   *debug.BuildInfo
+	closers     []io.Closer
 )
 
-func LogInit() {
-	// Console Writer (for stdout)
-	consoleWriter := zerolog.ConsoleWriter{
+func LogInit(opts ...Option) {
+	o := options{level: zerolog.DebugLevel}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// Console Writer (for stdout), or the slog backend when one is configured
+	var consoleWriter io.Writer = zerolog.ConsoleWriter{
 		Out:        os.Stdout,
 		TimeFormat: time.RFC3339,
 	}
+	if o.slogHandler != nil {
+		consoleWriter = slogWriter{handler: o.slogHandler}
+	}
+
+	// Release file sinks from a previous LogInit
+	_ = Close()
 
 	// Combine Writers (Console + File)
-	multiWriter := zerolog.MultiLevelWriter(consoleWriter)
+	writers := []io.Writer{consoleWriter}
+	if o.file != nil && o.file.Path != "" {
+		fileWriter := o.file.writer()
+		closers = append(closers, fileWriter)
+		writers = append(writers, fileWriter)
+	}
+	if o.errorFile != nil && o.errorFile.Path != "" {
+		errorWriter := o.errorFile.writer()
+		closers = append(closers, errorWriter)
+		writers = append(writers, minLevelWriter{Writer: errorWriter, min: zerolog.ErrorLevel})
+	}
+	multiWriter := zerolog.MultiLevelWriter(writers...)
 
-	// Create the logger
-	logger = zerolog.New(multiWriter).With().Timestamp().Logger()
+	// Create the logger, component loggers derive from the unhooked root.
+	// The slog handler stamps its events with the time of the records.
+	slogLogger = zerolog.New(multiWriter)
+	rootLogger = slogLogger.With().Timestamp().Logger()
+	logger = rootLogger.Hook(levelHook{})
 
-	// Set global log level (optional)
-	zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	// Set global log level, it can be changed later at runtime
+	SetLevel(o.level)
+
+	// Send log/slog (and the standard log package) through ulog as well
+	if o.slogHandler == nil {
+		slog.SetDefault(slog.New(NewSlogHandler()))
+	}
 
 	// default fields, remover if not needed
 	// Get build info
@@ -52,6 +87,18 @@ func LogInit() {
 	//	Logger()
 }
 
+// Close flushes and closes the file sinks opened by LogInit
+func Close() error {
+	var firstErr error
+	for _, c := range closers {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	closers = nil
+	return firstErr
+}
+
 func Info(msg string, fields ...interface{}) {
 	if len(fields) > 0 {
 		var sb strings.Builder
@@ -0,0 +1,47 @@
+package ulog
+
+import (
+	"log/slog"
+
+	"github.com/rs/zerolog"
+)
+
+// Option customises the logger built by LogInit
+type Option func(*options)
+
+type options struct {
+	slogHandler slog.Handler
+	file        *FileConfig
+	errorFile   *FileConfig
+	level       zerolog.Level
+}
+
+// WithSlogHandler routes every ulog event to the given slog.Handler instead of
+// the console writer. The caller owns slog's default logger in that case, so
+// LogInit leaves it untouched to avoid a ulog -> slog -> ulog loop.
+func WithSlogHandler(h slog.Handler) Option {
+	return func(o *options) {
+		o.slogHandler = h
+	}
+}
+
+// WithFile writes every event, as JSON, to a rotating file next to stdout
+func WithFile(fc FileConfig) Option {
+	return func(o *options) {
+		o.file = &fc
+	}
+}
+
+// WithErrorFile writes error and more severe events to a separate rotating file
+func WithErrorFile(fc FileConfig) Option {
+	return func(o *options) {
+		o.errorFile = &fc
+	}
+}
+
+// WithLevel sets the initial default log level, debug when not given
+func WithLevel(level zerolog.Level) Option {
+	return func(o *options) {
+		o.level = level
+	}
+}
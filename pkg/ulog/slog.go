@@ -0,0 +1,241 @@
+package ulog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// SlogHandler is a slog.Handler that writes records through the ulog logger,
+// so code written against log/slog shares fields, levels and sinks with ulog
+type SlogHandler struct {
+	attrs  []slog.Attr
+	groups []string
+	// component is the value of a top-level "component" attribute, whose
+	// level applies to the records as it does to ulog.Component loggers
+	component string
+}
+
+// NewSlogHandler returns a slog.Handler backed by the ulog logger
+func NewSlogHandler() *SlogHandler {
+	return &SlogHandler{}
+}
+
+// Enabled reports whether the ulog logger would emit an event at level for
+// the component of the handler
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return zerologLevel(level) >= effectiveLevel(h.component)
+}
+
+// Handle converts the record into a zerolog event timed at the record and
+// writes it
+func (h *SlogHandler) Handle(_ context.Context, r slog.Record) error {
+	l := slogLogger.Hook(levelHook{component: h.component})
+	e := l.WithLevel(zerologLevel(r.Level))
+	if e == nil {
+		return nil
+	}
+	if !r.Time.IsZero() {
+		e.Time(zerolog.TimestampFieldName, r.Time)
+	}
+	for _, a := range h.attrs {
+		addAttr(e, "", a)
+	}
+	prefix := groupPrefix(h.groups)
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(e, prefix, a)
+		return true
+	})
+	e.Msg(r.Message)
+	return nil
+}
+
+// WithAttrs returns a handler that adds attrs to every record
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	prefix := groupPrefix(h.groups)
+	nh := &SlogHandler{groups: h.groups, component: h.component}
+	nh.attrs = append(nh.attrs, h.attrs...)
+	for _, a := range attrs {
+		if a.Key == "component" && prefix == "" && a.Value.Kind() == slog.KindString {
+			nh.component = a.Value.String()
+		}
+		a.Key = prefix + a.Key
+		nh.attrs = append(nh.attrs, a)
+	}
+	return nh
+}
+
+// WithGroup returns a handler that qualifies later attribute keys with name
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	nh := &SlogHandler{attrs: h.attrs, component: h.component}
+	nh.groups = append(append(nh.groups, h.groups...), name)
+	return nh
+}
+
+// groups are flattened into dotted keys, e.g. "http.status"
+func groupPrefix(groups []string) string {
+	if len(groups) == 0 {
+		return ""
+	}
+	return strings.Join(groups, ".") + "."
+}
+
+func addAttr(e *zerolog.Event, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	key := prefix + a.Key
+	switch a.Value.Kind() {
+	case slog.KindString:
+		e.Str(key, a.Value.String())
+	case slog.KindInt64:
+		e.Int64(key, a.Value.Int64())
+	case slog.KindUint64:
+		e.Uint64(key, a.Value.Uint64())
+	case slog.KindFloat64:
+		e.Float64(key, a.Value.Float64())
+	case slog.KindBool:
+		e.Bool(key, a.Value.Bool())
+	case slog.KindDuration:
+		e.Dur(key, a.Value.Duration())
+	case slog.KindTime:
+		e.Time(key, a.Value.Time())
+	case slog.KindGroup:
+		groupKey := key + "."
+		if a.Key == "" {
+			// inline group, attributes belong to the current level
+			groupKey = prefix
+		}
+		for _, ga := range a.Value.Group() {
+			addAttr(e, groupKey, ga)
+		}
+	default:
+		if err, ok := a.Value.Any().(error); ok {
+			e.AnErr(key, err)
+			return
+		}
+		e.Interface(key, a.Value.Any())
+	}
+}
+
+func zerologLevel(level slog.Level) zerolog.Level {
+	switch {
+	case level < slog.LevelDebug:
+		return zerolog.TraceLevel
+	case level < slog.LevelInfo:
+		return zerolog.DebugLevel
+	case level < slog.LevelWarn:
+		return zerolog.InfoLevel
+	case level < slog.LevelError:
+		return zerolog.WarnLevel
+	default:
+		return zerolog.ErrorLevel
+	}
+}
+
+func slogLevel(level zerolog.Level) slog.Level {
+	switch level {
+	case zerolog.TraceLevel:
+		return slog.LevelDebug - 4
+	case zerolog.DebugLevel:
+		return slog.LevelDebug
+	case zerolog.WarnLevel:
+		return slog.LevelWarn
+	case zerolog.ErrorLevel, zerolog.FatalLevel, zerolog.PanicLevel:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// slogWriter is a zerolog.LevelWriter that decodes each JSON event and hands
+// it to a slog.Handler, letting ulog run on top of any slog backend
+type slogWriter struct {
+	handler slog.Handler
+}
+
+func (w slogWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (w slogWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	fields := map[string]any{}
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber()
+	if err := dec.Decode(&fields); err != nil {
+		return 0, err
+	}
+
+	lvl := slogLevel(level)
+	if level == zerolog.NoLevel {
+		if s, ok := fields[zerolog.LevelFieldName].(string); ok {
+			if parsed, err := zerolog.ParseLevel(s); err == nil {
+				lvl = slogLevel(parsed)
+			}
+		}
+	}
+	ctx := context.Background()
+	if !w.handler.Enabled(ctx, lvl) {
+		return len(p), nil
+	}
+
+	ts := time.Now()
+	if s, ok := fields[zerolog.TimestampFieldName].(string); ok {
+		if parsed, err := time.Parse(zerolog.TimeFieldFormat, s); err == nil {
+			ts = parsed
+		}
+	}
+	msg, _ := fields[zerolog.MessageFieldName].(string)
+	delete(fields, zerolog.LevelFieldName)
+	delete(fields, zerolog.TimestampFieldName)
+	delete(fields, zerolog.MessageFieldName)
+
+	r := slog.NewRecord(ts, lvl, msg, 0)
+	r.AddAttrs(jsonAttrs(fields)...)
+	if err := w.handler.Handle(ctx, r); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func jsonAttrs(fields map[string]any) []slog.Attr {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]slog.Attr, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, jsonAttr(k, fields[k]))
+	}
+	return attrs
+}
+
+func jsonAttr(key string, v any) slog.Attr {
+	switch val := v.(type) {
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return slog.Int64(key, i)
+		}
+		f, _ := val.Float64()
+		return slog.Float64(key, f)
+	case map[string]any:
+		return slog.Attr{Key: key, Value: slog.GroupValue(jsonAttrs(val)...)}
+	default:
+		return slog.Any(key, val)
+	}
+}
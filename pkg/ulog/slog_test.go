@@ -0,0 +1,80 @@
+package ulog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlogHandler_WritesThroughUlog(t *testing.T) {
+	var buf bytes.Buffer
+	slogLogger = zerolog.New(&buf)
+
+	l := slog.New(NewSlogHandler()).With("component", "http").WithGroup("req")
+	l.Warn("slow request", "status", 200, "err", errors.New("boom"))
+
+	var event map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &event))
+	assert.Equal(t, "warn", event["level"])
+	assert.Equal(t, "slow request", event["message"])
+	assert.Equal(t, "http", event["component"])
+	assert.Equal(t, float64(200), event["req.status"])
+	assert.Equal(t, "boom", event["req.err"])
+}
+
+func TestLogInit_WithSlogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	LogInit(WithSlogHandler(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	logger.Error().Int("hits", 3).Msg("stats failed")
+
+	var record map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "ERROR", record["level"])
+	assert.Equal(t, "stats failed", record["msg"])
+	assert.Equal(t, float64(3), record["hits"])
+}
+
+func TestSlogHandler_Enabled(t *testing.T) {
+	previous := Level()
+	SetLevel(zerolog.InfoLevel)
+	SetComponentLevel("http", zerolog.DebugLevel)
+	t.Cleanup(func() {
+		ClearComponentLevel("http")
+		SetLevel(previous)
+	})
+
+	h := NewSlogHandler()
+	assert.False(t, h.Enabled(context.Background(), slog.LevelDebug))
+	assert.True(t, h.Enabled(context.Background(), slog.LevelError))
+
+	// the level of a component overrides the default one
+	ch := h.WithAttrs([]slog.Attr{slog.String("component", "http")}).WithGroup("req")
+	assert.True(t, ch.Enabled(context.Background(), slog.LevelDebug))
+	assert.False(t, ch.Enabled(context.Background(), slog.LevelDebug-4))
+
+	var buf bytes.Buffer
+	slogLogger = zerolog.New(&buf)
+	slog.New(ch).Debug("request")
+	assert.Contains(t, buf.String(), `"message":"request"`)
+}
+
+func TestSlogHandler_RecordTime(t *testing.T) {
+	var buf bytes.Buffer
+	slogLogger = zerolog.New(&buf)
+
+	at := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	r := slog.NewRecord(at, slog.LevelInfo, "replayed", 0)
+	assert.NoError(t, NewSlogHandler().Handle(context.Background(), r))
+
+	var event map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &event))
+	assert.Equal(t, at.Format(zerolog.TimeFieldFormat), event["time"])
+}